@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"smart_contract/pkg/smart-contract/contract"
+)
+
+// Compiles a Solidity contract with solc and writes abigen-style Go bindings for it, along with the
+// hash of the source they were generated from so a stale build can be detected
+func main() {
+	sol := flag.String("sol", "pkg/smart-contract/contract/EscrowService.sol", "Solidity source to compile")
+	name := flag.String("contract", "EscrowService", "contract to generate bindings for")
+	pkg := flag.String("pkg", "contract", "Go package of the bindings")
+	out := flag.String("out", "pkg/smart-contract/contract/escrow_service.go", "bindings file to write")
+	solc := flag.String("solc", "solc", "solc binary to compile with")
+	flag.Parse()
+
+	artifact, err := contract.Compile(context.Background(), *solc, *sol, *name)
+	if err != nil {
+		log.Fatalf("Failed to compile contract: %v", err)
+	}
+	hash, err := contract.SourceHash(*sol)
+	if err != nil {
+		log.Fatalf("Failed to hash contract source: %v", err)
+	}
+
+	code, err := bind.Bind([]string{artifact.Name}, []string{artifact.ABI}, []string{artifact.Bin},
+		[]map[string]string{artifact.Hashes}, *pkg, bind.LangGo, nil, nil)
+	if err != nil {
+		log.Fatalf("Failed to generate bindings: %v", err)
+	}
+	if err := os.WriteFile(*out, []byte(code), 0644); err != nil {
+		log.Fatalf("Failed to write bindings: %v", err)
+	}
+
+	source, err := format.Source([]byte(fmt.Sprintf(`// Code generated by cmd/bindgen - DO NOT EDIT.
+
+package %s
+
+// SHA-256 of the %s source the %s bindings were generated from
+const %sSourceHash = %q
+`, *pkg, filepath.Base(*sol), artifact.Name, artifact.Name, hash)))
+	if err != nil {
+		log.Fatalf("Failed to format source hash: %v", err)
+	}
+	hashFile := strings.TrimSuffix(*out, ".go") + "_source.go"
+	if err := os.WriteFile(hashFile, source, 0644); err != nil {
+		log.Fatalf("Failed to write source hash: %v", err)
+	}
+
+	log.Printf("Wrote %s bindings to %s and %s", artifact.Name, *out, hashFile)
+}
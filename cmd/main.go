@@ -38,7 +38,7 @@ func main() {
 
   // Create files
   for file, content := range files {
-    err := ioutil.WriteFile(filepath.Join(projectDir, file), []byte(content), 0644)
+    err := os.WriteFile(filepath.Join(projectDir, file), []byte(content), 0644)
     if err != nil {
       fmt.Printf("Failed to create file %s: %v\n", file, err)
       return
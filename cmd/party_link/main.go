@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"smart_contract/pkg/email"
+)
+
+// Prints a signed dashboard link for a party of a contract, e.g. so an admin can resolve a cancellation
+func main() {
+	contractID := flag.Int("contract", 0, "ID of the contract")
+	party := flag.String("party", "admin", `party to act as: "client", "freelancer" or "admin"`)
+	flag.Parse()
+
+	secret := os.Getenv("PARTY_LINK_SECRET")
+	if secret == "" || *contractID == 0 {
+		log.Fatalf("Usage: PARTY_LINK_SECRET=... party_link -contract ID [-party admin]")
+	}
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	links := &email.PartyLinks{BaseURL: baseURL, Secret: []byte(secret)}
+	fmt.Println(links.Dashboard(*contractID, *party))
+}
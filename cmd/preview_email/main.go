@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"smart_contract/pkg/email"
+	"smart_contract/pkg/money"
+)
+
+// Sample data for each template, so previews show every part filled in
+var samples = map[string]func() (*email.Rendered, error){
+	"escrow_initiated": func() (*email.Rendered, error) {
+		return email.GenerateEmail(email.EmailData{
+			Locale:          *locale,
+			ClientFirstName: "John",
+			UserFirstName:   "Jane",
+			Requirements:    "- Create a login system\n- Implement payment gateway",
+			PaymentLink:     "https://example.com/payment",
+			DashboardLink:   "https://example.com/dashboard",
+		})
+	},
+	"requirements_confirmed": func() (*email.Rendered, error) {
+		quoted := amount("500 USD")
+		return email.RequirementsConfirmed.Render(*locale, email.RequirementsConfirmedData{
+			Recipient: recipient, AmountDue: amount("725 MATIC"), Quoted: &quoted, ExpiresAt: time.Now().Add(15 * time.Minute),
+		})
+	},
+	"payment_received": func() (*email.Rendered, error) {
+		return email.PaymentReceived.Render(*locale, email.PaymentReceivedData{Recipient: recipient, Amount: amount("1.5 ETH"), InvoiceNumber: "INV-2026-000001"})
+	},
+	"milestone_completed": func() (*email.Rendered, error) {
+		return email.MilestoneCompleted.Render(*locale, email.MilestoneCompletedData{Recipient: recipient})
+	},
+	"dispute_opened": func() (*email.Rendered, error) {
+		return email.DisputeOpened.Render(*locale, email.DisputeOpenedData{Recipient: recipient})
+	},
+	"dispute_resolved": func() (*email.Rendered, error) {
+		return email.DisputeResolved.Render(*locale, email.DisputeResolvedData{Recipient: recipient, RequirementsCompleted: true})
+	},
+	"funds_released": func() (*email.Rendered, error) {
+		return email.FundsReleased.Render(*locale, email.FundsReleasedData{Recipient: recipient, Amount: amount("1.4625 ETH"), ReceiptNumber: "RCT-2026-000001"})
+	},
+	"refund_issued": func() (*email.Rendered, error) {
+		return email.RefundIssued.Render(*locale, email.RefundIssuedData{Recipient: recipient, Amount: amount("1.5 ETH")})
+	},
+	"contract_cancelled": func() (*email.Rendered, error) {
+		return email.ContractCancelled.Render(*locale, email.ContractCancelledData{Recipient: recipient})
+	},
+	"cancellation_requested": func() (*email.Rendered, error) {
+		return email.CancellationRequested.Render(*locale, email.CancellationRequestedData{Recipient: recipient, RequestedBy: "client", Reason: "The project was put on hold."})
+	},
+	"contract_expiring": func() (*email.Rendered, error) {
+		return email.ContractExpiring.Render(*locale, email.ContractExpiringData{Recipient: recipient, AmountDue: amount("725 MATIC"), ExpiresAt: time.Now().Add(5 * time.Minute)})
+	},
+	"client_email_bounced": func() (*email.Rendered, error) {
+		return email.ClientEmailBounced.Render(*locale, email.ClientEmailBouncedData{Recipient: recipient, ClientName: "Jane", ClientEmail: "jane@example.com", Reason: "550 5.1.1 User unknown"})
+	},
+}
+
+var locale = flag.String("locale", "en", "locale to render the templates in")
+
+var recipient = email.Recipient{FirstName: "John", ContractID: 42, DashboardLink: "https://example.com/dashboard"}
+
+func amount(s string) money.Amount {
+	a, err := money.Parse(s)
+	if err != nil {
+		log.Fatalf("Invalid sample amount %q: %v", s, err)
+	}
+	return a
+}
+
+// Renders email templates with sample data and writes the plain text and HTML parts to disk for review
+func main() {
+	name := flag.String("template", "escrow_initiated", `template to preview, or "all"`)
+	out := flag.String("out", "email_preview", "directory to write the previews to")
+	flag.Parse()
+
+	names := []string{*name}
+	if *name == "all" {
+		names = names[:0]
+		for n := range samples {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatalf("Failed to create %s: %v", *out, err)
+	}
+
+	for _, n := range names {
+		sample, ok := samples[n]
+		if !ok {
+			log.Fatalf("Unknown template %q", n)
+		}
+		rendered, err := sample()
+		if err != nil {
+			log.Fatalf("Error rendering %s: %v", n, err)
+		}
+
+		base := filepath.Join(*out, n+"."+*locale)
+		if err := email.SaveToTxt(base, rendered.Subject, rendered.Text); err != nil {
+			log.Fatalf("Error saving %s: %v", n, err)
+		}
+		if err := os.WriteFile(base+".html", []byte(rendered.HTML), 0644); err != nil {
+			log.Fatalf("Error saving %s: %v", n, err)
+		}
+		fmt.Printf("Wrote %s.txt and %s.html\n", base, base)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/payment"
+	"smart_contract/pkg/smart-contract"
+)
+
+// Reprocesses stored payment webhook events, e.g. after a bug in their handling has been fixed
+func main() {
+	dbPath := flag.String("db", "tronch.db", "path to the SQLite database")
+	provider := flag.String("provider", "", "only replay events from this provider")
+	all := flag.Bool("all", false, "also replay events that were already processed successfully")
+	flag.Parse()
+
+	if err := db.Initialize(*dbPath); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	registry := payment.NewRegistry(smart_contract.HandlePaymentEvent)
+
+	replayed, err := smart_contract.ReplayPaymentEvents(context.Background(), registry, *provider, *all)
+	if err != nil {
+		log.Fatalf("Failed to replay payment events: %v", err)
+	}
+
+	log.Printf("Replayed %d payment events", replayed)
+}
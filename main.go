@@ -1,33 +1,176 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-	"text/template"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"html/template"
 	"io"
 
+	"smart_contract/pkg/chain"
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+	"smart_contract/pkg/i18n"
+	"smart_contract/pkg/inbound"
+	"smart_contract/pkg/interactions"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/outbox"
+	"smart_contract/pkg/payment"
+	"smart_contract/pkg/payout"
+	"smart_contract/pkg/rates"
 	"smart_contract/pkg/smart-contract"
+	"smart_contract/pkg/smart-contract/contract"
 )
 
 type ContractData struct {
 	ClientFirstName string `json:"client_first_name"`
+	ClientEmail     string `json:"client_email"`
 	UserFirstName   string `json:"user_first_name"`
+	UserEmail       string `json:"user_email"` // The freelancer's account
+	Locale          string `json:"locale"`     // Language the client is emailed in
 	Requirements    string `json:"requirements"`
 	Description     string `json:"description"`
+	PaymentAmount   string   `json:"payment_amount"` // e.g. "1.5 ETH"
+	ClientWallet    string   `json:"client_wallet"`  // Address the client deposits from and is refunded to
+	Deadline        string   `json:"deadline"`       // Date the work is due, e.g. "2024-06-30"; defaults to 30 days from now
+	Milestones      []string `json:"milestones"`     // Deliverables, committed to by hash in the escrow contract
 }
 
+// Payment providers available to the escrow flow
+var payments *payment.Registry
+
+// Returns escrowed funds when a paid contract is cancelled
+var refunder *smart_contract.Refunder
+
+// Records replies to contract emails, set when a reply domain is configured
+var replies *inbound.Processor
+
+// Shared secrets the mail provider signs its webhooks with; a webhook is only served when its secret is set
+var (
+	inboundWebhookSecret = os.Getenv("INBOUND_WEBHOOK_SECRET")
+	emailWebhookSecret   = os.Getenv("EMAIL_WEBHOOK_SECRET")
+)
+
+// Local provider that settles payments without moving money, only set in development when SIMULATED_PAYMENTS=true
+var simulatedPayments *payment.SimulatedProvider
+
 func main() {
+	if err := db.Initialize("tronch.db"); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	mailer, err := email.NewSenderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure email: %v", err)
+	}
+	if baseURL := os.Getenv("APP_BASE_URL"); baseURL != "" {
+		smart_contract.BaseURL = baseURL
+	}
+
+	// Let recipients opt out of optional notices with one click
+	if secret := os.Getenv("UNSUBSCRIBE_SECRET"); secret != "" {
+		smart_contract.Unsubscribes = &email.UnsubscribeLinks{BaseURL: smart_contract.BaseURL, Secret: []byte(secret)}
+	}
+
+	// Let each party act on a contract through the signed dashboard link in their emails
+	if secret := os.Getenv("PARTY_LINK_SECRET"); secret != "" {
+		smart_contract.PartyLinks = &email.PartyLinks{BaseURL: smart_contract.BaseURL, Secret: []byte(secret)}
+	} else {
+		log.Println("Cancellations disabled: PARTY_LINK_SECRET is not set")
+	}
+
+	// Route replies to contract emails back to their contract
+	if domain := os.Getenv("INBOUND_REPLY_DOMAIN"); domain != "" {
+		if err := registerInboundEmail(domain); err != nil {
+			log.Fatalf("Failed to set up inbound email: %v", err)
+		}
+	}
+
+	payments = payment.NewRegistry(smart_contract.HandlePaymentEvent)
+	if os.Getenv("SIMULATED_PAYMENTS") == "true" {
+		log.Println("Simulated payments are enabled; payment links settle without any money moving")
+		secret := os.Getenv("SIMULATED_WEBHOOK_SECRET")
+		if secret == "" {
+			log.Fatalf("SIMULATED_WEBHOOK_SECRET is required with SIMULATED_PAYMENTS")
+		}
+		simulatedPayments = payment.NewSimulatedProvider("http://localhost:8080", secret)
+		payments.RegisterNamed(simulatedPayments)
+	}
+	refunder = &smart_contract.Refunder{Payments: payments}
+
+	// Deliver queued emails in the background so a failed send never loses a notification
+	dispatcher := &outbox.Dispatcher{Sender: mailer, MaxAttempts: 8, Backoff: 30 * time.Second, OnBounce: recordBounce}
+	go dispatcher.Run(context.Background(), 10*time.Second)
+
+	// Remind clients to pay before their locked exchange rate expires
+	go func() {
+		for range time.Tick(time.Minute) {
+			if err := smart_contract.RemindExpiringQuotes(quoteTTL / 3); err != nil {
+				log.Printf("Error sending quote reminders: %v", err)
+			}
+		}
+	}()
+
+	disbursements := &payout.Service{Payments: payments, MaxAttempts: 5, Backoff: time.Minute}
+
+	// Accept deposits to, and release and refund from, the escrow contract when a network is configured
+	if os.Getenv("CHAIN_NETWORK") != "" || os.Getenv("ETH_RPC_URL") != "" {
+		network, err := chain.NetworkFromEnv()
+		if err != nil {
+			log.Fatalf("Failed to load chain configuration: %v", err)
+		}
+		if err := registerCryptoPayments(network, disbursements); err != nil {
+			log.Fatalf("Failed to set up crypto payments: %v", err)
+		}
+	}
+
+	// Pay freelancers once their contracts have been executed
+	go disbursements.Run(context.Background(), time.Minute)
+
 	// Serve static files
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
 	// Handle API endpoints
 	http.HandleFunc("/generate_contract", GenerateContract)
+	http.HandleFunc("/contracts/confirm", ConfirmContract)
+	http.HandleFunc("/contracts/requote", RefreshQuote)
+	http.HandleFunc("/request_payment", RequestPayment)
+	if simulatedPayments != nil {
+		http.HandleFunc("/pay/", CompleteSimulatedPayment)
+	}
+	http.HandleFunc("/webhooks/payment/", PaymentWebhook)
+	http.HandleFunc("/contracts/cancel", RequestCancellation)
+	http.HandleFunc("/contracts/cancel/resolve", ResolveCancellation)
+	http.HandleFunc("/payout_accounts", RegisterPayoutAccount)
+	http.HandleFunc("/email_outbox/status", EmailOutboxStatus)
+	if replies != nil && inboundWebhookSecret != "" {
+		http.HandleFunc("/webhooks/inbound_email", InboundEmailWebhook)
+	} else if replies != nil {
+		log.Println("Inbound email webhook disabled: INBOUND_WEBHOOK_SECRET is not set")
+	}
+	http.HandleFunc("/contracts/comments", ContractComments)
+	if emailWebhookSecret != "" {
+		http.HandleFunc("/webhooks/email_events", EmailEventsWebhook)
+	} else {
+		log.Println("Email events webhook disabled: EMAIL_WEBHOOK_SECRET is not set")
+	}
+	http.HandleFunc("/unsubscribe", Unsubscribe)
+	http.HandleFunc("/clients/email", UpdateClientEmail)
 
-	// Serve index.html as the frontend
+	// Serve index.html as the frontend, in the language asked for with ?lang= or the browser's preference
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		tmpl, err := template.ParseFiles("templates/index.html")
+		locale := r.URL.Query().Get("lang")
+		if locale == "" {
+			locale = i18n.Match(r.Header.Get("Accept-Language"))
+		}
+		tmpl, err := template.New("index.html").Funcs(i18n.For(locale).Funcs()).ParseFiles("templates/index.html")
 		if err != nil {
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
@@ -64,9 +207,30 @@ func GenerateContract(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Received contract data: %+v", data)
 
+	paymentAmount, err := money.Parse(data.PaymentAmount)
+	if err != nil {
+		log.Printf("Error parsing payment amount: %v", err)
+		http.Error(w, "Invalid payment amount", http.StatusBadRequest)
+		return
+	}
+
+	if data.ClientWallet != "" {
+		if err := payout.ValidateWalletAddress(data.ClientWallet); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	deadline := time.Now().AddDate(0, 0, 30)
+	if data.Deadline != "" {
+		if deadline, err = time.Parse("2006-01-02", data.Deadline); err != nil {
+			http.Error(w, "Invalid deadline, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Extract requirements
 	ctx := r.Context() // You can pass context if needed
-	requirements, err := smart_contract.ExtractRequirements(ctx, data.ClientFirstName, "", 0, data.Requirements, data.Description)
+	requirements, err := smart_contract.ExtractRequirements(ctx, data.ClientFirstName, data.ClientEmail, paymentAmount, data.Requirements, data.Description)
 	if err != nil {
 		log.Printf("Error extracting requirements: %v", err)
 		http.Error(w, "Failed to extract requirements", http.StatusInternalServerError)
@@ -79,6 +243,8 @@ func GenerateContract(w http.ResponseWriter, r *http.Request) {
 	userInput := map[string]string{
 		"requirements": requirements,
 		"description":  data.Description,
+		// The template's paymentAmount is a uint in base units (wei)
+		"paymentAmount": paymentAmount.Units.String(),
 	}
 	contractCode, err := smart_contract.GenerateSmartContract(userInput)
 	if err != nil {
@@ -95,5 +261,703 @@ func GenerateContract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Write([]byte("Contract generated and saved successfully"))
+	user, err := db.GetUserByEmail(data.UserEmail)
+	if err != nil {
+		log.Printf("Error loading user %s: %v", data.UserEmail, err)
+		http.Error(w, "Unknown user", http.StatusBadRequest)
+		return
+	}
+
+	client := &db.Client{UserID: user.ID, Name: data.ClientFirstName, Email: data.ClientEmail, Locale: data.Locale, Wallet: data.ClientWallet}
+	if err := db.CreateClient(db.DB, client); err != nil {
+		log.Printf("Error creating client: %v", err)
+		http.Error(w, "Failed to create client", http.StatusInternalServerError)
+		return
+	}
+
+	contractID, err := db.CreateContract(&db.Contract{
+		ClientID:    client.ID,
+		Description: data.Description,
+		Status:      string(smart_contract.AwaitingConfirmation),
+		Deadline:    deadline,
+	})
+	if err != nil {
+		log.Printf("Error creating contract: %v", err)
+		http.Error(w, "Failed to create contract", http.StatusInternalServerError)
+		return
+	}
+	if err := db.AddContractMilestones(contractID, data.Milestones); err != nil {
+		log.Printf("Error storing contract milestones: %v", err)
+		http.Error(w, "Failed to create contract", http.StatusInternalServerError)
+		return
+	}
+	if err := db.InsertContractCode(contractID, contractCode); err != nil {
+		log.Printf("Error storing contract code: %v", err)
+		http.Error(w, "Failed to create contract", http.StatusInternalServerError)
+		return
+	}
+	breakdown, err := smart_contract.InitiateContract(contractID, user, paymentAmount)
+	if err != nil {
+		log.Printf("Error initiating contract: %v", err)
+		http.Error(w, "Failed to initiate contract", http.StatusInternalServerError)
+		return
+	}
+
+	// Let the client know the escrow has been initiated
+	if err := smart_contract.QueueInitiationEmail(contractID, requirements); err != nil {
+		log.Printf("Error queueing initiation email: %v", err)
+		http.Error(w, "Contract created but the client could not be emailed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"contract_id": contractID,
+		"fees":        breakdown,
+		"message":     "Contract generated and saved successfully",
+	})
+}
+
+// Registers the crypto deposit provider, deploys an escrow contract for each confirmed contract,
+// starts watching the chain for deposits and sends on-chain refunds and releases through the escrow contract
+func registerCryptoPayments(network chain.Network, disbursements *payout.Service) error {
+	ctx := context.Background()
+
+	client, err := chain.Dial(ctx, network)
+	if err != nil {
+		return err
+	}
+	signer, err := interactions.NewSignerFromEnv(ctx)
+	if err != nil {
+		return err
+	}
+	interactor, err := interactions.NewInteractor(client, network, signer)
+	if err != nil {
+		return err
+	}
+	// Follow transactions sent before a restart, so their nonces aren't reused and stuck ones still get replaced
+	if err := interactor.Resume(ctx); err != nil {
+		return err
+	}
+	refunder.OnChain = interactor
+	disbursements.Wallet = interactor
+	// Fees are withdrawn to the operator's account, which pays the gas, unless the platform keeps them elsewhere
+	disbursements.FeeWallet = signer.Address().Hex()
+	if wallet := os.Getenv("PLATFORM_FEE_WALLET"); wallet != "" {
+		if err := payout.ValidateWalletAddress(wallet); err != nil {
+			return err
+		}
+		disbursements.FeeWallet = wallet
+	}
+	native, err := network.NativeCurrency()
+	if err != nil {
+		return err
+	}
+
+	source, err := payment.NewEthChainSource(ctx, client, native)
+	if err != nil {
+		return err
+	}
+
+	// Every deployment would fail without bytecode, so confirmed contracts wait for bindings generated with solc
+	if artifact := contract.EscrowServiceArtifact(); artifact.Bin == "" {
+		log.Printf("Escrow bindings have no bytecode, not deploying escrows until go generate ./pkg/smart-contract/contract is run with solc")
+	} else {
+		deployments := &smart_contract.DeploymentService{Deployer: interactor, Artifact: artifact, Currency: native}
+		go deployments.Run(ctx, 30*time.Second)
+	}
+
+	// Follow what happens on the escrows, including actions the parties take directly from their wallets
+	indexer := &smart_contract.EventIndexer{Reader: client, Confirmations: network.Confirmations}
+	go indexer.Run(ctx, 15*time.Second)
+
+	crypto := payment.NewCryptoProvider(source, smart_contract.EscrowDepositAddress, native, network.Confirmations)
+	payments.Register(crypto)
+	go crypto.Watch(ctx, 15*time.Second, func(ctx context.Context, event *payment.Event) error {
+		return smart_contract.ProcessPaymentEvent(ctx, payments, event, nil)
+	})
+	return nil
+}
+
+// How long a converted price stays valid before the client must request a new quote
+const quoteTTL = 15 * time.Minute
+
+// Currency contracts are paid in on chain, defaulting to the Polygon native token
+func paymentCurrency() (money.Currency, error) {
+	code := os.Getenv("PAYMENT_CURRENCY")
+	if code == "" {
+		code = "MATIC"
+	}
+	return money.LookupCurrency(code)
+}
+
+// Confirms a contract, converting its price into the payment currency at the current exchange rate.
+// The contract is identified by a party's signed token in the party query parameter, as in their dashboard link.
+func ConfirmContract(w http.ResponseWriter, r *http.Request) {
+	contractID, _, err := smart_contract.AuthenticateParty(r.URL.Query().Get("party"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	source, err := rates.LoadFile("rates.json")
+	if err != nil {
+		log.Printf("Error loading exchange rates: %v", err)
+		http.Error(w, "Failed to load exchange rates", http.StatusInternalServerError)
+		return
+	}
+	currency, err := paymentCurrency()
+	if err != nil {
+		log.Printf("Error looking up payment currency: %v", err)
+		http.Error(w, "Invalid payment currency", http.StatusInternalServerError)
+		return
+	}
+
+	if err := smart_contract.ConfirmContract(r.Context(), contractID, source, currency, quoteTTL); err != nil {
+		log.Printf("Error confirming contract: %v", err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeQuote(w, contractID)
+}
+
+// Locks a new exchange rate for a confirmed contract whose quote has expired, identified by a party's token
+func RefreshQuote(w http.ResponseWriter, r *http.Request) {
+	contractID, _, err := smart_contract.AuthenticateParty(r.URL.Query().Get("party"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	source, err := rates.LoadFile("rates.json")
+	if err != nil {
+		log.Printf("Error loading exchange rates: %v", err)
+		http.Error(w, "Failed to load exchange rates", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := smart_contract.RefreshQuote(r.Context(), contractID, source, quoteTTL); err != nil {
+		log.Printf("Error refreshing quote: %v", err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeQuote(w, contractID)
+}
+
+// Writes the contract's amount due and, when its price was converted, the rate it was locked at
+func writeQuote(w http.ResponseWriter, contractID int) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		http.Error(w, "Contract not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"amount_due": contract.Fees.Gross.String(),
+	}
+	if quote, err := db.GetLatestQuote(contractID); err == nil {
+		response["quoted"] = quote.Quoted.String()
+		response["rate"] = quote.Rate
+		response["rate_source"] = quote.Source
+		response["expires_at"] = quote.ExpiresAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Creates a payment intent for a contract and returns the link the client should pay through
+func RequestPayment(w http.ResponseWriter, r *http.Request) {
+	contractID, err := strconv.Atoi(r.URL.Query().Get("contract_id"))
+	if err != nil {
+		http.Error(w, "Invalid contract_id", http.StatusBadRequest)
+		return
+	}
+
+	provider, err := payments.Get(r.URL.Query().Get("provider"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	intent, err := smart_contract.RequestPayment(r.Context(), provider, contractID)
+	if err != nil {
+		log.Printf("Error requesting payment: %v", err)
+		http.Error(w, "Failed to request payment", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"intent_id":    intent.ID,
+		"payment_link": intent.PaymentLink,
+	})
+}
+
+// Receives a payment provider webhook at /webhooks/payment/{provider}
+func PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	status, message := handlePaymentWebhook(r.Context(), strings.TrimPrefix(r.URL.Path, "/webhooks/payment/"), r.Header, payload)
+	if status != http.StatusOK {
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Write([]byte(message))
+}
+
+// Verifies, stores and dispatches a webhook, returning the HTTP status to answer the provider with
+func handlePaymentWebhook(ctx context.Context, providerName string, headers http.Header, payload []byte) (int, string) {
+	provider, err := payments.Get(providerName)
+	if err != nil || providerName == "" {
+		return http.StatusNotFound, "Unknown payment provider"
+	}
+
+	event, err := provider.HandleWebhook(ctx, headers, payload)
+	if err != nil {
+		log.Printf("Rejected %s webhook: %v", providerName, err)
+		return http.StatusBadRequest, "Invalid webhook"
+	}
+
+	// A non-2xx response makes the provider retry, which is safe because events are deduplicated
+	if err := smart_contract.ProcessPaymentEvent(ctx, payments, event, payload); err != nil {
+		log.Printf("Error processing %s event %s: %v", providerName, event.ID, err)
+		return http.StatusInternalServerError, "Failed to process webhook"
+	}
+	return http.StatusOK, "OK"
+}
+
+// Settles a simulated payment link, standing in for the provider's hosted checkout during development
+func CompleteSimulatedPayment(w http.ResponseWriter, r *http.Request) {
+	intentID := strings.TrimPrefix(r.URL.Path, "/pay/")
+
+	headers, payload, err := simulatedPayments.Complete(intentID, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if status, message := handlePaymentWebhook(r.Context(), simulatedPayments.Name(), headers, payload); status != http.StatusOK {
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Write([]byte("Payment received"))
+}
+
+type CancellationRequestData struct {
+	PartyToken string `json:"party_token"` // From the requesting party's dashboard link; names the contract and party
+	Reason     string `json:"reason"`
+}
+
+type CancellationResolutionData struct {
+	PartyToken string `json:"party_token"` // From the resolving party's dashboard link
+	RequestID  int    `json:"request_id"`
+	Approve    bool   `json:"approve"`
+}
+
+// Requests cancellation of a contract, which takes effect immediately when no approval is needed
+func RequestCancellation(w http.ResponseWriter, r *http.Request) {
+	var data CancellationRequestData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	contractID, party, err := smart_contract.AuthenticateParty(data.PartyToken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	request, err := smart_contract.RequestCancellation(r.Context(), refunder, contractID, party, data.Reason)
+	if err != nil {
+		log.Printf("Error requesting cancellation: %v", err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"request_id": request.ID,
+		"status":     request.Status,
+	})
+}
+
+// Approves or rejects a pending cancellation request
+func ResolveCancellation(w http.ResponseWriter, r *http.Request) {
+	var data CancellationResolutionData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	contractID, party, err := smart_contract.AuthenticateParty(data.PartyToken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	err = smart_contract.ResolveCancellation(r.Context(), refunder, data.RequestID, contractID, party, data.Approve)
+	if err != nil {
+		log.Printf("Error resolving cancellation: %v", err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Write([]byte("Cancellation request resolved"))
+}
+
+type PayoutAccountData struct {
+	PartyToken    string               `json:"party_token"` // The freelancer's signed party token from one of their contracts
+	WalletAddress string               `json:"wallet_address"`
+	Provider      string               `json:"provider"`
+	Bank          *payment.BankAccount `json:"bank"`
+	MakeDefault   bool                 `json:"make_default"` // Replaces the freelancer's current default account
+}
+
+// Registers a freelancer's wallet or bank account as a payout destination, which becomes their default if they
+// have none yet or make_default is set
+func RegisterPayoutAccount(w http.ResponseWriter, r *http.Request) {
+	var data PayoutAccountData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	// Only the freelancer decides where they are paid
+	contractID, party, err := smart_contract.AuthenticateParty(data.PartyToken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if party != smart_contract.FreelancerParty {
+		http.Error(w, "Only the freelancer can register payout accounts", http.StatusForbidden)
+		return
+	}
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		http.Error(w, "Contract not found", http.StatusNotFound)
+		return
+	}
+	client, err := db.GetClientByID(db.DB, contract.ClientID)
+	if err != nil {
+		log.Printf("Error loading client: %v", err)
+		http.Error(w, "Failed to load contract", http.StatusInternalServerError)
+		return
+	}
+
+	var account *db.PayoutAccount
+	if data.Bank != nil {
+		provider, getErr := payments.Get(data.Provider)
+		if getErr != nil {
+			http.Error(w, getErr.Error(), http.StatusBadRequest)
+			return
+		}
+		payouts, ok := provider.(payment.PayoutProvider)
+		if !ok {
+			http.Error(w, "Payment provider cannot pay out to banks", http.StatusBadRequest)
+			return
+		}
+		account, err = payout.RegisterBankAccount(r.Context(), payouts, client.UserID, *data.Bank, data.MakeDefault)
+	} else {
+		account, err = payout.RegisterWallet(client.UserID, data.WalletAddress, data.MakeDefault)
+	}
+	if err != nil {
+		log.Printf("Error registering payout account: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"payout_account_id": account.ID,
+		"kind":              account.Kind,
+		"is_default":        account.IsDefault,
+	})
+}
+
+// Reports how many of a contract's emails are pending, sent and failed, with the most recent failures.
+// Only the contract's parties may look, identified by their signed token in the party query parameter.
+func EmailOutboxStatus(w http.ResponseWriter, r *http.Request) {
+	contractID, _, err := smart_contract.AuthenticateParty(r.URL.Query().Get("party"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	stats, err := db.GetOutboxStats(contractID, 20)
+	if err != nil {
+		log.Printf("Error loading email outbox status: %v", err)
+		http.Error(w, "Failed to load email outbox status", http.StatusInternalServerError)
+		return
+	}
+
+	failures := []map[string]interface{}{}
+	for _, e := range stats.Failures {
+		failures = append(failures, map[string]interface{}{
+			"id":          e.ID,
+			"contract_id": e.ContractID,
+			"event":       e.Event,
+			"recipient":   e.Recipient,
+			"attempts":    e.Attempts,
+			"last_error":  e.LastError,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"counts":         stats.Counts,
+		"oldest_pending": stats.OldestPending,
+		"failures":       failures,
+	})
+}
+
+// Signs reply-to addresses on outgoing emails and, when a maildir is configured, watches it for replies
+func registerInboundEmail(domain string) error {
+	secret := os.Getenv("INBOUND_REPLY_SECRET")
+	if secret == "" {
+		return fmt.Errorf("INBOUND_REPLY_SECRET is required with INBOUND_REPLY_DOMAIN")
+	}
+	currency, err := paymentCurrency()
+	if err != nil {
+		return err
+	}
+
+	smart_contract.Replies = &email.ReplyAddresses{Domain: domain, Secret: []byte(secret)}
+	replies = &inbound.Processor{
+		Replies:    smart_contract.Replies,
+		AuthServID: os.Getenv("INBOUND_AUTHSERV_ID"),
+		Rates:      func() (rates.Source, error) { return rates.LoadFile("rates.json") },
+		PayIn:      currency,
+		QuoteTTL:   quoteTTL,
+	}
+	if replies.AuthServID == "" {
+		log.Println("Inbound email commands and bounces disabled: INBOUND_AUTHSERV_ID is not set")
+	}
+
+	if dir := os.Getenv("INBOUND_MAILDIR"); dir != "" {
+		go replies.WatchMaildir(context.Background(), dir, 30*time.Second)
+	}
+	return nil
+}
+
+// Accepts a raw RFC 5322 message from the inbound mail provider, signed like payment webhooks
+func InboundEmailWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if replies == nil {
+		http.Error(w, "Inbound email is not configured", http.StatusNotFound)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if err := payment.VerifySignature(inboundWebhookSecret, r.Header.Get(payment.SignatureHeader), payload, 5*time.Minute); err != nil {
+		log.Printf("Rejected inbound email webhook: %v", err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	// Rejected mail is acknowledged so the provider doesn't keep redelivering it
+	result, err := replies.Process(r.Context(), payload)
+	if inbound.IsRejected(err) {
+		log.Printf("Ignoring inbound email: %v", err)
+		w.Write([]byte("Ignored"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error processing inbound email: %v", err)
+		http.Error(w, "Failed to process email", http.StatusInternalServerError)
+		return
+	}
+
+	if result.Comment == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"bounces": result.Bounces})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"contract_id": result.Comment.ContractID,
+		"added":       result.Added,
+		"command":     result.Command,
+	})
+}
+
+// Lists the comments on a contract's timeline
+func ContractComments(w http.ResponseWriter, r *http.Request) {
+	contractID, err := strconv.Atoi(r.URL.Query().Get("contract_id"))
+	if err != nil {
+		http.Error(w, "Invalid contract_id", http.StatusBadRequest)
+		return
+	}
+
+	comments, err := db.GetContractComments(contractID)
+	if err != nil {
+		log.Printf("Error loading contract comments: %v", err)
+		http.Error(w, "Failed to load comments", http.StatusInternalServerError)
+		return
+	}
+
+	timeline := []map[string]interface{}{}
+	for _, c := range comments {
+		timeline = append(timeline, map[string]interface{}{
+			"id":         c.ID,
+			"author":     c.AuthorParty,
+			"email":      c.AuthorEmail,
+			"body":       c.Body,
+			"source":     c.Source,
+			"created_at": c.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
+
+// Flags addresses the SMTP server rejected, the same as bounces reported afterwards
+func recordBounce(bounce email.Bounce) {
+	if err := smart_contract.RecordBounce(bounce); err != nil {
+		log.Printf("Error recording bounce for %s: %v", bounce.Recipient, err)
+	}
+}
+
+// Accepts bounces and spam complaints reported by the mail provider as a JSON list, signed like payment webhooks
+func EmailEventsWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if err := payment.VerifySignature(emailWebhookSecret, r.Header.Get(payment.SignatureHeader), payload, 5*time.Minute); err != nil {
+		log.Printf("Rejected email events webhook: %v", err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var bounces []email.Bounce
+	if err := json.Unmarshal(payload, &bounces); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	for _, bounce := range bounces {
+		if err := smart_contract.RecordBounce(bounce); err != nil {
+			log.Printf("Error recording bounce for %s: %v", bounce.Recipient, err)
+			http.Error(w, "Failed to record bounce", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Write([]byte("OK"))
+}
+
+// Shows a confirmation page for an unsubscribe link; a POST, including mail clients' one-click requests, opts the address out
+func Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	address, category := query.Get("email"), email.Category(query.Get("category"))
+
+	valid := smart_contract.Unsubscribes != nil && category != email.Transactional &&
+		smart_contract.Unsubscribes.Verify(address, category, query.Get("token"))
+	data := map[string]interface{}{
+		"Email":    address,
+		"Category": category,
+		"Action":   r.URL.RequestURI(),
+		"Invalid":  !valid,
+		"Done":     false,
+	}
+
+	if valid && r.Method == http.MethodPost {
+		if err := db.SetNotificationPreference(address, string(category), false); err != nil {
+			log.Printf("Error unsubscribing %s: %v", address, err)
+			http.Error(w, "Failed to unsubscribe", http.StatusInternalServerError)
+			return
+		}
+		data["Done"] = true
+	}
+
+	locale := query.Get("lang")
+	if locale == "" {
+		locale = i18n.Match(r.Header.Get("Accept-Language"))
+	}
+	tmpl, err := template.New("unsubscribe.html").Funcs(i18n.For(locale).Funcs()).ParseFiles("templates/unsubscribe.html")
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	tmpl.Execute(w, data)
+}
+
+// Lets the freelancer correct a client's email address after mail to it bounced, authenticated by the
+// freelancer's signed party token for one of the client's contracts
+func UpdateClientEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		PartyToken string `json:"party_token"`
+		Email      string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Every later notification and reply command follows the address, so the client can't be redirected by anyone else
+	contractID, party, err := smart_contract.AuthenticateParty(request.PartyToken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if party != smart_contract.FreelancerParty && party != smart_contract.AdminParty {
+		http.Error(w, "Only the freelancer can change the client's email", http.StatusForbidden)
+		return
+	}
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		http.Error(w, "Contract not found", http.StatusNotFound)
+		return
+	}
+
+	client, err := smart_contract.UpdateClientEmail(contract.ClientID, request.Email)
+	if err != nil {
+		log.Printf("Error updating client email: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"client_id":    client.ID,
+		"email":        client.Email,
+		"email_status": client.EmailStatus,
+	})
 }
@@ -0,0 +1,158 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"smart_contract/pkg/money"
+)
+
+// Named networks the escrow contracts can be deployed to, e.g. a local dev node, a testnet and mainnet
+type Config struct {
+	Default  string             `json:"default"`
+	Networks map[string]Network `json:"networks"`
+}
+
+// A chain and how we transact on it
+type Network struct {
+	Name          string    `json:"-"`
+	RPCURL        string    `json:"rpc_url"`
+	ChainID       int64     `json:"chain_id"`      // Verified against the node when dialing
+	Currency      string    `json:"currency"`      // Native coin, e.g. "MATIC"
+	Confirmations uint64    `json:"confirmations"` // Blocks on top of a transaction's before it is treated as final
+	Gas           GasPolicy `json:"gas"`
+}
+
+// Limits on what we are willing to pay for gas on a network
+type GasPolicy struct {
+	MaxFeePerGas         Wei     `json:"max_fee_per_gas"`          // Cap on base fee plus tip
+	MaxPriorityFeePerGas Wei     `json:"max_priority_fee_per_gas"` // Cap on the tip paid to validators
+	GasLimitMultiplier   float64 `json:"gas_limit_multiplier"`     // Headroom added to estimated gas, e.g. 1.2
+}
+
+// Reads the network configuration from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %v", path, err)
+	}
+	if _, ok := config.Networks[config.Default]; !ok {
+		return nil, fmt.Errorf("default network %q not found", config.Default)
+	}
+	return &config, nil
+}
+
+// Looks up a network by name, or the default network when name is empty
+func (c *Config) Network(name string) (Network, error) {
+	if name == "" {
+		name = c.Default
+	}
+	network, ok := c.Networks[name]
+	if !ok {
+		return Network{}, fmt.Errorf("network %q not found", name)
+	}
+	network.Name = name
+	if network.Confirmations == 0 {
+		network.Confirmations = 1
+	}
+	if network.Gas.GasLimitMultiplier == 0 {
+		network.Gas.GasLimitMultiplier = 1
+	}
+	return network, nil
+}
+
+// Returns the network named by CHAIN_NETWORK from chains.json, with ETH_RPC_URL overriding its RPC URL
+func NetworkFromEnv() (Network, error) {
+	config, err := LoadConfig("chains.json")
+	if err != nil {
+		return Network{}, err
+	}
+	network, err := config.Network(os.Getenv("CHAIN_NETWORK"))
+	if err != nil {
+		return Network{}, err
+	}
+	if url := os.Getenv("ETH_RPC_URL"); url != "" {
+		network.RPCURL = url
+	}
+	return network, nil
+}
+
+// Returns the network's native coin
+func (n Network) NativeCurrency() (money.Currency, error) {
+	return money.LookupCurrency(n.Currency)
+}
+
+// Returns the chain ID as a big.Int for signing
+func (n Network) ChainIDBig() *big.Int {
+	return big.NewInt(n.ChainID)
+}
+
+// Connects to the network's node, refusing to continue if it serves a different chain than configured
+func Dial(ctx context.Context, network Network) (*ethclient.Client, error) {
+	client, err := ethclient.DialContext(ctx, network.RPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s node: %v", network.Name, err)
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to get chain ID from %s node: %v", network.Name, err)
+	}
+	if chainID.Cmp(network.ChainIDBig()) != 0 {
+		client.Close()
+		return nil, fmt.Errorf("node at %s serves chain %s, but network %s is chain %d", network.RPCURL, chainID, network.Name, network.ChainID)
+	}
+	return client, nil
+}
+
+// An amount of wei, written in JSON as a number with a unit, e.g. "30 gwei" or "0.01 ether"
+type Wei struct {
+	*big.Int
+}
+
+var weiUnits = map[string]int{"wei": 0, "gwei": 9, "ether": 18}
+
+func (w *Wei) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("wei amounts must be strings such as \"30 gwei\": %v", err)
+	}
+	value, unit, _ := strings.Cut(strings.TrimSpace(s), " ")
+	if unit == "" {
+		unit = "wei"
+	}
+	decimals, ok := weiUnits[strings.ToLower(strings.TrimSpace(unit))]
+	if !ok {
+		return fmt.Errorf("unknown unit %q in %q", unit, s)
+	}
+
+	amount, ok := new(big.Rat).SetString(value)
+	if !ok || amount.Sign() < 0 {
+		return fmt.Errorf("invalid amount %q", s)
+	}
+	amount.Mul(amount, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if !amount.IsInt() {
+		return fmt.Errorf("%q is not a whole number of wei", s)
+	}
+	w.Int = new(big.Int).Set(amount.Num())
+	return nil
+}
+
+func (w Wei) MarshalJSON() ([]byte, error) {
+	if w.Int == nil {
+		return []byte(`"0 wei"`), nil
+	}
+	return json.Marshal(w.String() + " wei")
+}
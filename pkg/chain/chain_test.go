@@ -0,0 +1,181 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig("../../chains.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		chainID       int64
+		currency      string
+		confirmations uint64
+	}{
+		{"", 137, "MATIC", 12}, // The default network
+		{"local", 1337, "ETH", 1},
+		{"amoy", 80002, "MATIC", 3},
+		{"polygon", 137, "MATIC", 12},
+	}
+	for _, test := range tests {
+		network, err := config.Network(test.name)
+		if err != nil {
+			t.Errorf("network %q: %v", test.name, err)
+			continue
+		}
+		if network.ChainID != test.chainID || network.Currency != test.currency || network.Confirmations != test.confirmations {
+			t.Errorf("network %q = %+v", test.name, network)
+		}
+		if _, err := network.NativeCurrency(); err != nil {
+			t.Errorf("network %q: %v", test.name, err)
+		}
+	}
+
+	polygon, _ := config.Network("polygon")
+	if polygon.Name != "polygon" || polygon.Gas.MaxFeePerGas.Cmp(big.NewInt(500e9)) != 0 || polygon.Gas.MaxPriorityFeePerGas.Cmp(big.NewInt(50e9)) != 0 {
+		t.Errorf("polygon = %+v, want 500 gwei max fee and 50 gwei tip", polygon)
+	}
+	if _, err := config.Network("mainnet"); err == nil {
+		t.Error("found an unconfigured network")
+	}
+}
+
+func TestLoadConfigDefaultsAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	config, err := LoadConfig(write("minimal.json", `{"default": "dev", "networks": {"dev": {"rpc_url": "http://127.0.0.1:8545", "chain_id": 1337, "currency": "ETH"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, err := config.Network("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.Confirmations != 1 || network.Gas.GasLimitMultiplier != 1 {
+		t.Errorf("dev = %+v, want one confirmation and no gas headroom", network)
+	}
+
+	for name, path := range map[string]string{
+		"missing file":     filepath.Join(dir, "missing.json"),
+		"invalid JSON":     write("invalid.json", `{"networks": `),
+		"missing default":  write("no-default.json", `{"default": "dev", "networks": {}}`),
+		"invalid gas unit": write("bad-gas.json", `{"default": "dev", "networks": {"dev": {"gas": {"max_fee_per_gas": "30 finney"}}}}`),
+	} {
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("loaded a config with a %s", name)
+		}
+	}
+}
+
+func TestWeiUnmarshal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{`"30 gwei"`, "30000000000", true},
+		{`"0.01 ether"`, "10000000000000000", true},
+		{`"1.5 GWEI"`, "1500000000", true},
+		{`"21000"`, "21000", true},
+		{`"0.5 wei"`, "", false},
+		{`"-1 gwei"`, "", false},
+		{`"30 finney"`, "", false},
+		{`30`, "", false},
+	}
+	for _, test := range tests {
+		var wei Wei
+		err := json.Unmarshal([]byte(test.input), &wei)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%s parsed as %s", test.input, wei)
+			}
+			continue
+		}
+		if err != nil || wei.String() != test.want {
+			t.Errorf("%s = %v (%v), want %s wei", test.input, wei.Int, err, test.want)
+		}
+	}
+}
+
+// Serves eth_chainId for the given chain, like a node would
+func fakeNode(t *testing.T, chainID int64) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		if request.Method == "eth_chainId" {
+			response["result"] = "0x" + big.NewInt(chainID).Text(16)
+		} else {
+			response["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDialVerifiesChainID(t *testing.T) {
+	node := fakeNode(t, 80002)
+	ctx := context.Background()
+
+	client, err := Dial(ctx, Network{Name: "amoy", RPCURL: node.URL, ChainID: 80002})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	// A mainnet config pointed at a testnet node must not be used to send real transactions
+	if client, err := Dial(ctx, Network{Name: "polygon", RPCURL: node.URL, ChainID: 137}); err == nil {
+		client.Close()
+		t.Error("dialed a node serving a different chain")
+	}
+}
+
+func TestNetworkFromEnv(t *testing.T) {
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir("pkg/chain") })
+
+	t.Setenv("CHAIN_NETWORK", "amoy")
+	t.Setenv("ETH_RPC_URL", "http://node.internal:8545")
+	network, err := NetworkFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.Name != "amoy" || network.ChainID != 80002 || network.RPCURL != "http://node.internal:8545" {
+		t.Errorf("network = %+v, want amoy through the overridden RPC URL", network)
+	}
+
+	t.Setenv("CHAIN_NETWORK", "mainnet")
+	if _, err := NetworkFromEnv(); err == nil {
+		t.Error("loaded an unconfigured network")
+	}
+}
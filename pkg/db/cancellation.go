@@ -0,0 +1,47 @@
+package db
+
+import (
+  "fmt"
+)
+
+// Represents a request by one party to cancel a contract
+type CancellationRequest struct {
+  ID          int
+  ContractID  int
+  RequestedBy string
+  Reason      string
+  Status      string
+  ResolvedBy  string
+}
+
+// Adds a new cancellation request to the database
+func CreateCancellationRequest(request *CancellationRequest) (int, error) {
+  var id int
+  err := DB.QueryRow("INSERT INTO cancellation_requests (contract_id, requested_by, reason, status) VALUES (?, ?, ?, ?) RETURNING id",
+    request.ContractID, request.RequestedBy, request.Reason, request.Status).Scan(&id)
+  if err != nil {
+    return 0, fmt.Errorf("failed to insert cancellation request: %v", err)
+  }
+  return id, nil
+}
+
+// Marks a cancellation request as approved or rejected
+func ResolveCancellationRequest(id int, status, resolvedBy string) error {
+  _, err := DB.Exec("UPDATE cancellation_requests SET status = ?, resolved_by = ?, resolved_at = CURRENT_TIMESTAMP WHERE id = ?",
+    status, resolvedBy, id)
+  if err != nil {
+    return fmt.Errorf("failed to resolve cancellation request: %v", err)
+  }
+  return nil
+}
+
+// Retrieves a cancellation request from the database by ID
+func GetCancellationRequestByID(id int) (*CancellationRequest, error) {
+  request := &CancellationRequest{}
+  err := DB.QueryRow("SELECT id, contract_id, requested_by, COALESCE(reason, ''), status, COALESCE(resolved_by, '') FROM cancellation_requests WHERE id = ?", id).
+    Scan(&request.ID, &request.ContractID, &request.RequestedBy, &request.Reason, &request.Status, &request.ResolvedBy)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get cancellation request: %v", err)
+  }
+  return request, nil
+}
@@ -0,0 +1,278 @@
+package db
+
+import (
+  "database/sql"
+  "fmt"
+  "math/big"
+  "time"
+)
+
+// Statuses of a transaction sent by our signer
+const (
+  ChainTxPending   = "pending"
+  ChainTxConfirmed = "confirmed"
+  ChainTxReverted  = "reverted" // Mined, but the call failed
+  ChainTxFailed    = "failed"   // Never mined, e.g. its nonce was used by another transaction
+)
+
+// Represents a transaction sent from our signer's account, identified by its nonce across fee replacements
+type ChainTransaction struct {
+  ID           int
+  Sender       string
+  Nonce        uint64
+  ContractID   int    // Contract whose network fee budget pays for the gas, 0 if none
+  Description  string // What the transaction does, e.g. "confirmReqs on 0x..."
+  Hash         string // Hash of the latest version sent
+  Raw          string // Latest signed version, hex encoded, kept so it can be replaced after a restart
+  Hashes       []string // Every version sent, oldest first; any of them may be the one mined
+  Status       string
+  Replacements int
+  BlockNumber  uint64
+  GasUsed      uint64
+  Fee          *big.Int // Wei paid for the gas used, nil until mined
+  LastError    string
+  SentAt       time.Time
+}
+
+// Records a transaction that is about to be sent
+func CreateChainTransaction(t *ChainTransaction) (int, error) {
+  tx, err := DB.Begin()
+  if err != nil {
+    return 0, fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  var id int
+  var contractID interface{}
+  if t.ContractID != 0 {
+    contractID = t.ContractID
+  }
+  err = tx.QueryRow("INSERT INTO chain_transactions (sender, nonce, contract_id, description, hash, raw, status, sent_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id",
+    t.Sender, t.Nonce, contractID, t.Description, t.Hash, t.Raw, ChainTxPending, t.SentAt).Scan(&id)
+  if err != nil {
+    return 0, fmt.Errorf("failed to insert chain transaction: %v", err)
+  }
+  if _, err := tx.Exec("INSERT INTO chain_transaction_hashes (hash, transaction_id) VALUES (?, ?)", t.Hash, id); err != nil {
+    return 0, fmt.Errorf("failed to insert chain transaction hash: %v", err)
+  }
+  if err := tx.Commit(); err != nil {
+    return 0, fmt.Errorf("failed to commit chain transaction: %v", err)
+  }
+  return id, nil
+}
+
+// Removes a transaction the node rejected, so its nonce can be used again
+func DeleteChainTransaction(id int) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  if _, err := tx.Exec("DELETE FROM chain_transaction_hashes WHERE transaction_id = ?", id); err != nil {
+    return fmt.Errorf("failed to delete chain transaction hashes: %v", err)
+  }
+  if _, err := tx.Exec("DELETE FROM chain_transactions WHERE id = ?", id); err != nil {
+    return fmt.Errorf("failed to delete chain transaction: %v", err)
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit chain transaction: %v", err)
+  }
+  return nil
+}
+
+// Records a higher-fee version of a pending transaction
+func ReplaceChainTransaction(id int, hash, raw string, sentAt time.Time) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  if _, err := tx.Exec("UPDATE chain_transactions SET hash = ?, raw = ?, replacements = replacements + 1, sent_at = ? WHERE id = ?", hash, raw, sentAt, id); err != nil {
+    return fmt.Errorf("failed to replace chain transaction: %v", err)
+  }
+  if _, err := tx.Exec("INSERT INTO chain_transaction_hashes (hash, transaction_id) VALUES (?, ?)", hash, id); err != nil {
+    return fmt.Errorf("failed to insert chain transaction hash: %v", err)
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit chain transaction: %v", err)
+  }
+  return nil
+}
+
+// Records the outcome of a transaction; block is the block it was mined in, if any, and fee what its gas cost in the
+// network's native currency, which is added to the network fees spent on its contract
+func FinishChainTransaction(id int, status, hash string, block, gasUsed uint64, fee *big.Int, currency string, lastError string) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  var feeUnits interface{}
+  if fee != nil {
+    feeUnits = fee.String()
+  }
+  _, err = tx.Exec("UPDATE chain_transactions SET status = ?, hash = ?, block_number = ?, gas_used = ?, fee = ?, last_error = ?, finished_at = ? WHERE id = ?",
+    status, hash, block, gasUsed, feeUnits, lastError, time.Now(), id)
+  if err != nil {
+    return fmt.Errorf("failed to finish chain transaction: %v", err)
+  }
+  if fee != nil {
+    if err := addNetworkFeeSpent(tx, id, fee, currency); err != nil {
+      return err
+    }
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit chain transaction: %v", err)
+  }
+  return nil
+}
+
+// Marks a finished transaction pending again, e.g. when a reorg removed the block it was mined in, taking back the fee
+// it added to its contract
+func ReopenChainTransaction(id int) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  var fee, currency sql.NullString
+  err = tx.QueryRow(`SELECT t.fee, c.network_fee_currency FROM chain_transactions t
+    LEFT JOIN contracts c ON c.id = t.contract_id WHERE t.id = ?`, id).Scan(&fee, &currency)
+  if err != nil {
+    return fmt.Errorf("failed to get chain transaction: %v", err)
+  }
+  if paid, ok := new(big.Int).SetString(fee.String, 10); ok {
+    if err := addNetworkFeeSpent(tx, id, paid.Neg(paid), currency.String); err != nil {
+      return err
+    }
+  }
+
+  _, err = tx.Exec("UPDATE chain_transactions SET status = ?, block_number = NULL, gas_used = NULL, fee = NULL, finished_at = NULL WHERE id = ?", ChainTxPending, id)
+  if err != nil {
+    return fmt.Errorf("failed to reopen chain transaction: %v", err)
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit chain transaction: %v", err)
+  }
+  return nil
+}
+
+// Adds a transaction's fee, in base units of the network's native currency, to the network fees spent on its
+// contract, if it has one
+func addNetworkFeeSpent(tx *sql.Tx, id int, fee *big.Int, currency string) error {
+  var contractID sql.NullInt64
+  var spent, spentCurrency string
+  err := tx.QueryRow(`SELECT t.contract_id, COALESCE(c.network_fee_spent, '0'), COALESCE(c.network_fee_currency, '') FROM chain_transactions t
+    LEFT JOIN contracts c ON c.id = t.contract_id WHERE t.id = ?`, id).Scan(&contractID, &spent, &spentCurrency)
+  if err != nil {
+    return fmt.Errorf("failed to get contract network fees: %v", err)
+  }
+  if !contractID.Valid {
+    return nil
+  }
+  if currency == "" {
+    return fmt.Errorf("network fee for contract %d has no currency", contractID.Int64)
+  }
+  if spentCurrency != "" && spentCurrency != currency {
+    return fmt.Errorf("contract %d has spent %s on network fees, not %s", contractID.Int64, spentCurrency, currency)
+  }
+
+  total, ok := new(big.Int).SetString(spent, 10)
+  if !ok {
+    return fmt.Errorf("invalid network fees %q on contract %d", spent, contractID.Int64)
+  }
+  total.Add(total, fee)
+  if _, err := tx.Exec("UPDATE contracts SET network_fee_spent = ?, network_fee_currency = ? WHERE id = ?", total.String(), currency, contractID.Int64); err != nil {
+    return fmt.Errorf("failed to update contract network fees: %v", err)
+  }
+  return nil
+}
+
+// Retrieves the transaction any of whose versions has the given hash
+func GetChainTransactionByHash(hash string) (*ChainTransaction, error) {
+  var id int
+  err := DB.QueryRow("SELECT transaction_id FROM chain_transaction_hashes WHERE hash = ?", hash).Scan(&id)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get chain transaction: %v", err)
+  }
+  return GetChainTransaction(id)
+}
+
+// Retrieves a transaction and the hashes of all its versions
+func GetChainTransaction(id int) (*ChainTransaction, error) {
+  t := &ChainTransaction{}
+  var block, contractID, gasUsed sql.NullInt64
+  var fee sql.NullString
+  var sentAt sql.NullTime
+  err := DB.QueryRow(`SELECT id, sender, nonce, contract_id, COALESCE(description, ''), hash, raw, status, replacements, block_number, gas_used, fee,
+    COALESCE(last_error, ''), sent_at FROM chain_transactions WHERE id = ?`, id).
+    Scan(&t.ID, &t.Sender, &t.Nonce, &contractID, &t.Description, &t.Hash, &t.Raw, &t.Status, &t.Replacements, &block, &gasUsed, &fee,
+      &t.LastError, &sentAt)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get chain transaction: %v", err)
+  }
+  t.ContractID, t.BlockNumber, t.GasUsed, t.SentAt = int(contractID.Int64), uint64(block.Int64), uint64(gasUsed.Int64), sentAt.Time
+  if fee.Valid {
+    t.Fee, _ = new(big.Int).SetString(fee.String, 10)
+  }
+
+  rows, err := DB.Query("SELECT hash FROM chain_transaction_hashes WHERE transaction_id = ? ORDER BY rowid", id)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get chain transaction hashes: %v", err)
+  }
+  defer rows.Close()
+  for rows.Next() {
+    var hash string
+    if err := rows.Scan(&hash); err != nil {
+      return nil, fmt.Errorf("failed to scan chain transaction hash: %v", err)
+    }
+    t.Hashes = append(t.Hashes, hash)
+  }
+  return t, rows.Err()
+}
+
+// Retrieves the most recent transaction recorded with a description, or nil if there is none
+func GetLatestChainTransaction(description string) (*ChainTransaction, error) {
+  var id int
+  err := DB.QueryRow("SELECT id FROM chain_transactions WHERE description = ? ORDER BY id DESC LIMIT 1", description).Scan(&id)
+  if err == sql.ErrNoRows {
+    return nil, nil
+  }
+  if err != nil {
+    return nil, fmt.Errorf("failed to get chain transaction: %v", err)
+  }
+  return GetChainTransaction(id)
+}
+
+// Retrieves the IDs of a sender's pending transactions, lowest nonce first
+func GetPendingChainTransactionIDs(sender string) ([]int, error) {
+  rows, err := DB.Query("SELECT id FROM chain_transactions WHERE sender = ? AND status = ? ORDER BY nonce", sender, ChainTxPending)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get pending chain transactions: %v", err)
+  }
+  defer rows.Close()
+
+  var ids []int
+  for rows.Next() {
+    var id int
+    if err := rows.Scan(&id); err != nil {
+      return nil, fmt.Errorf("failed to scan chain transaction: %v", err)
+    }
+    ids = append(ids, id)
+  }
+  return ids, rows.Err()
+}
+
+// Returns the nonce after the highest one recorded for a sender, or 0 if it has sent nothing
+func GetNextChainNonce(sender string) (uint64, error) {
+  var next sql.NullInt64
+  err := DB.QueryRow("SELECT MAX(nonce) + 1 FROM chain_transactions WHERE sender = ?", sender).Scan(&next)
+  if err != nil {
+    return 0, fmt.Errorf("failed to get next nonce: %v", err)
+  }
+  return uint64(next.Int64), nil
+}
@@ -5,29 +5,46 @@ import (
 	"fmt"
 )
 
+// Whether mail to a client's address is being delivered
+const (
+	EmailOK         = "ok"
+	EmailBounced    = "bounced"    // The address permanently rejected our mail
+	EmailComplained = "complained" // The client reported our mail as spam
+)
+
 // Client entity in the database
 type Client struct {
-	ID      int
-	Name    string
-	Email   string
-	Address string
+	ID          int
+	UserID      int // The freelancer who invited this client
+	Name        string
+	Email       string
+	Address     string
+	Locale      string // Language the client receives emails in, e.g. "en"
+	EmailStatus string // EmailOK unless mail to the address bounced or was reported as spam
+	EmailIssue  string // Why the address was flagged
+	Wallet      string // Address the client deposits from and is refunded to, empty if unknown
 }
 
 // Adds a new client to the database
 func CreateClient(db *sql.DB, client *Client) error {
-	_, err := db.Exec("INSERT INTO clients (name, email, address) VALUES (?, ?, ?)",
-		client.Name, client.Email, client.Address)
+	result, err := db.Exec("INSERT INTO clients (user_id, name, email, address, locale, wallet_address) VALUES (?, ?, ?, ?, ?, ?)",
+		client.UserID, client.Name, client.Email, client.Address, localeOrDefault(client.Locale), client.Wallet)
 	if err != nil {
 		return fmt.Errorf("failed to insert client: %v", err)
 	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get client id: %v", err)
+	}
+	client.ID = int(id)
 	return nil
 }
 
 // Retrieves a client from the database by ID
 func GetClientByID(db *sql.DB, id int) (*Client, error) {
 	client := &Client{}
-	err := db.QueryRow("SELECT id, name, email, address FROM clients WHERE id = ?", id).
-		Scan(&client.ID, &client.Name, &client.Email, &client.Address)
+	err := db.QueryRow("SELECT "+clientColumns+" FROM clients WHERE id = ?", id).
+		Scan(&client.ID, &client.UserID, &client.Name, &client.Email, &client.Address, &client.Locale, &client.EmailStatus, &client.EmailIssue, &client.Wallet)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get client: %v", err)
 	}
@@ -36,14 +53,49 @@ func GetClientByID(db *sql.DB, id int) (*Client, error) {
 
 // Updates a client's information in the database
 func UpdateClient(db *sql.DB, client *Client) error {
-	_, err := db.Exec("UPDATE clients SET name = ?, email = ?, address = ? WHERE id = ?",
-		client.Name, client.Email, client.Address, client.ID)
+	if client.EmailStatus == "" {
+		client.EmailStatus = EmailOK
+	}
+	_, err := db.Exec("UPDATE clients SET user_id = ?, name = ?, email = ?, address = ?, locale = ?, email_status = ?, email_issue = ?, wallet_address = ? WHERE id = ?",
+		client.UserID, client.Name, client.Email, client.Address, localeOrDefault(client.Locale), client.EmailStatus, client.EmailIssue, client.Wallet, client.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update client: %v", err)
 	}
 	return nil
 }
 
+// Flags every client using an address whose mail bounced or was reported as spam, returning the clients
+// that weren't already flagged
+func FlagClientEmail(db *sql.DB, email, status, issue string) ([]Client, error) {
+	rows, err := db.Query("SELECT "+clientColumns+" FROM clients WHERE lower(email) = lower(?) AND COALESCE(email_status, 'ok') = 'ok'", email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clients by email: %v", err)
+	}
+	var clients []Client
+	for rows.Next() {
+		var client Client
+		if err := rows.Scan(&client.ID, &client.UserID, &client.Name, &client.Email, &client.Address, &client.Locale, &client.EmailStatus, &client.EmailIssue, &client.Wallet); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan client: %v", err)
+		}
+		clients = append(clients, client)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get clients by email: %v", err)
+	}
+
+	for i := range clients {
+		clients[i].EmailStatus, clients[i].EmailIssue = status, issue
+		if _, err := db.Exec("UPDATE clients SET email_status = ?, email_issue = ? WHERE id = ?", status, issue, clients[i].ID); err != nil {
+			return nil, fmt.Errorf("failed to flag client email: %v", err)
+		}
+	}
+	return clients, nil
+}
+
+const clientColumns = "id, COALESCE(user_id, 0), name, email, COALESCE(address, ''), COALESCE(locale, 'en'), COALESCE(email_status, 'ok'), COALESCE(email_issue, ''), COALESCE(wallet_address, '')"
+
 // Removes a client from the database
 func DeleteClient(db *sql.DB, id int) error {
 	_, err := db.Exec("DELETE FROM clients WHERE id = ?", id)
@@ -0,0 +1,60 @@
+package db
+
+import (
+  "fmt"
+  "time"
+)
+
+// Represents a comment on a contract's timeline
+type ContractComment struct {
+  ID          int
+  ContractID  int
+  AuthorParty string // "client" or "freelancer"
+  AuthorEmail string
+  Body        string
+  Source      string // Where the comment came from, e.g. "email"
+  MessageID   string // Unique per inbound message so a reply is only recorded once
+  CreatedAt   time.Time
+}
+
+// Adds a comment to a contract; reports false if a comment for the same message was already added
+func AddContractComment(comment *ContractComment) (bool, error) {
+  result, err := DB.Exec("INSERT OR IGNORE INTO contract_comments (contract_id, author_party, author_email, body, source, message_id) VALUES (?, ?, ?, ?, ?, ?)",
+    comment.ContractID, comment.AuthorParty, comment.AuthorEmail, comment.Body, comment.Source, comment.MessageID)
+  if err != nil {
+    return false, fmt.Errorf("failed to add contract comment: %v", err)
+  }
+  if inserted, _ := result.RowsAffected(); inserted == 0 {
+    return false, nil
+  }
+
+  id, err := result.LastInsertId()
+  if err != nil {
+    return false, fmt.Errorf("failed to add contract comment: %v", err)
+  }
+  comment.ID = int(id)
+  return true, nil
+}
+
+// Retrieves a contract's comments, oldest first
+func GetContractComments(contractID int) ([]ContractComment, error) {
+  rows, err := DB.Query(`SELECT id, contract_id, author_party, author_email, body, source, message_id, created_at
+    FROM contract_comments WHERE contract_id = ? ORDER BY id`, contractID)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get contract comments: %v", err)
+  }
+  defer rows.Close()
+
+  var comments []ContractComment
+  for rows.Next() {
+    var c ContractComment
+    if err := rows.Scan(&c.ID, &c.ContractID, &c.AuthorParty, &c.AuthorEmail, &c.Body, &c.Source, &c.MessageID, &c.CreatedAt); err != nil {
+      return nil, fmt.Errorf("failed to scan contract comment: %v", err)
+    }
+    comments = append(comments, c)
+  }
+  if err := rows.Err(); err != nil {
+    return nil, fmt.Errorf("failed to get contract comments: %v", err)
+  }
+  return comments, nil
+}
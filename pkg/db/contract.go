@@ -1,23 +1,48 @@
 package db
 
 import (
+  "database/sql"
   "fmt"
+  "time"
+
+  "smart_contract/pkg/fees"
+  "smart_contract/pkg/money"
 )
 
 // Represents a contract entity in the database
 type Contract struct {
-  ID          int
-  ClientID    int
-  Description string
-  Status      string
-  Code        string // Added to store contract code
+  ID                int
+  ClientID          int
+  Address           string // Address of the deployed escrow contract, empty until deployed
+  Description       string
+  Status            string
+  Code              string         // Added to store contract code
+  Fees              fees.Breakdown // Fee breakdown quoted when the contract was initiated
+  PayoutTxID        string         // Transaction that paid the freelancer, empty until paid out
+  Deadline          time.Time      // When the work is due, recorded in the escrow contract
+  DeployTxHash      string         // Transaction deploying the escrow contract, set as soon as it is sent
+  DeployBlock       uint64         // Block the deployment was mined in, zero until confirmed
+  NetworkFeeSpent   money.Amount   // Gas paid for the contract's transactions so far in the network's native currency, budgeted by Fees.NetworkFee
+  NetworkFeeSettled money.Amount   // Network fee charged in place of the estimate, fixed when the payout is created; nil Units until then
+}
+
+// Returns the fee breakdown the freelancer is paid by: the quote, with the network fee settled once the payout was created
+func (c *Contract) SettledFees() (fees.Breakdown, error) {
+  if c.NetworkFeeSettled.Units == nil {
+    return c.Fees, nil
+  }
+  return c.Fees.WithNetworkFee(c.NetworkFeeSettled)
 }
 
 // Adds a new contract to the database
 func CreateContract(contract *Contract) (int, error) {
   var id int
-  err := DB.QueryRow("INSERT INTO contracts (client_id, description, status) VALUES (?, ?, ?) RETURNING id",
-    contract.ClientID, contract.Description, contract.Status).Scan(&id)
+  var deadline interface{}
+  if !contract.Deadline.IsZero() {
+    deadline = contract.Deadline
+  }
+  err := DB.QueryRow("INSERT INTO contracts (client_id, description, status, deadline) VALUES (?, ?, ?, ?) RETURNING id",
+    contract.ClientID, contract.Description, contract.Status, deadline).Scan(&id)
   if err != nil {
     return 0, fmt.Errorf("failed to insert contract: %v", err)
   }
@@ -33,6 +58,25 @@ func InsertContractCode(id int, code string) error {
   return nil
 }
 
+// Stores the fee breakdown quoted for a contract, with amounts in base units
+func SaveContractFees(id int, breakdown fees.Breakdown) error {
+  _, err := DB.Exec("UPDATE contracts SET currency = ?, fee_version = ?, gross_amount = ?, platform_fee = ?, network_fee = ?, net_amount = ? WHERE id = ?",
+    breakdown.Gross.Currency.Code, breakdown.ScheduleVersion, breakdown.Gross, breakdown.PlatformFee, breakdown.NetworkFee, breakdown.Net, id)
+  if err != nil {
+    return fmt.Errorf("failed to save contract fees: %v", err)
+  }
+  return nil
+}
+
+// Fixes the network fee charged for a contract in place of its estimate, keeping the first one settled
+func SettleContractNetworkFee(id int, fee money.Amount) error {
+  _, err := DB.Exec("UPDATE contracts SET network_fee_settled = ? WHERE id = ? AND network_fee_settled IS NULL", fee, id)
+  if err != nil {
+    return fmt.Errorf("failed to settle contract network fee: %v", err)
+  }
+  return nil
+}
+
 // Updates a contract's information in the database
 func UpdateContract(contract *Contract) error {
   _, err := DB.Exec("UPDATE contracts SET client_id = ?, description = ?, status = ? WHERE id = ?",
@@ -52,6 +96,84 @@ func UpdateContractStatus(id int, status string) error {
   return nil
 }
 
+// Records the transaction that paid the freelancer for a contract
+func SetContractPayoutTx(id int, txID string) error {
+  _, err := DB.Exec("UPDATE contracts SET payout_tx_id = ? WHERE id = ?", txID, id)
+  if err != nil {
+    return fmt.Errorf("failed to set contract payout transaction: %v", err)
+  }
+  return nil
+}
+
+// Records the transaction deploying a contract's escrow before it is mined, so a restart waits for it instead of deploying again
+func SetContractDeployTx(id int, txHash string) error {
+  _, err := DB.Exec("UPDATE contracts SET deploy_tx_hash = ? WHERE id = ?", txHash, id)
+  if err != nil {
+    return fmt.Errorf("failed to set contract deployment transaction: %v", err)
+  }
+  return nil
+}
+
+// Stores the address of a contract's confirmed escrow deployment along with the transaction and block that created it
+func SetContractDeployment(id int, address, txHash string, block uint64) error {
+  _, err := DB.Exec("UPDATE contracts SET address = ?, deploy_tx_hash = ?, deploy_block = ? WHERE id = ?", address, txHash, block, id)
+  if err != nil {
+    return fmt.Errorf("failed to set contract deployment: %v", err)
+  }
+  return nil
+}
+
+// Retrieves the ID of the contract whose escrow is deployed at address, or 0 if there is none
+func GetContractIDByAddress(address string) (int, error) {
+  var id int
+  err := DB.QueryRow("SELECT id FROM contracts WHERE address = ?", address).Scan(&id)
+  if err == sql.ErrNoRows {
+    return 0, nil
+  }
+  if err != nil {
+    return 0, fmt.Errorf("failed to get contract: %v", err)
+  }
+  return id, nil
+}
+
+// Retrieves the IDs of contracts with the given status whose escrow has not been deployed
+func GetUndeployedContractIDs(status string) ([]int, error) {
+  return queryContractIDs("SELECT id FROM contracts WHERE status = ? AND COALESCE(address, '') = '' ORDER BY id", status)
+}
+
+// Retrieves the IDs of contracts whose escrow has been deployed
+func GetDeployedContractIDs() ([]int, error) {
+  return queryContractIDs("SELECT id FROM contracts WHERE COALESCE(address, '') != '' ORDER BY id")
+}
+
+// Retrieves the IDs of all contracts with the given status
+func GetContractIDsByStatus(status string) ([]int, error) {
+  return queryContractIDs("SELECT id FROM contracts WHERE status = ? ORDER BY id", status)
+}
+
+// Retrieves the IDs of a client's contracts, oldest first
+func GetContractIDsByClient(clientID int) ([]int, error) {
+  return queryContractIDs("SELECT id FROM contracts WHERE client_id = ? ORDER BY id", clientID)
+}
+
+func queryContractIDs(query string, args ...interface{}) ([]int, error) {
+  rows, err := DB.Query(query, args...)
+  if err != nil {
+    return nil, fmt.Errorf("failed to query contracts: %v", err)
+  }
+  defer rows.Close()
+
+  var ids []int
+  for rows.Next() {
+    var id int
+    if err := rows.Scan(&id); err != nil {
+      return nil, fmt.Errorf("failed to scan contract: %v", err)
+    }
+    ids = append(ids, id)
+  }
+  return ids, rows.Err()
+}
+
 // Removes a contract from the database
 func DeleteContract(id int) error {
   _, err := DB.Exec("DELETE FROM contracts WHERE id = ?", id)
@@ -64,11 +186,61 @@ func DeleteContract(id int) error {
 // Retrieves a contract from the database by ID
 func GetContractByID(id int) (*Contract, error) {
   contract := &Contract{}
-  err := DB.QueryRow("SELECT id, client_id, description, status, code FROM contracts WHERE id = ?", id).
-    Scan(&contract.ID, &contract.ClientID, &contract.Description, &contract.Status, &contract.Code)
+  var currency string
+  var amounts [4]string
+  var spent, spentCurrency string
+  var settled sql.NullString
+  var deadline sql.NullTime
+  err := DB.QueryRow(`SELECT id, client_id, COALESCE(address, ''), description, status, COALESCE(code, ''), COALESCE(payout_tx_id, ''),
+      COALESCE(currency, ''), COALESCE(fee_version, ''), COALESCE(gross_amount, '0'), COALESCE(platform_fee, '0'), COALESCE(network_fee, '0'), COALESCE(net_amount, '0'),
+      deadline, COALESCE(deploy_tx_hash, ''), COALESCE(deploy_block, 0), COALESCE(network_fee_spent, '0'), COALESCE(network_fee_currency, ''),
+      network_fee_settled
+      FROM contracts WHERE id = ?`, id).
+    Scan(&contract.ID, &contract.ClientID, &contract.Address, &contract.Description, &contract.Status, &contract.Code, &contract.PayoutTxID,
+      &currency, &contract.Fees.ScheduleVersion, &amounts[0], &amounts[1], &amounts[2], &amounts[3],
+      &deadline, &contract.DeployTxHash, &contract.DeployBlock, &spent, &spentCurrency, &settled)
   if err != nil {
     return nil, fmt.Errorf("failed to get contract: %v", err)
   }
+  contract.Deadline = deadline.Time
+
+  if currency != "" {
+    if contract.Fees, err = scanBreakdown(contract.Fees.ScheduleVersion, currency, amounts); err != nil {
+      return nil, err
+    }
+    if settled.Valid {
+      if contract.NetworkFeeSettled, err = money.FromUnits(settled.String, contract.Fees.Gross.Currency); err != nil {
+        return nil, fmt.Errorf("failed to get contract network fees: %v", err)
+      }
+    }
+  }
+  // Fees are only spent once a transaction for the contract is mined, which records the network's currency
+  if spentCurrency != "" {
+    currency, err := money.LookupCurrency(spentCurrency)
+    if err != nil {
+      return nil, fmt.Errorf("failed to get contract network fees: %v", err)
+    }
+    if contract.NetworkFeeSpent, err = money.FromUnits(spent, currency); err != nil {
+      return nil, fmt.Errorf("failed to get contract network fees: %v", err)
+    }
+  }
   return contract, nil
 }
 
+// Rebuilds a fee breakdown from the base unit amounts stored on a contract
+func scanBreakdown(version, currencyCode string, amounts [4]string) (fees.Breakdown, error) {
+  currency, err := money.LookupCurrency(currencyCode)
+  if err != nil {
+    return fees.Breakdown{}, fmt.Errorf("failed to get contract fees: %v", err)
+  }
+
+  breakdown := fees.Breakdown{ScheduleVersion: version}
+  fields := []*money.Amount{&breakdown.Gross, &breakdown.PlatformFee, &breakdown.NetworkFee, &breakdown.Net}
+  for i, field := range fields {
+    if *field, err = money.FromUnits(amounts[i], currency); err != nil {
+      return fees.Breakdown{}, fmt.Errorf("failed to get contract fees: %v", err)
+    }
+  }
+  return breakdown, nil
+}
+
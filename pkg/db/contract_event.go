@@ -0,0 +1,150 @@
+package db
+
+import (
+  "database/sql"
+  "fmt"
+)
+
+// An event emitted by a contract's escrow, unconfirmed until it is buried under the network's confirmations
+type ContractEvent struct {
+  ID          int
+  ContractID  int
+  Name        string // e.g. "EscrowInitiated"
+  TxHash      string
+  LogIndex    uint
+  BlockNumber uint64
+  BlockHash   string
+  Data        string // The event's fields, JSON encoded
+  Confirmed   bool   // Whether the event has been applied to the contract's status
+}
+
+// How far the events of a contract's escrow have been read
+type ContractEventCursor struct {
+  ContractID     int
+  NextBlock      uint64 // First block not read yet
+  LastHash       string // Hash of the block before NextBlock when it was read, empty if unknown
+  ConfirmedBlock uint64 // First block whose events have not all been confirmed
+}
+
+// Retrieves a contract's event cursor, or nil if its events have never been read
+func GetContractEventCursor(contractID int) (*ContractEventCursor, error) {
+  cursor := &ContractEventCursor{}
+  err := DB.QueryRow("SELECT contract_id, next_block, COALESCE(last_hash, ''), confirmed_block FROM contract_event_cursors WHERE contract_id = ?", contractID).
+    Scan(&cursor.ContractID, &cursor.NextBlock, &cursor.LastHash, &cursor.ConfirmedBlock)
+  if err == sql.ErrNoRows {
+    return nil, nil
+  }
+  if err != nil {
+    return nil, fmt.Errorf("failed to get contract event cursor: %v", err)
+  }
+  return cursor, nil
+}
+
+// Stores events read from a contract's escrow as unconfirmed, moving its cursor past the blocks they were read from
+func SaveContractEvents(cursor *ContractEventCursor, events []ContractEvent) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  for _, event := range events {
+    _, err := tx.Exec(`INSERT OR IGNORE INTO contract_events (contract_id, name, tx_hash, log_index, block_number, block_hash, data)
+      VALUES (?, ?, ?, ?, ?, ?, ?)`,
+      cursor.ContractID, event.Name, event.TxHash, event.LogIndex, event.BlockNumber, event.BlockHash, event.Data)
+    if err != nil {
+      return fmt.Errorf("failed to insert contract event: %v", err)
+    }
+  }
+  if err := saveContractEventCursor(tx, cursor); err != nil {
+    return err
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit contract events: %v", err)
+  }
+  return nil
+}
+
+// Deletes a contract's unconfirmed events after a reorg and rewinds its cursor to the first block whose events
+// were not confirmed, so they are read again from the new chain
+func RollBackContractEvents(contractID int) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  if _, err := tx.Exec("DELETE FROM contract_events WHERE contract_id = ? AND confirmed = 0", contractID); err != nil {
+    return fmt.Errorf("failed to delete unconfirmed contract events: %v", err)
+  }
+  if _, err := tx.Exec("UPDATE contract_event_cursors SET next_block = confirmed_block, last_hash = NULL WHERE contract_id = ?", contractID); err != nil {
+    return fmt.Errorf("failed to rewind contract event cursor: %v", err)
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit contract events: %v", err)
+  }
+  return nil
+}
+
+// Retrieves a contract's unconfirmed events up to and including a block, in the order they were emitted
+func GetUnconfirmedContractEvents(contractID int, toBlock uint64) ([]ContractEvent, error) {
+  rows, err := DB.Query(`SELECT id, contract_id, name, tx_hash, log_index, block_number, block_hash, COALESCE(data, ''), confirmed
+    FROM contract_events WHERE contract_id = ? AND confirmed = 0 AND block_number <= ? ORDER BY block_number, log_index`, contractID, toBlock)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get contract events: %v", err)
+  }
+  defer rows.Close()
+
+  var events []ContractEvent
+  for rows.Next() {
+    var event ContractEvent
+    err := rows.Scan(&event.ID, &event.ContractID, &event.Name, &event.TxHash, &event.LogIndex, &event.BlockNumber, &event.BlockHash, &event.Data, &event.Confirmed)
+    if err != nil {
+      return nil, fmt.Errorf("failed to scan contract event: %v", err)
+    }
+    events = append(events, event)
+  }
+  return events, rows.Err()
+}
+
+// Marks an event confirmed once it has been applied to its contract
+func ConfirmContractEvent(id int) error {
+  _, err := DB.Exec("UPDATE contract_events SET confirmed = 1 WHERE id = ?", id)
+  if err != nil {
+    return fmt.Errorf("failed to confirm contract event: %v", err)
+  }
+  return nil
+}
+
+// Records that every event of a contract's escrow before a block has been confirmed
+func SetContractEventsConfirmed(contractID int, block uint64) error {
+  _, err := DB.Exec("UPDATE contract_event_cursors SET confirmed_block = ? WHERE contract_id = ?", block, contractID)
+  if err != nil {
+    return fmt.Errorf("failed to update contract event cursor: %v", err)
+  }
+  return nil
+}
+
+// Reports whether a contract's escrow has emitted a confirmed event with the given name
+func HasConfirmedContractEvent(contractID int, name string) (bool, error) {
+  var count int
+  err := DB.QueryRow("SELECT COUNT(*) FROM contract_events WHERE contract_id = ? AND name = ? AND confirmed = 1", contractID, name).Scan(&count)
+  if err != nil {
+    return false, fmt.Errorf("failed to count contract events: %v", err)
+  }
+  return count > 0, nil
+}
+
+func saveContractEventCursor(tx *sql.Tx, cursor *ContractEventCursor) error {
+  var lastHash interface{}
+  if cursor.LastHash != "" {
+    lastHash = cursor.LastHash
+  }
+  _, err := tx.Exec(`INSERT INTO contract_event_cursors (contract_id, next_block, last_hash, confirmed_block) VALUES (?, ?, ?, ?)
+    ON CONFLICT (contract_id) DO UPDATE SET next_block = excluded.next_block, last_hash = excluded.last_hash`,
+    cursor.ContractID, cursor.NextBlock, lastHash, cursor.ConfirmedBlock)
+  if err != nil {
+    return fmt.Errorf("failed to save contract event cursor: %v", err)
+  }
+  return nil
+}
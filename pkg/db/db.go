@@ -40,7 +40,9 @@ func Initialize(dbPath string) error {
       first_name TEXT,
       last_name TEXT,
       email TEXT UNIQUE,
-      password TEXT
+      password TEXT,
+      plan TEXT,
+      locale TEXT DEFAULT 'en'
     );
 
     CREATE TABLE IF NOT EXISTS clients (
@@ -48,6 +50,11 @@ func Initialize(dbPath string) error {
       user_id INTEGER,
       name TEXT,
       email TEXT,
+      address TEXT,
+      locale TEXT DEFAULT 'en',
+      email_status TEXT DEFAULT 'ok',
+      email_issue TEXT,
+      wallet_address TEXT,
       FOREIGN KEY (user_id) REFERENCES users(id)
     );
 
@@ -58,9 +65,250 @@ func Initialize(dbPath string) error {
       status TEXT,
       client_id INTEGER,
       description TEXT,
+      currency TEXT,
+      fee_version TEXT,
+      gross_amount TEXT,
+      platform_fee TEXT,
+      network_fee TEXT,
+      net_amount TEXT,
+      payout_tx_id TEXT,
+      deadline DATETIME,
+      deploy_tx_hash TEXT,
+      deploy_block INTEGER,
+      network_fee_spent TEXT,
+      network_fee_currency TEXT,
+      network_fee_settled TEXT,
       FOREIGN KEY (client_id) REFERENCES clients(id)
     );
 
+    CREATE TABLE IF NOT EXISTS contract_milestones (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      contract_id INTEGER,
+      position INTEGER,
+      description TEXT,
+      UNIQUE (contract_id, position),
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS payment_intents (
+      id TEXT PRIMARY KEY,
+      provider TEXT,
+      contract_id INTEGER,
+      amount TEXT,
+      received TEXT,
+      currency TEXT,
+      status TEXT,
+      payment_link TEXT,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS payment_events (
+      provider TEXT,
+      event_id TEXT,
+      type TEXT,
+      intent_id TEXT,
+      contract_id INTEGER,
+      status TEXT,
+      amount TEXT,
+      currency TEXT,
+      reference TEXT,
+      payload BLOB,
+      processed BOOLEAN DEFAULT 0,
+      error TEXT,
+      received_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      processed_at DATETIME,
+      PRIMARY KEY (provider, event_id)
+    );
+
+    CREATE TABLE IF NOT EXISTS payment_cursors (
+      provider TEXT PRIMARY KEY,
+      next_block INTEGER
+    );
+
+    CREATE TABLE IF NOT EXISTS ledger_entries (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      contract_id INTEGER,
+      kind TEXT,
+      reference TEXT UNIQUE,
+      description TEXT,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS ledger_lines (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      entry_id INTEGER,
+      contract_id INTEGER,
+      account TEXT,
+      debit TEXT,
+      credit TEXT,
+      currency TEXT,
+      FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS cancellation_requests (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      contract_id INTEGER,
+      requested_by TEXT,
+      reason TEXT,
+      status TEXT,
+      resolved_by TEXT,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      resolved_at DATETIME,
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS payout_accounts (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      user_id INTEGER,
+      kind TEXT,
+      wallet_address TEXT,
+      provider TEXT,
+      destination TEXT,
+      is_default BOOLEAN DEFAULT 0,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      FOREIGN KEY (user_id) REFERENCES users(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS payouts (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      contract_id INTEGER UNIQUE,
+      payout_account_id INTEGER,
+      amount TEXT,
+      currency TEXT,
+      status TEXT,
+      attempts INTEGER DEFAULT 0,
+      last_error TEXT,
+      tx_id TEXT,
+      idempotency_key TEXT,
+      next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      FOREIGN KEY (contract_id) REFERENCES contracts(id),
+      FOREIGN KEY (payout_account_id) REFERENCES payout_accounts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS invoices (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      number TEXT UNIQUE,
+      kind TEXT,
+      contract_id INTEGER,
+      html TEXT,
+      pdf BLOB,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE (contract_id, kind),
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS invoice_counters (
+      series TEXT PRIMARY KEY,
+      last INTEGER
+    );
+
+    CREATE TABLE IF NOT EXISTS quotes (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      contract_id INTEGER,
+      quoted_amount TEXT,
+      quoted_currency TEXT,
+      amount TEXT,
+      currency TEXT,
+      rate TEXT,
+      source TEXT,
+      locked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      expires_at DATETIME,
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS email_outbox (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      contract_id INTEGER,
+      event TEXT,
+      recipient TEXT,
+      reply_to TEXT,
+      unsubscribe_url TEXT,
+      subject TEXT,
+      text_body TEXT,
+      html_body TEXT,
+      attachments TEXT,
+      status TEXT DEFAULT 'pending',
+      attempts INTEGER DEFAULT 0,
+      last_error TEXT,
+      next_attempt_at DATETIME,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      sent_at DATETIME,
+      UNIQUE (contract_id, event, recipient),
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS notification_preferences (
+      email TEXT,
+      category TEXT,
+      subscribed BOOLEAN,
+      updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      PRIMARY KEY (email, category)
+    );
+
+    CREATE TABLE IF NOT EXISTS contract_comments (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      contract_id INTEGER,
+      author_party TEXT,
+      author_email TEXT,
+      body TEXT,
+      source TEXT,
+      message_id TEXT UNIQUE,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS contract_events (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      contract_id INTEGER,
+      name TEXT,
+      tx_hash TEXT,
+      log_index INTEGER,
+      block_number INTEGER,
+      block_hash TEXT,
+      data TEXT,
+      confirmed INTEGER DEFAULT 0,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE (tx_hash, log_index),
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS contract_event_cursors (
+      contract_id INTEGER PRIMARY KEY,
+      next_block INTEGER,
+      last_hash TEXT,
+      confirmed_block INTEGER,
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS chain_transactions (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      sender TEXT,
+      nonce INTEGER,
+      contract_id INTEGER,
+      description TEXT,
+      hash TEXT,
+      raw TEXT,
+      status TEXT DEFAULT 'pending',
+      replacements INTEGER DEFAULT 0,
+      block_number INTEGER,
+      gas_used INTEGER,
+      fee TEXT,
+      last_error TEXT,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      sent_at DATETIME,
+      finished_at DATETIME,
+      UNIQUE (sender, nonce),
+      FOREIGN KEY (contract_id) REFERENCES contracts(id)
+    );
+
+    CREATE TABLE IF NOT EXISTS chain_transaction_hashes (
+      hash TEXT PRIMARY KEY,
+      transaction_id INTEGER,
+      FOREIGN KEY (transaction_id) REFERENCES chain_transactions(id)
+    );
+
     CREATE TABLE IF NOT EXISTS contract_copies (
       id INTEGER PRIMARY KEY AUTOINCREMENT,
       contract_id INTEGER,
@@ -72,11 +320,91 @@ func Initialize(dbPath string) error {
   if err != nil {
     return fmt.Errorf("failed to create tables: %v", err)
   }
+  if err := migrate(); err != nil {
+    return err
+  }
 
   log.Println("Database initialized")
   return nil
 }
 
+// Columns added to tables after they were first created. CREATE TABLE IF NOT EXISTS leaves existing tables as
+// they were, so databases created before a column was added get it here.
+var addedColumns = []struct {
+  Table      string
+  Column     string
+  Definition string
+}{
+  {"users", "plan", "TEXT"},
+  {"users", "locale", "TEXT DEFAULT 'en'"},
+  {"clients", "address", "TEXT"},
+  {"clients", "locale", "TEXT DEFAULT 'en'"},
+  {"clients", "email_status", "TEXT DEFAULT 'ok'"},
+  {"clients", "email_issue", "TEXT"},
+  {"clients", "wallet_address", "TEXT"},
+  {"contracts", "currency", "TEXT"},
+  {"contracts", "fee_version", "TEXT"},
+  {"contracts", "gross_amount", "TEXT"},
+  {"contracts", "platform_fee", "TEXT"},
+  {"contracts", "network_fee", "TEXT"},
+  {"contracts", "net_amount", "TEXT"},
+  {"contracts", "payout_tx_id", "TEXT"},
+  {"contracts", "deadline", "DATETIME"},
+  {"contracts", "deploy_tx_hash", "TEXT"},
+  {"contracts", "deploy_block", "INTEGER"},
+  {"contracts", "network_fee_spent", "TEXT"},
+  {"contracts", "network_fee_currency", "TEXT"},
+  {"contracts", "network_fee_settled", "TEXT"},
+  {"payment_intents", "received", "TEXT"},
+  {"payment_events", "reference", "TEXT"},
+  {"payouts", "idempotency_key", "TEXT"},
+  {"email_outbox", "reply_to", "TEXT"},
+  {"email_outbox", "unsubscribe_url", "TEXT"},
+  {"chain_transactions", "contract_id", "INTEGER"},
+  {"chain_transactions", "gas_used", "INTEGER"},
+  {"chain_transactions", "fee", "TEXT"},
+}
+
+// Adds the columns an existing database is missing
+func migrate() error {
+  for _, added := range addedColumns {
+    exists, err := hasColumn(added.Table, added.Column)
+    if err != nil {
+      return err
+    }
+    if exists {
+      continue
+    }
+    if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", added.Table, added.Column, added.Definition)); err != nil {
+      return fmt.Errorf("failed to add %s.%s: %v", added.Table, added.Column, err)
+    }
+    log.Printf("Added column %s.%s", added.Table, added.Column)
+  }
+  return nil
+}
+
+// Reports whether a table has a column
+func hasColumn(table, column string) (bool, error) {
+  rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+  if err != nil {
+    return false, fmt.Errorf("failed to read columns of %s: %v", table, err)
+  }
+  defer rows.Close()
+
+  for rows.Next() {
+    var cid, notNull, pk int
+    var name, kind string
+    var defaultValue sql.NullString
+    if err := rows.Scan(&cid, &name, &kind, &notNull, &defaultValue, &pk); err != nil {
+      return false, fmt.Errorf("failed to read columns of %s: %v", table, err)
+    }
+    if name == column {
+      return true, nil
+    }
+  }
+  return false, rows.Err()
+}
+
 // Prints the schema of the SQLite database
 func PrintSchema() {
   rows, err := DB.Query("SELECT name, sql FROM sqlite_master WHERE type='table';")
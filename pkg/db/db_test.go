@@ -0,0 +1,58 @@
+package db
+
+import (
+  "database/sql"
+  "path/filepath"
+  "testing"
+
+  _ "github.com/mattn/go-sqlite3"
+)
+
+func TestInitializeMigratesExistingDatabase(t *testing.T) {
+  path := filepath.Join(t.TempDir(), "test.db")
+
+  // The schema before fees, payouts and deployments were added
+  old, err := sql.Open("sqlite3", path)
+  if err != nil {
+    t.Fatal(err)
+  }
+  _, err = old.Exec(`
+    CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, first_name TEXT, last_name TEXT, email TEXT UNIQUE, password TEXT);
+    CREATE TABLE clients (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER, name TEXT, email TEXT);
+    CREATE TABLE contracts (id INTEGER PRIMARY KEY AUTOINCREMENT, address TEXT UNIQUE, code TEXT, status TEXT, client_id INTEGER, description TEXT);
+    INSERT INTO users (first_name, last_name, email, password) VALUES ('Fran', 'Lee', 'fran@example.com', '');
+  `)
+  old.Close()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  if err := Initialize(path); err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { DB.Close() })
+
+  for _, added := range addedColumns {
+    exists, err := hasColumn(added.Table, added.Column)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if !exists {
+      t.Errorf("%s.%s was not added", added.Table, added.Column)
+    }
+  }
+
+  user, err := GetUserByEmail("fran@example.com")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if user.Locale != "en" {
+    t.Errorf("existing user has locale %q, want the default", user.Locale)
+  }
+
+  // Initializing an up to date database again changes nothing
+  DB.Close()
+  if err := Initialize(path); err != nil {
+    t.Fatal(err)
+  }
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+  "fmt"
+  "time"
+)
+
+// Represents an invoice or receipt issued for a contract
+type Invoice struct {
+  ID         int
+  Number     string
+  Kind       string // "invoice" or "receipt"
+  ContractID int
+  HTML       string
+  PDF        []byte
+  CreatedAt  time.Time
+}
+
+// Numbers, renders and stores an invoice in one transaction, e.g. INV-2024-000042, so that
+// concurrent documents can't be issued under the same number
+func CreateInvoice(invoice *Invoice, prefix string, render func(number string) (string, []byte, error)) (int, error) {
+  tx, err := DB.Begin()
+  if err != nil {
+    return 0, fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  // The counter of a series starts from the invoices numbered before it existed
+  year := invoice.CreatedAt.Year()
+  series := fmt.Sprintf("%s-%d", prefix, year)
+  _, err = tx.Exec(`INSERT INTO invoice_counters (series, last) VALUES (?, (SELECT COUNT(*) FROM invoices WHERE number LIKE ?))
+    ON CONFLICT (series) DO NOTHING`, series, series+"-%")
+  if err != nil {
+    return 0, fmt.Errorf("failed to create invoice counter: %v", err)
+  }
+  var last int
+  if err := tx.QueryRow("UPDATE invoice_counters SET last = last + 1 WHERE series = ? RETURNING last", series).Scan(&last); err != nil {
+    return 0, fmt.Errorf("failed to allocate invoice number: %v", err)
+  }
+
+  invoice.Number = fmt.Sprintf("%s-%06d", series, last)
+  if invoice.HTML, invoice.PDF, err = render(invoice.Number); err != nil {
+    return 0, err
+  }
+
+  var id int
+  err = tx.QueryRow("INSERT INTO invoices (number, kind, contract_id, html, pdf) VALUES (?, ?, ?, ?, ?) RETURNING id",
+    invoice.Number, invoice.Kind, invoice.ContractID, invoice.HTML, invoice.PDF).Scan(&id)
+  if err != nil {
+    return 0, fmt.Errorf("failed to insert invoice: %v", err)
+  }
+  if err := tx.Commit(); err != nil {
+    return 0, fmt.Errorf("failed to commit invoice: %v", err)
+  }
+  return id, nil
+}
+
+// Retrieves the invoice or receipt of a contract
+func GetInvoiceByContract(contractID int, kind string) (*Invoice, error) {
+  invoice := &Invoice{}
+  err := DB.QueryRow("SELECT id, number, kind, contract_id, html, pdf, created_at FROM invoices WHERE contract_id = ? AND kind = ?", contractID, kind).
+    Scan(&invoice.ID, &invoice.Number, &invoice.Kind, &invoice.ContractID, &invoice.HTML, &invoice.PDF, &invoice.CreatedAt)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get invoice: %v", err)
+  }
+  return invoice, nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+  "database/sql"
+  "fmt"
+
+  "smart_contract/pkg/money"
+)
+
+// Represents a journal entry in the ledger
+type LedgerEntry struct {
+  ID          int64
+  ContractID  int
+  Kind        string
+  Reference   string // Unique per movement of money so it is never posted twice
+  Description string
+  Lines       []LedgerLine
+}
+
+// Represents one side of a journal entry against a single account
+type LedgerLine struct {
+  Account string
+  Debit   money.Amount
+  Credit  money.Amount
+}
+
+// Adds a journal entry and its lines in one transaction; reports false if the reference was already posted
+func InsertLedgerEntry(entry *LedgerEntry) (bool, error) {
+  tx, err := DB.Begin()
+  if err != nil {
+    return false, fmt.Errorf("failed to begin ledger transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  posted, err := insertLedgerEntry(tx, entry)
+  if err != nil || !posted {
+    return false, err
+  }
+  if err := tx.Commit(); err != nil {
+    return false, fmt.Errorf("failed to commit ledger entry: %v", err)
+  }
+  return true, nil
+}
+
+// Reports whether an entry with the reference has been posted
+func LedgerEntryPosted(reference string) (bool, error) {
+  var count int
+  if err := DB.QueryRow("SELECT COUNT(*) FROM ledger_entries WHERE reference = ?", reference).Scan(&count); err != nil {
+    return false, fmt.Errorf("failed to query ledger entries: %v", err)
+  }
+  return count > 0, nil
+}
+
+func insertLedgerEntry(tx *sql.Tx, entry *LedgerEntry) (bool, error) {
+  result, err := tx.Exec("INSERT OR IGNORE INTO ledger_entries (contract_id, kind, reference, description) VALUES (?, ?, ?, ?)",
+    entry.ContractID, entry.Kind, entry.Reference, entry.Description)
+  if err != nil {
+    return false, fmt.Errorf("failed to insert ledger entry: %v", err)
+  }
+  if inserted, _ := result.RowsAffected(); inserted == 0 {
+    return false, nil
+  }
+
+  entry.ID, err = result.LastInsertId()
+  if err != nil {
+    return false, fmt.Errorf("failed to insert ledger entry: %v", err)
+  }
+
+  for _, line := range entry.Lines {
+    currency := line.Debit.Currency
+    if line.Debit.IsZero() {
+      currency = line.Credit.Currency
+    }
+    _, err := tx.Exec("INSERT INTO ledger_lines (entry_id, contract_id, account, debit, credit, currency) VALUES (?, ?, ?, ?, ?, ?)",
+      entry.ID, entry.ContractID, line.Account, line.Debit, line.Credit, currency.Code)
+    if err != nil {
+      return false, fmt.Errorf("failed to insert ledger line: %v", err)
+    }
+  }
+  return true, nil
+}
+
+// Retrieves every ledger line posted for a contract
+func GetLedgerLines(contractID int) ([]LedgerLine, error) {
+  rows, err := DB.Query("SELECT account, debit, credit, currency FROM ledger_lines WHERE contract_id = ? ORDER BY id", contractID)
+  if err != nil {
+    return nil, fmt.Errorf("failed to query ledger lines: %v", err)
+  }
+  defer rows.Close()
+
+  var lines []LedgerLine
+  for rows.Next() {
+    var line LedgerLine
+    var debit, credit, currencyCode string
+    if err := rows.Scan(&line.Account, &debit, &credit, &currencyCode); err != nil {
+      return nil, fmt.Errorf("failed to scan ledger line: %v", err)
+    }
+
+    currency, err := money.LookupCurrency(currencyCode)
+    if err != nil {
+      return nil, fmt.Errorf("failed to scan ledger line: %v", err)
+    }
+    if line.Debit, err = money.FromUnits(debit, currency); err != nil {
+      return nil, fmt.Errorf("failed to scan ledger line: %v", err)
+    }
+    if line.Credit, err = money.FromUnits(credit, currency); err != nil {
+      return nil, fmt.Errorf("failed to scan ledger line: %v", err)
+    }
+    lines = append(lines, line)
+  }
+  return lines, rows.Err()
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+  "fmt"
+)
+
+// A deliverable of a contract, committed to by hash in its escrow contract
+type ContractMilestone struct {
+  ID          int
+  ContractID  int
+  Position    int // Order of the milestone within its contract, starting at 0
+  Description string
+}
+
+// Adds a contract's milestones in order
+func AddContractMilestones(contractID int, descriptions []string) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  for position, description := range descriptions {
+    _, err := tx.Exec("INSERT INTO contract_milestones (contract_id, position, description) VALUES (?, ?, ?)", contractID, position, description)
+    if err != nil {
+      return fmt.Errorf("failed to insert contract milestone: %v", err)
+    }
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit contract milestones: %v", err)
+  }
+  return nil
+}
+
+// Retrieves a contract's milestones in order
+func GetContractMilestones(contractID int) ([]ContractMilestone, error) {
+  rows, err := DB.Query("SELECT id, contract_id, position, description FROM contract_milestones WHERE contract_id = ? ORDER BY position", contractID)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get contract milestones: %v", err)
+  }
+  defer rows.Close()
+
+  var milestones []ContractMilestone
+  for rows.Next() {
+    var milestone ContractMilestone
+    if err := rows.Scan(&milestone.ID, &milestone.ContractID, &milestone.Position, &milestone.Description); err != nil {
+      return nil, fmt.Errorf("failed to scan contract milestone: %v", err)
+    }
+    milestones = append(milestones, milestone)
+  }
+  return milestones, rows.Err()
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+  "database/sql"
+  "fmt"
+  "strings"
+)
+
+// Records whether an address wants a category of optional notices; addresses are subscribed until they opt out
+func SetNotificationPreference(email, category string, subscribed bool) error {
+  _, err := DB.Exec("INSERT OR REPLACE INTO notification_preferences (email, category, subscribed, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)",
+    strings.ToLower(email), category, subscribed)
+  if err != nil {
+    return fmt.Errorf("failed to save notification preference: %v", err)
+  }
+  return nil
+}
+
+// Reports whether an address has opted out of a category of notices
+func IsUnsubscribed(email, category string) (bool, error) {
+  var subscribed bool
+  err := DB.QueryRow("SELECT subscribed FROM notification_preferences WHERE email = ? AND category = ?", strings.ToLower(email), category).Scan(&subscribed)
+  if err == sql.ErrNoRows {
+    return false, nil
+  }
+  if err != nil {
+    return false, fmt.Errorf("failed to get notification preference: %v", err)
+  }
+  return !subscribed, nil
+}
@@ -0,0 +1,209 @@
+package db
+
+import (
+  "database/sql"
+  "encoding/json"
+  "fmt"
+  "time"
+
+  "smart_contract/pkg/email"
+)
+
+// Statuses of an email in the outbox
+const (
+  OutboxPending = "pending"
+  OutboxSent    = "sent"
+  OutboxFailed  = "failed"
+)
+
+// Represents an email waiting in, or delivered from, the outbox
+type OutboxEmail struct {
+  ID            int
+  ContractID    int
+  Event         string // What the email is about, e.g. "status:payment_made"; sent once per contract and recipient
+  Recipient     string
+  ReplyTo       string // Routes replies back to the contract; empty when inbound mail isn't configured
+  Unsubscribe   string // One-click unsubscribe link, set for notices the recipient can opt out of
+  Subject       string
+  Text          string
+  HTML          string
+  Attachments   []email.Attachment
+  Status        string
+  Attempts      int
+  LastError     string
+  NextAttemptAt time.Time
+  CreatedAt     time.Time
+}
+
+// Summarises the outbox for monitoring
+type OutboxStats struct {
+  Counts        map[string]int
+  OldestPending *time.Time // Nil when nothing is waiting
+  Failures      []OutboxEmail
+}
+
+// Adds emails to the outbox, ignoring any already queued for the same contract, event and recipient
+func EnqueueEmails(emails []OutboxEmail) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  if err := enqueueEmails(tx, emails); err != nil {
+    return err
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit outbox emails: %v", err)
+  }
+  return nil
+}
+
+// Moves a contract from one status to another, posts the ledger entries for any money the change moves and queues
+// the emails announcing it in one transaction. Fails without changing anything if the contract is no longer in the
+// status it was moved from. Entries that were already posted are left as they are
+func UpdateContractStatusWithEmails(id int, from, to string, emails []OutboxEmail, entries ...*LedgerEntry) error {
+  tx, err := DB.Begin()
+  if err != nil {
+    return fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  result, err := tx.Exec("UPDATE contracts SET status = ? WHERE id = ? AND COALESCE(status, '') = ?", to, id, from)
+  if err != nil {
+    return fmt.Errorf("failed to update contract status: %v", err)
+  }
+  if updated, err := result.RowsAffected(); err != nil {
+    return fmt.Errorf("failed to update contract status: %v", err)
+  } else if updated == 0 {
+    return fmt.Errorf("contract %d is no longer %q", id, from)
+  }
+  for _, entry := range entries {
+    if _, err := insertLedgerEntry(tx, entry); err != nil {
+      return err
+    }
+  }
+  if err := enqueueEmails(tx, emails); err != nil {
+    return err
+  }
+  if err := tx.Commit(); err != nil {
+    return fmt.Errorf("failed to commit contract status: %v", err)
+  }
+  return nil
+}
+
+func enqueueEmails(tx *sql.Tx, emails []OutboxEmail) error {
+  for _, e := range emails {
+    attachments, err := json.Marshal(e.Attachments)
+    if err != nil {
+      return fmt.Errorf("failed to encode attachments: %v", err)
+    }
+    _, err = tx.Exec(`INSERT OR IGNORE INTO email_outbox (contract_id, event, recipient, reply_to, unsubscribe_url, subject, text_body, html_body, attachments, status, next_attempt_at)
+      VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      e.ContractID, e.Event, e.Recipient, e.ReplyTo, e.Unsubscribe, e.Subject, e.Text, e.HTML, string(attachments), OutboxPending, time.Now().UTC())
+    if err != nil {
+      return fmt.Errorf("failed to enqueue email: %v", err)
+    }
+  }
+  return nil
+}
+
+// Retrieves pending emails whose next attempt is due, oldest first
+func GetDueEmails(now time.Time, limit int) ([]OutboxEmail, error) {
+  return queryOutbox("WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?", OutboxPending, now.UTC(), limit)
+}
+
+// Marks an email as delivered
+func MarkEmailSent(id int, attempts int) error {
+  _, err := DB.Exec("UPDATE email_outbox SET status = ?, attempts = ?, last_error = '', sent_at = ? WHERE id = ?",
+    OutboxSent, attempts, time.Now().UTC(), id)
+  if err != nil {
+    return fmt.Errorf("failed to mark email sent: %v", err)
+  }
+  return nil
+}
+
+// Records a failed delivery attempt, scheduling the next one or giving up when status is failed
+func MarkEmailAttempt(id int, status string, attempts int, lastError string, next time.Time) error {
+  _, err := DB.Exec("UPDATE email_outbox SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?",
+    status, attempts, lastError, next.UTC(), id)
+  if err != nil {
+    return fmt.Errorf("failed to record email attempt: %v", err)
+  }
+  return nil
+}
+
+// Counts a contract's emails by status and lists its most recent permanent failures
+func GetOutboxStats(contractID, failures int) (*OutboxStats, error) {
+  stats := &OutboxStats{Counts: map[string]int{}}
+
+  rows, err := DB.Query("SELECT status, COUNT(*) FROM email_outbox WHERE contract_id = ? GROUP BY status", contractID)
+  if err != nil {
+    return nil, fmt.Errorf("failed to count outbox emails: %v", err)
+  }
+  defer rows.Close()
+  for rows.Next() {
+    var status string
+    var count int
+    if err := rows.Scan(&status, &count); err != nil {
+      return nil, fmt.Errorf("failed to count outbox emails: %v", err)
+    }
+    stats.Counts[status] = count
+  }
+  if err := rows.Err(); err != nil {
+    return nil, fmt.Errorf("failed to count outbox emails: %v", err)
+  }
+
+  pending, err := queryOutbox("WHERE contract_id = ? AND status = ? ORDER BY created_at LIMIT 1", contractID, OutboxPending)
+  if err != nil {
+    return nil, err
+  }
+  if len(pending) > 0 {
+    stats.OldestPending = &pending[0].CreatedAt
+  }
+
+  stats.Failures, err = queryOutbox("WHERE contract_id = ? AND status = ? ORDER BY id DESC LIMIT ?", contractID, OutboxFailed, failures)
+  if err != nil {
+    return nil, err
+  }
+  return stats, nil
+}
+
+func queryOutbox(where string, args ...interface{}) ([]OutboxEmail, error) {
+  rows, err := DB.Query(`SELECT id, contract_id, event, recipient, COALESCE(reply_to, ''), COALESCE(unsubscribe_url, ''), subject, text_body, COALESCE(html_body, ''), COALESCE(attachments, 'null'),
+    status, attempts, COALESCE(last_error, ''), next_attempt_at, created_at FROM email_outbox `+where, args...)
+  if err != nil {
+    return nil, fmt.Errorf("failed to query outbox: %v", err)
+  }
+  defer rows.Close()
+
+  var emails []OutboxEmail
+  for rows.Next() {
+    var e OutboxEmail
+    var attachments string
+    err := rows.Scan(&e.ID, &e.ContractID, &e.Event, &e.Recipient, &e.ReplyTo, &e.Unsubscribe, &e.Subject, &e.Text, &e.HTML, &attachments,
+      &e.Status, &e.Attempts, &e.LastError, &e.NextAttemptAt, &e.CreatedAt)
+    if err != nil {
+      return nil, fmt.Errorf("failed to scan outbox email: %v", err)
+    }
+    if err := json.Unmarshal([]byte(attachments), &e.Attachments); err != nil {
+      return nil, fmt.Errorf("failed to decode attachments: %v", err)
+    }
+    emails = append(emails, e)
+  }
+  if err := rows.Err(); err != nil {
+    return nil, fmt.Errorf("failed to query outbox: %v", err)
+  }
+  return emails, nil
+}
+
+// Counts the distinct events queued for a contract whose names start with prefix
+func CountOutboxEvents(contractID int, prefix string) (int, error) {
+  var count int
+  err := DB.QueryRow("SELECT COUNT(DISTINCT event) FROM email_outbox WHERE contract_id = ? AND substr(event, 1, ?) = ?",
+    contractID, len(prefix), prefix).Scan(&count)
+  if err != nil {
+    return 0, fmt.Errorf("failed to count outbox events: %v", err)
+  }
+  return count, nil
+}
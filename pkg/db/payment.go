@@ -0,0 +1,271 @@
+package db
+
+import (
+  "database/sql"
+  "fmt"
+
+  "smart_contract/pkg/money"
+)
+
+// Represents a payment intent created with a payment provider
+type PaymentIntent struct {
+  ID          string
+  Provider    string
+  ContractID  int
+  Amount      money.Amount
+  Received    money.Amount // Credited to escrow so far, which partial payments leave short of Amount
+  Status      string
+  PaymentLink string
+}
+
+// Adds a new payment intent to the database; an intent already stored under the ID keeps what it has received
+func CreatePaymentIntent(intent *PaymentIntent) error {
+  _, err := DB.Exec("INSERT OR IGNORE INTO payment_intents (id, provider, contract_id, amount, currency, status, payment_link) VALUES (?, ?, ?, ?, ?, ?, ?)",
+    intent.ID, intent.Provider, intent.ContractID, intent.Amount, intent.Amount.Currency.Code, intent.Status, intent.PaymentLink)
+  if err != nil {
+    return fmt.Errorf("failed to insert payment intent: %v", err)
+  }
+  return nil
+}
+
+// Updates a payment intent's status in the database
+func UpdatePaymentIntentStatus(id, status string) error {
+  _, err := DB.Exec("UPDATE payment_intents SET status = ? WHERE id = ?", status, id)
+  if err != nil {
+    return fmt.Errorf("failed to update payment intent status: %v", err)
+  }
+  return nil
+}
+
+// Records what a payment intent has received so far along with the ledger entry moving the funds into escrow, in one
+// transaction. Reports false, changing nothing, if the entry was already posted
+func CreditPaymentIntent(id, status string, received money.Amount, entry *LedgerEntry) (bool, error) {
+  tx, err := DB.Begin()
+  if err != nil {
+    return false, fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  posted, err := insertLedgerEntry(tx, entry)
+  if err != nil || !posted {
+    return false, err
+  }
+  if _, err := tx.Exec("UPDATE payment_intents SET status = ?, received = ? WHERE id = ?", status, received, id); err != nil {
+    return false, fmt.Errorf("failed to update payment intent: %v", err)
+  }
+  if err := tx.Commit(); err != nil {
+    return false, fmt.Errorf("failed to commit payment: %v", err)
+  }
+  return true, nil
+}
+
+// Retrieves a payment intent from the database by ID
+func GetPaymentIntentByID(id string) (*PaymentIntent, error) {
+  intent := &PaymentIntent{}
+  var units, received, currencyCode string
+  err := DB.QueryRow("SELECT id, provider, contract_id, amount, COALESCE(received, '0'), currency, status, payment_link FROM payment_intents WHERE id = ?", id).
+    Scan(&intent.ID, &intent.Provider, &intent.ContractID, &units, &received, &currencyCode, &intent.Status, &intent.PaymentLink)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get payment intent: %v", err)
+  }
+
+  currency, err := money.LookupCurrency(currencyCode)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get payment intent: %v", err)
+  }
+  if intent.Amount, err = money.FromUnits(units, currency); err != nil {
+    return nil, fmt.Errorf("failed to get payment intent: %v", err)
+  }
+  if intent.Received, err = money.FromUnits(received, currency); err != nil {
+    return nil, fmt.Errorf("failed to get payment intent: %v", err)
+  }
+  return intent, nil
+}
+
+// Retrieves a provider's payment intents in any of the given statuses
+func ListPaymentIntents(provider string, statuses ...string) ([]*PaymentIntent, error) {
+  rows, err := DB.Query("SELECT id, status FROM payment_intents WHERE provider = ? ORDER BY created_at, rowid", provider)
+  if err != nil {
+    return nil, fmt.Errorf("failed to query payment intents: %v", err)
+  }
+
+  var ids []string
+  for rows.Next() {
+    var id, status string
+    if err := rows.Scan(&id, &status); err != nil {
+      rows.Close()
+      return nil, fmt.Errorf("failed to scan payment intent: %v", err)
+    }
+    for _, s := range statuses {
+      if s == status {
+        ids = append(ids, id)
+        break
+      }
+    }
+  }
+  rows.Close()
+
+  var intents []*PaymentIntent
+  for _, id := range ids {
+    intent, err := GetPaymentIntentByID(id)
+    if err != nil {
+      return nil, err
+    }
+    intents = append(intents, intent)
+  }
+  return intents, nil
+}
+
+// Returns the next block a provider should scan for payments; reports false if it has not started scanning
+func GetPaymentCursor(provider string) (uint64, bool, error) {
+  var next uint64
+  err := DB.QueryRow("SELECT next_block FROM payment_cursors WHERE provider = ?", provider).Scan(&next)
+  if err == sql.ErrNoRows {
+    return 0, false, nil
+  }
+  if err != nil {
+    return 0, false, fmt.Errorf("failed to get payment cursor: %v", err)
+  }
+  return next, true, nil
+}
+
+// Stores the next block a provider should scan for payments
+func SetPaymentCursor(provider string, next uint64) error {
+  _, err := DB.Exec("INSERT INTO payment_cursors (provider, next_block) VALUES (?, ?) ON CONFLICT(provider) DO UPDATE SET next_block = excluded.next_block",
+    provider, next)
+  if err != nil {
+    return fmt.Errorf("failed to set payment cursor: %v", err)
+  }
+  return nil
+}
+
+// Represents a payment provider event as received through a webhook
+type PaymentEvent struct {
+  Provider   string
+  EventID    string
+  Type       string
+  IntentID   string
+  ContractID int
+  Status     string
+  Amount     money.Amount
+  Reference  string // Ledger reference of the funds, shared with other records of the same on-chain transfer
+  Payload    []byte
+  Processed  bool
+  Error      string
+}
+
+// Stores a payment event unless it was already received; reports whether it was new
+func InsertPaymentEvent(event *PaymentEvent) (bool, error) {
+  result, err := DB.Exec(`INSERT OR IGNORE INTO payment_events (provider, event_id, type, intent_id, contract_id, status, amount, currency, reference, payload)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+    event.Provider, event.EventID, event.Type, event.IntentID, event.ContractID, event.Status, event.Amount, event.Amount.Currency.Code,
+    event.Reference, event.Payload)
+  if err != nil {
+    return false, fmt.Errorf("failed to insert payment event: %v", err)
+  }
+
+  inserted, err := result.RowsAffected()
+  if err != nil {
+    return false, fmt.Errorf("failed to insert payment event: %v", err)
+  }
+  return inserted == 1, nil
+}
+
+// Records the outcome of processing a payment event; an empty errMsg marks it processed
+func MarkPaymentEventProcessed(provider, eventID, errMsg string) error {
+  _, err := DB.Exec("UPDATE payment_events SET processed = ?, error = ?, processed_at = CURRENT_TIMESTAMP WHERE provider = ? AND event_id = ?",
+    errMsg == "", errMsg, provider, eventID)
+  if err != nil {
+    return fmt.Errorf("failed to update payment event: %v", err)
+  }
+  return nil
+}
+
+// Reports whether a payment event has been stored
+func PaymentEventReceived(provider, eventID string) (bool, error) {
+  var count int
+  if err := DB.QueryRow("SELECT COUNT(*) FROM payment_events WHERE provider = ? AND event_id = ?", provider, eventID).Scan(&count); err != nil {
+    return false, fmt.Errorf("failed to query payment events: %v", err)
+  }
+  return count > 0, nil
+}
+
+// Retrieves a payment event by provider and event ID
+func GetPaymentEvent(provider, eventID string) (*PaymentEvent, error) {
+  events, err := queryPaymentEvents("WHERE provider = ? AND event_id = ?", provider, eventID)
+  if err != nil {
+    return nil, err
+  }
+  if len(events) == 0 {
+    return nil, fmt.Errorf("failed to get payment event: %s/%s not found", provider, eventID)
+  }
+  return events[0], nil
+}
+
+// Retrieves stored payment events in the order they were received, optionally only unprocessed ones
+func ListPaymentEvents(provider string, unprocessedOnly bool) ([]*PaymentEvent, error) {
+  return queryPaymentEvents("WHERE (? = '' OR provider = ?) AND (? = 0 OR processed = 0) ORDER BY received_at, rowid",
+    provider, provider, unprocessedOnly)
+}
+
+func queryPaymentEvents(where string, args ...interface{}) ([]*PaymentEvent, error) {
+  rows, err := DB.Query(`SELECT provider, event_id, type, intent_id, contract_id, status, amount, currency, COALESCE(reference, ''), payload, processed, COALESCE(error, '')
+    FROM payment_events `+where, args...)
+  if err != nil {
+    return nil, fmt.Errorf("failed to query payment events: %v", err)
+  }
+  defer rows.Close()
+
+  var events []*PaymentEvent
+  for rows.Next() {
+    event := &PaymentEvent{}
+    var units, currencyCode string
+    err := rows.Scan(&event.Provider, &event.EventID, &event.Type, &event.IntentID, &event.ContractID, &event.Status,
+      &units, &currencyCode, &event.Reference, &event.Payload, &event.Processed, &event.Error)
+    if err != nil {
+      return nil, fmt.Errorf("failed to scan payment event: %v", err)
+    }
+
+    // Events without an amount, such as failures, are stored without a currency
+    if currencyCode != "" {
+      currency, err := money.LookupCurrency(currencyCode)
+      if err != nil {
+        return nil, fmt.Errorf("failed to scan payment event: %v", err)
+      }
+      if event.Amount, err = money.FromUnits(units, currency); err != nil {
+        return nil, fmt.Errorf("failed to scan payment event: %v", err)
+      }
+    }
+    events = append(events, event)
+  }
+  return events, rows.Err()
+}
+
+// Retrieves every payment intent created for a contract
+func GetPaymentIntentsByContract(contractID int) ([]*PaymentIntent, error) {
+  rows, err := DB.Query("SELECT id FROM payment_intents WHERE contract_id = ? ORDER BY created_at", contractID)
+  if err != nil {
+    return nil, fmt.Errorf("failed to query payment intents: %v", err)
+  }
+
+  var ids []string
+  for rows.Next() {
+    var id string
+    if err := rows.Scan(&id); err != nil {
+      rows.Close()
+      return nil, fmt.Errorf("failed to scan payment intent: %v", err)
+    }
+    ids = append(ids, id)
+  }
+  rows.Close()
+
+  var intents []*PaymentIntent
+  for _, id := range ids {
+    intent, err := GetPaymentIntentByID(id)
+    if err != nil {
+      return nil, err
+    }
+    intents = append(intents, intent)
+  }
+  return intents, nil
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+  "database/sql"
+  "fmt"
+  "time"
+
+  "smart_contract/pkg/money"
+)
+
+// Represents where a freelancer wants to be paid
+type PayoutAccount struct {
+  ID            int
+  UserID        int
+  Kind          string // "wallet" or "bank"
+  WalletAddress string
+  Provider      string // Payment provider holding the bank details
+  Destination   string // Provider's reference to the bank account
+  IsDefault     bool
+}
+
+// Represents the disbursement of a contract's funds to the freelancer
+type Payout struct {
+  ID              int
+  ContractID      int
+  PayoutAccountID int
+  Amount          money.Amount
+  Status          string
+  Attempts        int
+  LastError       string
+  TxID            string
+  IdempotencyKey  string // Sent with every attempt, so retrying a payout that may have gone out never pays twice
+  NextAttemptAt   time.Time
+}
+
+// Adds a payout account, making it the user's default if requested or if the user has no default yet
+func CreatePayoutAccount(account *PayoutAccount) (int, error) {
+  tx, err := DB.Begin()
+  if err != nil {
+    return 0, fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  if account.IsDefault {
+    if _, err := tx.Exec("UPDATE payout_accounts SET is_default = 0 WHERE user_id = ?", account.UserID); err != nil {
+      return 0, fmt.Errorf("failed to clear default payout account: %v", err)
+    }
+  }
+
+  var id int
+  err = tx.QueryRow(`INSERT INTO payout_accounts (user_id, kind, wallet_address, provider, destination, is_default)
+    VALUES (?, ?, ?, ?, ?, ? OR NOT EXISTS (SELECT 1 FROM payout_accounts WHERE user_id = ? AND is_default = 1)) RETURNING id, is_default`,
+    account.UserID, account.Kind, account.WalletAddress, account.Provider, account.Destination, account.IsDefault, account.UserID).Scan(&id, &account.IsDefault)
+  if err != nil {
+    return 0, fmt.Errorf("failed to insert payout account: %v", err)
+  }
+
+  if err := tx.Commit(); err != nil {
+    return 0, fmt.Errorf("failed to commit payout account: %v", err)
+  }
+  return id, nil
+}
+
+// Retrieves a payout account by ID
+func GetPayoutAccountByID(id int) (*PayoutAccount, error) {
+  account := &PayoutAccount{}
+  err := DB.QueryRow("SELECT id, user_id, kind, COALESCE(wallet_address, ''), COALESCE(provider, ''), COALESCE(destination, ''), is_default FROM payout_accounts WHERE id = ?", id).
+    Scan(&account.ID, &account.UserID, &account.Kind, &account.WalletAddress, &account.Provider, &account.Destination, &account.IsDefault)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get payout account: %v", err)
+  }
+  return account, nil
+}
+
+// Retrieves a user's default payout account
+func GetDefaultPayoutAccount(userID int) (*PayoutAccount, error) {
+  var id int
+  err := DB.QueryRow("SELECT id FROM payout_accounts WHERE user_id = ? AND is_default = 1", userID).Scan(&id)
+  if err == sql.ErrNoRows {
+    return nil, fmt.Errorf("user %d has no default payout account", userID)
+  }
+  if err != nil {
+    return nil, fmt.Errorf("failed to get default payout account: %v", err)
+  }
+  return GetPayoutAccountByID(id)
+}
+
+// Adds a payout for a contract unless one already exists
+func CreatePayout(payout *Payout) error {
+  _, err := DB.Exec("INSERT OR IGNORE INTO payouts (contract_id, payout_account_id, amount, currency, status) VALUES (?, ?, ?, ?, ?)",
+    payout.ContractID, payout.PayoutAccountID, payout.Amount, payout.Amount.Currency.Code, payout.Status)
+  if err != nil {
+    return fmt.Errorf("failed to insert payout: %v", err)
+  }
+  return nil
+}
+
+// Records the outcome of a payout attempt
+func UpdatePayout(payout *Payout) error {
+  _, err := DB.Exec("UPDATE payouts SET status = ?, attempts = ?, last_error = ?, tx_id = ?, idempotency_key = ?, next_attempt_at = ? WHERE id = ?",
+    payout.Status, payout.Attempts, payout.LastError, payout.TxID, payout.IdempotencyKey, payout.NextAttemptAt, payout.ID)
+  if err != nil {
+    return fmt.Errorf("failed to update payout: %v", err)
+  }
+  return nil
+}
+
+// Retrieves the payout for a contract
+func GetPayoutByContract(contractID int) (*Payout, error) {
+  payout := &Payout{}
+  var units, currencyCode string
+  err := DB.QueryRow(`SELECT id, contract_id, payout_account_id, amount, currency, status, attempts, COALESCE(last_error, ''), COALESCE(tx_id, ''), COALESCE(idempotency_key, ''),
+    next_attempt_at FROM payouts WHERE contract_id = ?`, contractID).
+    Scan(&payout.ID, &payout.ContractID, &payout.PayoutAccountID, &units, &currencyCode, &payout.Status, &payout.Attempts,
+      &payout.LastError, &payout.TxID, &payout.IdempotencyKey, &payout.NextAttemptAt)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get payout: %v", err)
+  }
+
+  currency, err := money.LookupCurrency(currencyCode)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get payout: %v", err)
+  }
+  if payout.Amount, err = money.FromUnits(units, currency); err != nil {
+    return nil, fmt.Errorf("failed to get payout: %v", err)
+  }
+  return payout, nil
+}
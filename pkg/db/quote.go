@@ -0,0 +1,85 @@
+package db
+
+import (
+  "fmt"
+  "time"
+
+  "smart_contract/pkg/fees"
+  "smart_contract/pkg/money"
+)
+
+// Represents an exchange rate locked for a contract, converting its quoted price into the currency it is paid in
+type Quote struct {
+  ID         int
+  ContractID int
+  Quoted     money.Amount // Price as agreed, e.g. in USD
+  Amount     money.Amount // Price to be paid, e.g. in MATIC
+  Rate       string       // Units of Amount's currency per unit of Quoted's currency
+  Source     string
+  LockedAt   time.Time
+  ExpiresAt  time.Time
+}
+
+// Adds a quote and re-prices the contract's fees with it, but only if the contract is still undeployed in status
+// and previousID is still its latest quote, or 0 if it had none, so two locks of the same quote can't both win
+func LockQuote(quote *Quote, breakdown fees.Breakdown, status string, previousID int) (int, error) {
+  tx, err := DB.Begin()
+  if err != nil {
+    return 0, fmt.Errorf("failed to begin transaction: %v", err)
+  }
+  defer tx.Rollback()
+
+  result, err := tx.Exec(`UPDATE contracts SET currency = ?, fee_version = ?, gross_amount = ?, platform_fee = ?, network_fee = ?, net_amount = ?
+    WHERE id = ? AND status = ? AND COALESCE(address, '') = '' AND COALESCE((SELECT MAX(id) FROM quotes WHERE contract_id = ?), 0) = ?`,
+    breakdown.Gross.Currency.Code, breakdown.ScheduleVersion, breakdown.Gross, breakdown.PlatformFee, breakdown.NetworkFee, breakdown.Net,
+    quote.ContractID, status, quote.ContractID, previousID)
+  if err != nil {
+    return 0, fmt.Errorf("failed to save contract fees: %v", err)
+  }
+  if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+    return 0, fmt.Errorf("contract %d changed while its quote was being locked", quote.ContractID)
+  }
+
+  var id int
+  err = tx.QueryRow(`INSERT INTO quotes (contract_id, quoted_amount, quoted_currency, amount, currency, rate, source, locked_at, expires_at)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+    quote.ContractID, quote.Quoted, quote.Quoted.Currency.Code, quote.Amount, quote.Amount.Currency.Code,
+    quote.Rate, quote.Source, quote.LockedAt, quote.ExpiresAt).Scan(&id)
+  if err != nil {
+    return 0, fmt.Errorf("failed to insert quote: %v", err)
+  }
+
+  if err := tx.Commit(); err != nil {
+    return 0, fmt.Errorf("failed to commit quote: %v", err)
+  }
+  return id, nil
+}
+
+// Retrieves the most recently locked quote for a contract
+func GetLatestQuote(contractID int) (*Quote, error) {
+  quote := &Quote{}
+  var quotedUnits, quotedCurrency, units, currency string
+  err := DB.QueryRow(`SELECT id, contract_id, quoted_amount, quoted_currency, amount, currency, rate, source, locked_at, expires_at
+    FROM quotes WHERE contract_id = ? ORDER BY id DESC LIMIT 1`, contractID).
+    Scan(&quote.ID, &quote.ContractID, &quotedUnits, &quotedCurrency, &units, &currency, &quote.Rate, &quote.Source, &quote.LockedAt, &quote.ExpiresAt)
+  if err != nil {
+    return nil, fmt.Errorf("failed to get quote: %v", err)
+  }
+
+  if quote.Quoted, err = scanAmount(quotedUnits, quotedCurrency); err != nil {
+    return nil, fmt.Errorf("failed to get quote: %v", err)
+  }
+  if quote.Amount, err = scanAmount(units, currency); err != nil {
+    return nil, fmt.Errorf("failed to get quote: %v", err)
+  }
+  return quote, nil
+}
+
+// Rebuilds an amount from its stored base units and currency code
+func scanAmount(units, currencyCode string) (money.Amount, error) {
+  currency, err := money.LookupCurrency(currencyCode)
+  if err != nil {
+    return money.Amount{}, err
+  }
+  return money.FromUnits(units, currency)
+}
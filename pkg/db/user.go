@@ -12,12 +12,14 @@ type User struct {
   LastName  string
   Email     string
   Password  string
+  Plan      string
+  Locale    string // Language the user sees the app and emails in, e.g. "en"
 }
 
 // Adds a new user to the database
 func CreateUser(user User) error {
-  _, err := DB.Exec("INSERT INTO users (first_name, last_name, email, password) VALUES (?, ?, ?, ?)",
-    user.FirstName, user.LastName, user.Email, user.Password)
+  _, err := DB.Exec("INSERT INTO users (first_name, last_name, email, password, plan, locale) VALUES (?, ?, ?, ?, ?, ?)",
+    user.FirstName, user.LastName, user.Email, user.Password, user.Plan, localeOrDefault(user.Locale))
   if err != nil {
     return fmt.Errorf("failed to create user: %v", err)
   }
@@ -28,8 +30,19 @@ func CreateUser(user User) error {
 // Retrieves a user by email
 func GetUserByEmail(email string) (User, error) {
   var user User
-  err := DB.QueryRow("SELECT id, first_name, last_name, email, password FROM users WHERE email = ?", email).
-    Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password)
+  err := DB.QueryRow("SELECT id, first_name, last_name, email, password, COALESCE(plan, ''), COALESCE(locale, 'en') FROM users WHERE email = ?", email).
+    Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.Plan, &user.Locale)
+  if err != nil {
+    return User{}, fmt.Errorf("failed to get user: %v", err)
+  }
+  return user, nil
+}
+
+// Retrieves a user by ID
+func GetUserByID(id int) (User, error) {
+  var user User
+  err := DB.QueryRow("SELECT id, first_name, last_name, email, password, COALESCE(plan, ''), COALESCE(locale, 'en') FROM users WHERE id = ?", id).
+    Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.Plan, &user.Locale)
   if err != nil {
     return User{}, fmt.Errorf("failed to get user: %v", err)
   }
@@ -38,8 +51,8 @@ func GetUserByEmail(email string) (User, error) {
 
 // Updates a user's details
 func UpdateUser(user User) error {
-  _, err := DB.Exec("UPDATE users SET first_name = ?, last_name = ?, email = ?, password = ? WHERE id = ?",
-    user.FirstName, user.LastName, user.Email, user.Password, user.ID)
+  _, err := DB.Exec("UPDATE users SET first_name = ?, last_name = ?, email = ?, password = ?, plan = ?, locale = ? WHERE id = ?",
+    user.FirstName, user.LastName, user.Email, user.Password, user.Plan, localeOrDefault(user.Locale), user.ID)
   if err != nil {
     return fmt.Errorf("failed to update user: %v", err)
   }
@@ -56,3 +69,10 @@ func DeleteUser(userID int) error {
   log.Printf("User with ID %d deleted", userID)
   return nil
 }
+
+func localeOrDefault(locale string) string {
+  if locale == "" {
+    return "en"
+  }
+  return locale
+}
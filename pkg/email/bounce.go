@@ -0,0 +1,134 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// Kinds of delivery problem reported for a recipient
+type BounceKind string
+
+const (
+	HardBounce BounceKind = "hard"      // The address doesn't exist or permanently refuses mail
+	SoftBounce BounceKind = "soft"      // A temporary failure such as a full mailbox
+	Complaint  BounceKind = "complaint" // The recipient marked a message as spam
+)
+
+// A delivery problem with one recipient, from a bounce, a spam complaint or the provider's webhook
+type Bounce struct {
+	Recipient string     `json:"recipient"`
+	Kind      BounceKind `json:"kind"`
+	Reason    string     `json:"reason"`
+}
+
+// Parses a delivery status notification (RFC 3464) or spam complaint (RFC 5965) into the bounces it reports.
+// Reports false if the message is neither.
+func ParseReport(raw []byte) ([]Bounce, bool) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/report" {
+		return nil, false
+	}
+
+	var bounces []Bounce
+	var complaint bool
+	var complainant string
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch partType {
+		case "message/delivery-status", "message/global-delivery-status":
+			bounces = append(bounces, deliveryStatus(part)...)
+		case "message/feedback-report":
+			complaint = true
+			fields := readFieldBlocks(part)
+			if len(fields) > 0 {
+				complainant = address(fields[0].Get("Original-Rcpt-To"))
+			}
+		case "message/rfc822", "text/rfc822-headers":
+			// Complaints without Original-Rcpt-To identify the recipient by the original message
+			if complainant == "" {
+				if original, err := mail.ReadMessage(part); err == nil {
+					complainant = address(original.Header.Get("To"))
+				}
+			}
+		}
+	}
+
+	if complaint && complainant != "" {
+		bounces = append(bounces, Bounce{Recipient: complainant, Kind: Complaint, Reason: "marked as spam"})
+	}
+	return bounces, params["report-type"] == "delivery-status" || params["report-type"] == "feedback-report" || len(bounces) > 0
+}
+
+// Reads the per-recipient fields of a delivery status, skipping recipients that were delivered
+func deliveryStatus(r io.Reader) []Bounce {
+	blocks := readFieldBlocks(r)
+	if len(blocks) < 2 {
+		return nil
+	}
+
+	var bounces []Bounce
+	// The first block describes the message, the rest one recipient each
+	for _, fields := range blocks[1:] {
+		recipient := address(fields.Get("Final-Recipient"))
+		if recipient == "" {
+			recipient = address(fields.Get("Original-Recipient"))
+		}
+		action := strings.ToLower(fields.Get("Action"))
+		if recipient == "" || (action != "failed" && action != "delayed") {
+			continue
+		}
+
+		kind := SoftBounce
+		if action == "failed" && strings.HasPrefix(fields.Get("Status"), "5") {
+			kind = HardBounce
+		}
+		reason := fields.Get("Diagnostic-Code")
+		if reason == "" {
+			reason = "status " + fields.Get("Status")
+		}
+		bounces = append(bounces, Bounce{Recipient: recipient, Kind: kind, Reason: reason})
+	}
+	return bounces
+}
+
+// Reads groups of header fields separated by blank lines
+func readFieldBlocks(r io.Reader) []textproto.MIMEHeader {
+	reader := textproto.NewReader(bufio.NewReader(r))
+	var blocks []textproto.MIMEHeader
+	for {
+		fields, err := reader.ReadMIMEHeader()
+		if len(fields) > 0 {
+			blocks = append(blocks, fields)
+		}
+		if err != nil {
+			return blocks
+		}
+	}
+}
+
+// Extracts the address from a field such as "rfc822; jane@example.com"
+func address(field string) string {
+	if _, value, ok := strings.Cut(field, ";"); ok {
+		field = value
+	}
+	field = strings.TrimSpace(field)
+	if parsed, err := mail.ParseAddress(field); err == nil {
+		return parsed.Address
+	}
+	return strings.Trim(field, "<>")
+}
@@ -0,0 +1,189 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers covered by DKIM signatures when present; both List-Unsubscribe headers must be signed for one-click unsubscribe
+var dkimHeaders = []string{"From", "To", "Reply-To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "List-Unsubscribe", "List-Unsubscribe-Post"}
+
+// Signs outgoing messages with DKIM (RFC 6376) using relaxed canonicalization.
+// The public key must be published at <Selector>._domainkey.<Domain>.
+type DKIMSigner struct {
+	Domain   string
+	Selector string
+	Key      crypto.Signer // An *rsa.PrivateKey or ed25519.PrivateKey
+}
+
+// Loads a PEM encoded RSA or Ed25519 private key for DKIM signing
+func LoadDKIMKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DKIM key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("DKIM key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DKIM key: %v", err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported DKIM key type %T", key)
+}
+
+// Returns the message with a DKIM-Signature header prepended
+func (d *DKIMSigner) Sign(message []byte) ([]byte, error) {
+	var algorithm string
+	switch d.Key.(type) {
+	case *rsa.PrivateKey:
+		algorithm = "rsa-sha256"
+	case ed25519.PrivateKey:
+		algorithm = "ed25519-sha256"
+	default:
+		return nil, fmt.Errorf("unsupported DKIM key type %T", d.Key)
+	}
+
+	end := bytes.Index(message, []byte("\r\n\r\n"))
+	if end < 0 {
+		return nil, fmt.Errorf("message has no header section")
+	}
+	headers := parseHeaderFields(string(message[:end+2]))
+	bodyHash := sha256.Sum256(relaxedBody(message[end+4:]))
+
+	var signed []string
+	var hash bytes.Buffer
+	for _, name := range dkimHeaders {
+		if field, ok := headers[strings.ToLower(name)]; ok {
+			signed = append(signed, strings.ToLower(name))
+			hash.WriteString(relaxedHeader(field))
+			hash.WriteString("\r\n")
+		}
+	}
+
+	signature := fmt.Sprintf("v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%s; h=%s; bh=%s; b=",
+		algorithm, d.Domain, d.Selector, strconv.FormatInt(time.Now().Unix(), 10),
+		strings.Join(signed, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]))
+	// The signature header is hashed last, with an empty b= tag and no trailing CRLF
+	hash.WriteString(relaxedHeader("DKIM-Signature: " + signature))
+	digest := sha256.Sum256(hash.Bytes())
+
+	var b []byte
+	var err error
+	if algorithm == "rsa-sha256" {
+		b, err = d.Key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	} else {
+		// Ed25519 signs the SHA-256 digest itself (RFC 8463)
+		b, err = d.Key.Sign(rand.Reader, digest[:], crypto.Hash(0))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %v", err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("DKIM-Signature: " + foldSignature(signature+base64.StdEncoding.EncodeToString(b)) + "\r\n")
+	out.Write(message)
+	return out.Bytes(), nil
+}
+
+// Splits a header section into its fields, keyed by lowercase name; a repeated field keeps its last instance
+func parseHeaderFields(section string) map[string]string {
+	fields := map[string]string{}
+	var current string
+	flush := func() {
+		if name, _, ok := strings.Cut(current, ":"); ok {
+			fields[strings.ToLower(strings.TrimSpace(name))] = current
+		}
+	}
+	for _, line := range strings.SplitAfter(section, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			current += line
+			continue
+		}
+		flush()
+		current = line
+	}
+	flush()
+	return fields
+}
+
+var whitespace = regexp.MustCompile(`[ \t]+`)
+
+// Relaxed header canonicalization: lowercase name, unfolded value with whitespace runs collapsed
+func relaxedHeader(field string) string {
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.NewReplacer("\r\n", "", "\n", "").Replace(value)
+	value = strings.TrimSpace(whitespace.ReplaceAllString(value, " "))
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value
+}
+
+// Relaxed body canonicalization: whitespace runs collapsed, trailing whitespace and empty lines removed
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(whitespace.ReplaceAllString(line, " "), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// Folds the signature header at tag boundaries so lines stay near 78 characters. Only the signature itself
+// is split mid-value: verifiers remove it before hashing the header, so the whitespace added can't break the hash.
+func foldSignature(value string) string {
+	var folded strings.Builder
+	width := len("DKIM-Signature: ")
+	for i, tag := range strings.Split(value, "; ") {
+		if i > 0 {
+			folded.WriteString(";")
+			if width+len(tag) >= 77 {
+				folded.WriteString("\r\n\t")
+				width = 1
+			} else {
+				folded.WriteString(" ")
+				width += 2
+			}
+		}
+		if strings.HasPrefix(tag, "b=") {
+			for width+len(tag) > 78 {
+				n := 78 - width
+				folded.WriteString(tag[:n] + "\r\n\t")
+				tag, width = tag[n:], 1
+			}
+		}
+		folded.WriteString(tag)
+		width += len(tag)
+	}
+	return folded.String()
+}
@@ -0,0 +1,114 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-msgauth/dkim"
+)
+
+// Checks our signatures against an independent DKIM implementation, for each key type we support
+func TestDKIMSignaturesVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    crypto.Signer
+		record string // The DNS TXT record publishing the public key
+	}{
+		{"rsa", rsaKey, "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(rsaPublic)},
+		{"ed25519", edKey, "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(edPublic)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signer := &DKIMSigner{Domain: "tronch.io", Selector: "mail", Key: test.key}
+			lookup := func(domain string) ([]string, error) {
+				if domain != "mail._domainkey.tronch.io" {
+					return nil, fmt.Errorf("no TXT record for %s", domain)
+				}
+				return []string{test.record}, nil
+			}
+
+			msg := &Message{
+				From:        "tronch <no-reply@tronch.io>",
+				To:          []string{"Cleo <cleo@example.com>"},
+				ReplyTo:     "reply+42.client.1a2b3c4d@reply.tronch.io",
+				Subject:     "Your   invoice for contract #42 — logo design",
+				Unsubscribe: "https://tronch.io/unsubscribe?token=" + strings.Repeat("a1b2c3d4", 12),
+				Text:        "Hi Cleo,\n\nYour invoice is attached.  \n\n\n",
+				HTML:        "<p>Hi Cleo,</p>\n<p>Your invoice is attached.</p>",
+				Attachments: []Attachment{{Filename: "INV-2024-000042.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte("%PDF-1.4\n"), 100)}},
+			}
+			data, err := msg.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			signed, err := signer.Sign(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verifications, err := dkim.VerifyWithOptions(bytes.NewReader(signed), &dkim.VerifyOptions{LookupTXT: lookup})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(verifications) != 1 {
+				t.Fatalf("found %d signatures, want 1", len(verifications))
+			}
+			if v := verifications[0]; v.Err != nil || v.Domain != "tronch.io" {
+				t.Fatalf("signature for %s failed to verify: %v", v.Domain, v.Err)
+			}
+			for _, header := range []string{"from", "subject", "list-unsubscribe", "list-unsubscribe-post"} {
+				if !containsFold(verifications[0].HeaderKeys, header) {
+					t.Errorf("%s is not signed; signed %v", header, verifications[0].HeaderKeys)
+				}
+			}
+
+			// Changing a signed header or the body breaks the signature
+			tampered := map[string][]byte{
+				"subject": bytes.Replace(signed, []byte("Subject: "), []byte("Subject: Re: "), 1),
+				"body":    bytes.Replace(signed, []byte("attached"), []byte("overdue!"), 1),
+			}
+			for part, message := range tampered {
+				if bytes.Equal(message, signed) {
+					t.Fatalf("failed to tamper with the %s", part)
+				}
+				verifications, err := dkim.VerifyWithOptions(bytes.NewReader(message), &dkim.VerifyOptions{LookupTXT: lookup})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(verifications) != 1 || verifications[0].Err == nil {
+					t.Errorf("verified a message with a changed %s", part)
+				}
+			}
+		})
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// An email ready to be sent, with a plain text body and an optional HTML alternative
+type Message struct {
+	From        string // Defaults to the sender's configured address when empty
+	To          []string
+	ReplyTo     string
+	Subject     string
+	Unsubscribe string // One-click unsubscribe URL for notices the recipient can opt out of
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Builds the MIME encoded message: multipart/alternative text and HTML bodies, wrapped in multipart/mixed when there are attachments
+func (m *Message) Bytes() ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %v", m.From, err)
+	}
+	if len(m.To) == 0 {
+		return nil, fmt.Errorf("message has no recipients")
+	}
+
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", from.String())
+	header.Set("To", strings.Join(m.To, ", "))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(from.Address))
+	header.Set("MIME-Version", "1.0")
+	if m.ReplyTo != "" {
+		header.Set("Reply-To", m.ReplyTo)
+	}
+	if m.Unsubscribe != "" {
+		// RFC 8058: mail clients POST List-Unsubscribe=One-Click to the URL
+		header.Set("List-Unsubscribe", "<"+m.Unsubscribe+">")
+		header.Set("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+
+	body, contentType, err := m.body()
+	if err != nil {
+		return nil, err
+	}
+	header.Set("Content-Type", contentType)
+
+	for _, key := range []string{"From", "To", "Reply-To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "List-Unsubscribe", "List-Unsubscribe-Post"} {
+		if value := header.Get(key); value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// Returns the message body and its content type
+func (m *Message) body() ([]byte, string, error) {
+	var alternative bytes.Buffer
+	parts := multipart.NewWriter(&alternative)
+	if err := writeTextPart(parts, "text/plain", m.Text); err != nil {
+		return nil, "", err
+	}
+	if m.HTML != "" {
+		if err := writeTextPart(parts, "text/html", m.HTML); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, "", err
+	}
+	alternativeType := "multipart/alternative; boundary=" + parts.Boundary()
+
+	if len(m.Attachments) == 0 {
+		return alternative.Bytes(), alternativeType, nil
+	}
+
+	var mixed bytes.Buffer
+	outer := multipart.NewWriter(&mixed)
+	part, err := outer.CreatePart(textproto.MIMEHeader{"Content-Type": {alternativeType}})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(alternative.Bytes()); err != nil {
+		return nil, "", err
+	}
+	for _, attachment := range m.Attachments {
+		if err := writeAttachment(outer, attachment); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := outer.Close(); err != nil {
+		return nil, "", err
+	}
+	return mixed.Bytes(), "multipart/mixed; boundary=" + outer.Boundary(), nil
+}
+
+func writeTextPart(parts *multipart.Writer, contentType, content string) error {
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s part: %v", contentType, err)
+	}
+	encoder := quotedprintable.NewWriter(part)
+	if _, err := encoder.Write([]byte(content)); err != nil {
+		return fmt.Errorf("failed to encode %s part: %v", contentType, err)
+	}
+	return encoder.Close()
+}
+
+func writeAttachment(parts *multipart.Writer, attachment Attachment) error {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": attachment.Filename})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create attachment %s: %v", attachment.Filename, err)
+	}
+
+	// Base64 lines must not exceed 76 characters
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
+
+func messageID(from string) string {
+	domain := "tronch.io"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	random := make([]byte, 12)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
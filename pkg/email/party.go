@@ -0,0 +1,51 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Signs and verifies the links that let one party act on a contract without an account,
+// e.g. https://tronch.io/contracts/42?party=42.client.1a2b3c4d5e6f7a8b9c0d1e2f
+type PartyLinks struct {
+	BaseURL string
+	Secret  []byte
+}
+
+// Returns the token identifying a party of a contract
+func (p *PartyLinks) Token(contractID int, party string) string {
+	token := fmt.Sprintf("%d.%s", contractID, party)
+	return token + "." + p.sign(token)
+}
+
+// Returns the dashboard link through which a party acts on a contract
+func (p *PartyLinks) Dashboard(contractID int, party string) string {
+	return fmt.Sprintf("%s/contracts/%d?%s", p.BaseURL, contractID, url.Values{"party": {p.Token(contractID, party)}}.Encode())
+}
+
+// Extracts the contract and party from a token, reporting false if it wasn't issued by us or was tampered with
+func (p *PartyLinks) Parse(token string) (int, string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[1] == "" {
+		return 0, "", false
+	}
+	contractID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", false
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(p.sign(parts[0]+"."+parts[1]))) {
+		return 0, "", false
+	}
+	return contractID, parts[1], true
+}
+
+func (p *PartyLinks) sign(token string) string {
+	mac := hmac.New(sha256.New, p.Secret)
+	mac.Write([]byte("party\n" + token))
+	return hex.EncodeToString(mac.Sum(nil))[:24]
+}
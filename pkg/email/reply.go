@@ -0,0 +1,59 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+// Signs and verifies the reply-to addresses that route replies back to a contract,
+// e.g. reply+42.client.1a2b3c4d5e6f@reply.tronch.io
+type ReplyAddresses struct {
+	Domain string
+	Secret []byte
+}
+
+// Returns the address a party should reply to about a contract
+func (r *ReplyAddresses) For(contractID int, party string) string {
+	token := fmt.Sprintf("%d.%s", contractID, party)
+	return fmt.Sprintf("reply+%s.%s@%s", token, r.sign(token), r.Domain)
+}
+
+// Extracts the contract and party from a reply-to address, reporting false if it isn't one of ours or was tampered with
+func (r *ReplyAddresses) Parse(address string) (int, string, bool) {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		address = parsed.Address
+	}
+
+	local, domain, ok := strings.Cut(strings.ToLower(address), "@")
+	if !ok || domain != strings.ToLower(r.Domain) {
+		return 0, "", false
+	}
+	token, ok := strings.CutPrefix(local, "reply+")
+	if !ok {
+		return 0, "", false
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, "", false
+	}
+	contractID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", false
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(r.sign(parts[0]+"."+parts[1]))) {
+		return 0, "", false
+	}
+	return contractID, parts[1], true
+}
+
+func (r *ReplyAddresses) sign(token string) string {
+	mac := hmac.New(sha256.New, r.Secret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))[:12]
+}
@@ -0,0 +1,254 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Delivers email messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Returned when retrying a message cannot succeed, such as when it is malformed or the server rejects a recipient
+type PermanentError struct {
+	Err       error
+	Recipient string // Set when the server rejected this recipient, a bounce at send time
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Reports whether err means the message should not be retried
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// Default From address when none is configured
+const DefaultFrom = "tronch <no-reply@tronch.io>"
+
+// Returns an SMTP sender when SMTP_HOST is set, otherwise a sink writing messages to MAIL_DIR for local development
+func NewSenderFromEnv() (Sender, error) {
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = DefaultFrom
+	}
+	dkim, err := dkimFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		log.Printf("SMTP_HOST not set, writing emails to %s", dir)
+		return &FileSender{Dir: dir, From: from, DKIM: dkim}, nil
+	}
+
+	port := 587
+	if value := os.Getenv("SMTP_PORT"); value != "" {
+		p, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT %q: %v", value, err)
+		}
+		port = p
+	}
+
+	return &SMTPSender{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+		// Local servers such as mailpit don't offer STARTTLS
+		RequireTLS: os.Getenv("SMTP_STARTTLS") != "false",
+		DKIM:       dkim,
+	}, nil
+}
+
+// Returns a DKIM signer when DKIM_DOMAIN, DKIM_SELECTOR and DKIM_KEY_FILE are set, otherwise nil
+func dkimFromEnv() (*DKIMSigner, error) {
+	domain, selector, keyFile := os.Getenv("DKIM_DOMAIN"), os.Getenv("DKIM_SELECTOR"), os.Getenv("DKIM_KEY_FILE")
+	if domain == "" && selector == "" && keyFile == "" {
+		return nil, nil
+	}
+	if domain == "" || selector == "" || keyFile == "" {
+		return nil, fmt.Errorf("DKIM_DOMAIN, DKIM_SELECTOR and DKIM_KEY_FILE must be set together")
+	}
+	key, err := LoadDKIMKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMSigner{Domain: domain, Selector: selector, Key: key}, nil
+}
+
+// Sends email through an SMTP server, upgrading the connection with STARTTLS when offered
+type SMTPSender struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	From       string
+	RequireTLS bool        // Fail rather than send in plain text when the server doesn't offer STARTTLS
+	DKIM       *DKIMSigner // Signs messages when set
+}
+
+// Sends a message through the SMTP server
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	data, from, recipients, err := prepare(msg, s.From, s.DKIM)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, strconv.Itoa(s.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %v", err)
+		}
+	} else if s.RequireTLS {
+		return fmt.Errorf("SMTP server %s does not support STARTTLS", s.Host)
+	}
+
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate with SMTP server: %v", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return smtpFailure("set sender", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			failure := smtpFailure("add recipient "+recipient, err)
+			if permanent, ok := failure.(*PermanentError); ok {
+				permanent.Recipient = recipient
+			}
+			return failure
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message data: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpFailure("send message", err)
+	}
+	return client.Quit()
+}
+
+// Writes each message to an .eml file in Dir, which any mail client can open
+type FileSender struct {
+	Dir  string
+	From string
+	DKIM *DKIMSigner // Signs messages when set, to check signatures before going live
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+// Writes the message to the sink directory
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	data, _, recipients, err := prepare(msg, s.From, s.DKIM)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %v", err)
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFilename.ReplaceAllString(recipients[0], "_"))
+	path := filepath.Join(s.Dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write email: %v", err)
+	}
+
+	log.Printf("Email to %v saved to %s", recipients, path)
+	return nil
+}
+
+// Only logs messages, used when no sender has been configured
+type LogSender struct{}
+
+// Logs the message's recipients and subject
+func (LogSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("Email to %v: %s", msg.To, msg.Subject)
+	return nil
+}
+
+// Encodes the message, defaulting its From address and signing it when dkim is set, and returns the envelope sender and recipients
+func prepare(msg *Message, defaultFrom string, dkim *DKIMSigner) ([]byte, string, []string, error) {
+	if msg.From == "" {
+		msg.From = defaultFrom
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return nil, "", nil, &PermanentError{Err: err}
+	}
+	if dkim != nil {
+		if data, err = dkim.Sign(data); err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return nil, "", nil, &PermanentError{Err: fmt.Errorf("invalid from address %q: %v", msg.From, err)}
+	}
+	var recipients []string
+	for _, to := range msg.To {
+		address, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, "", nil, &PermanentError{Err: fmt.Errorf("invalid recipient %q: %v", to, err)}
+		}
+		recipients = append(recipients, address.Address)
+	}
+	return data, from.Address, recipients, nil
+}
+
+// Wraps an SMTP error, treating 5xx replies as permanent
+func smtpFailure(action string, err error) error {
+	failure := fmt.Errorf("failed to %s: %v", action, err)
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return &PermanentError{Err: failure}
+	}
+	return failure
+}
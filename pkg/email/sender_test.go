@@ -0,0 +1,272 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Parses an encoded message, returning its headers and the decoded content of each leaf part by content type
+func parseMessage(t *testing.T, data []byte) (mail.Header, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	var walk func(contentType string, header textproto.MIMEHeader, body io.Reader)
+	walk = func(contentType string, header textproto.MIMEHeader, body io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(mediaType, "multipart/") {
+			reader := multipart.NewReader(body, params["boundary"])
+			for {
+				part, err := reader.NextRawPart()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				walk(part.Header.Get("Content-Type"), part.Header, part)
+			}
+		}
+		if header.Get("Content-Transfer-Encoding") == "quoted-printable" {
+			body = quotedprintable.NewReader(body)
+		}
+		content, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[mediaType] = string(content)
+	}
+	walk(msg.Header.Get("Content-Type"), nil, msg.Body)
+	return msg.Header, parts
+}
+
+func TestMessageBytes(t *testing.T) {
+	msg := &Message{
+		From:        "tronch <no-reply@tronch.io>",
+		To:          []string{"Cleo <cleo@example.com>"},
+		ReplyTo:     "reply+7@tronch.io",
+		Subject:     "Contrat n° 7 confirmé",
+		Unsubscribe: "https://tronch.io/unsubscribe?token=abc",
+		Text:        "Your contract is confirmed.",
+		HTML:        "<p>Your contract is confirmed.</p>",
+		Attachments: []Attachment{{Filename: "invoice-7.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte("%PDF"), 40)}},
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, parts := parseMessage(t, data)
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+	if header.Get("Reply-To") != msg.ReplyTo || header.Get("List-Unsubscribe") != "<"+msg.Unsubscribe+">" || header.Get("List-Unsubscribe-Post") != "List-Unsubscribe=One-Click" {
+		t.Errorf("headers = %v", header)
+	}
+	if !strings.HasSuffix(header.Get("Message-ID"), "@tronch.io>") {
+		t.Errorf("message ID %q isn't from the sender's domain", header.Get("Message-ID"))
+	}
+	if parts["text/plain"] != msg.Text || parts["text/html"] != msg.HTML {
+		t.Errorf("bodies = %q", parts)
+	}
+	if _, ok := parts["application/pdf"]; !ok {
+		t.Errorf("attachment missing from %v", parts)
+	}
+	for _, line := range strings.Split(string(data), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("line of %d characters exceeds the SMTP limit", len(line))
+		}
+	}
+
+	for name, invalid := range map[string]*Message{
+		"from address":  {From: "not an address", To: []string{"cleo@example.com"}},
+		"no recipients": {From: "no-reply@tronch.io"},
+	} {
+		if _, err := invalid.Bytes(); err == nil {
+			t.Errorf("encoded a message with an invalid %s", name)
+		}
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender := &FileSender{Dir: dir, From: DefaultFrom}
+
+	if err := sender.Send(context.Background(), &Message{To: []string{"Cleo <cleo@example.com>"}, Subject: "Hello", Text: "Hi Cleo"}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*-cleo@example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("wrote %v (%v), want one email for cleo@example.com", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, parts := parseMessage(t, data)
+	if from, err := header.AddressList("From"); err != nil || from[0].Address != "no-reply@tronch.io" {
+		t.Errorf("from = %v (%v), want the default sender", from, err)
+	}
+	if parts["text/plain"] != "Hi Cleo" {
+		t.Errorf("bodies = %q", parts)
+	}
+
+	// Messages that can never be sent aren't retried
+	err = sender.Send(context.Background(), &Message{To: []string{"not an address"}, Text: "Hi"})
+	if !IsPermanent(err) {
+		t.Errorf("invalid recipient gave %v, want a permanent error", err)
+	}
+}
+
+func TestNewSenderFromEnv(t *testing.T) {
+	for _, key := range []string{"SMTP_HOST", "SMTP_PORT", "SMTP_FROM", "SMTP_STARTTLS", "MAIL_DIR", "DKIM_DOMAIN", "DKIM_SELECTOR", "DKIM_KEY_FILE"} {
+		t.Setenv(key, "")
+	}
+
+	t.Setenv("MAIL_DIR", "outbox")
+	sender, err := NewSenderFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file, ok := sender.(*FileSender); !ok || file.Dir != "outbox" || file.From != DefaultFrom {
+		t.Errorf("without SMTP_HOST got %#v, want a file sink in outbox", sender)
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_FROM", "billing@tronch.io")
+	sender, err = NewSenderFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if smtp, ok := sender.(*SMTPSender); !ok || smtp.Port != 587 || !smtp.RequireTLS || smtp.From != "billing@tronch.io" {
+		t.Errorf("with SMTP_HOST got %#v, want STARTTLS on port 587", sender)
+	}
+
+	t.Setenv("SMTP_PORT", "submission")
+	if _, err := NewSenderFromEnv(); err == nil {
+		t.Error("accepted an invalid SMTP_PORT")
+	}
+	t.Setenv("SMTP_PORT", "")
+	t.Setenv("DKIM_DOMAIN", "tronch.io")
+	if _, err := NewSenderFromEnv(); err == nil {
+		t.Error("accepted DKIM_DOMAIN without a selector and key")
+	}
+}
+
+// Accepts one SMTP session without STARTTLS, rejecting the given recipients, and returns what was delivered
+func fakeSMTPServer(t *testing.T, rejected ...string) (int, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	delivered := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.Fields(line + " ")[0])
+			switch command {
+			case "EHLO", "HELO":
+				text.PrintfLine("250-fake\r\n250 8BITMIME")
+			case "MAIL":
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				reply := "250 OK"
+				for _, recipient := range rejected {
+					if strings.Contains(line, "<"+recipient+">") {
+						reply = "550 5.1.1 no such user"
+					}
+				}
+				text.PrintfLine("%s", reply)
+			case "DATA":
+				text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				delivered <- string(data)
+				text.PrintfLine("250 queued")
+			case "QUIT":
+				text.PrintfLine("221 bye")
+				return
+			default:
+				text.PrintfLine("502 unsupported")
+			}
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, delivered
+}
+
+func TestSMTPSender(t *testing.T) {
+	port, delivered := fakeSMTPServer(t)
+	sender := &SMTPSender{Host: "127.0.0.1", Port: port, From: DefaultFrom}
+
+	if err := sender.Send(context.Background(), &Message{To: []string{"cleo@example.com"}, Subject: "Hello", Text: "Hi Cleo"}); err != nil {
+		t.Fatal(err)
+	}
+	_, parts := parseMessage(t, []byte(<-delivered))
+	if parts["text/plain"] != "Hi Cleo" {
+		t.Errorf("delivered %q", parts)
+	}
+}
+
+func TestSMTPSenderRejections(t *testing.T) {
+	// A server that rejects the recipient bounces the message at send time
+	port, _ := fakeSMTPServer(t, "gone@example.com")
+	sender := &SMTPSender{Host: "127.0.0.1", Port: port, From: DefaultFrom}
+	err := sender.Send(context.Background(), &Message{To: []string{"gone@example.com"}, Text: "Hi"})
+	var permanent *PermanentError
+	if !errors.As(err, &permanent) || permanent.Recipient != "gone@example.com" {
+		t.Errorf("rejected recipient gave %v, want a permanent error for gone@example.com", err)
+	}
+
+	// Plain text is refused unless explicitly allowed, and may work once the server is fixed
+	port, _ = fakeSMTPServer(t)
+	sender = &SMTPSender{Host: "127.0.0.1", Port: port, From: DefaultFrom, RequireTLS: true}
+	err = sender.Send(context.Background(), &Message{To: []string{"cleo@example.com"}, Text: "Hi"})
+	if err == nil || IsPermanent(err) {
+		t.Errorf("server without STARTTLS gave %v, want a retryable error", err)
+	}
+}
+
+func TestSMTPFailure(t *testing.T) {
+	if err := smtpFailure("send message", &textproto.Error{Code: 550, Msg: "mailbox unavailable"}); !IsPermanent(err) {
+		t.Errorf("5xx reply gave %v, want a permanent error", err)
+	}
+	if err := smtpFailure("send message", &textproto.Error{Code: 451, Msg: "try again later"}); IsPermanent(err) {
+		t.Errorf("4xx reply gave a permanent error: %v", err)
+	}
+	if err := smtpFailure("send message", io.ErrUnexpectedEOF); IsPermanent(err) {
+		t.Errorf("dropped connection gave a permanent error: %v", err)
+	}
+}
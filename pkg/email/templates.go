@@ -0,0 +1,241 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"smart_contract/pkg/i18n"
+	"smart_contract/pkg/money"
+)
+
+// Directory holding the email templates; each template is a set of files
+// <name>.subject.txt, <name>.txt and <name>.html, with the HTML part rendered inside layout.html.
+// Their text comes from the i18n catalog through the t helper.
+var TemplateDir = "templates/email"
+
+// The subject and bodies of an email rendered from a template
+type Rendered struct {
+	Subject  string
+	Text     string
+	HTML     string
+	Category Category
+}
+
+// An email template whose data must be of type T
+type Template[T any] struct {
+	Name     string
+	Category Category // Whether recipients can opt out of the email
+}
+
+// Renders the template's subject, plain text and HTML parts in the given locale
+func (t Template[T]) Render(locale string, data T) (*Rendered, error) {
+	rendered, err := render(t.Name, i18n.For(locale), data)
+	if err != nil {
+		return nil, err
+	}
+	rendered.Category = t.Category
+	return rendered, nil
+}
+
+// Sent on each status transition of a contract
+var (
+	RequirementsConfirmed = Template[RequirementsConfirmedData]{"requirements_confirmed", Transactional}
+	PaymentReceived       = Template[PaymentReceivedData]{"payment_received", Transactional}
+	MilestoneCompleted    = Template[MilestoneCompletedData]{"milestone_completed", Updates}
+	DisputeOpened         = Template[DisputeOpenedData]{"dispute_opened", Transactional}
+	DisputeResolved       = Template[DisputeResolvedData]{"dispute_resolved", Transactional}
+	FundsReleased         = Template[FundsReleasedData]{"funds_released", Transactional}
+	RefundIssued          = Template[RefundIssuedData]{"refund_issued", Transactional}
+	ContractCancelled     = Template[ContractCancelledData]{"contract_cancelled", Transactional}
+)
+
+// Sent outside of status transitions
+var (
+	EscrowInitiated       = Template[EscrowInitiatedData]{"escrow_initiated", Transactional}
+	CancellationRequested = Template[CancellationRequestedData]{"cancellation_requested", Transactional}
+	ContractExpiring      = Template[ContractExpiringData]{"contract_expiring", Reminders}
+	ClientEmailBounced    = Template[ClientEmailBouncedData]{"client_email_bounced", Transactional}
+)
+
+// Who an email is addressed to and which contract it is about
+type Recipient struct {
+	FirstName     string
+	ContractID    int
+	DashboardLink string
+}
+
+// Data for the email sent to the client when the freelancer initiates an escrow
+type EscrowInitiatedData struct {
+	ClientFirstName string
+	UserFirstName   string
+	Requirements    []string
+	PaymentLink     string
+	DashboardLink   string
+}
+
+// Data for the email sent when the client confirms the requirements
+type RequirementsConfirmedData struct {
+	Recipient
+	AmountDue money.Amount
+	Quoted    *money.Amount // The agreed price when it was converted into another currency
+	ExpiresAt time.Time     // When the converted amount stops being valid
+}
+
+// Data for the email sent when the client's payment reaches escrow
+type PaymentReceivedData struct {
+	Recipient
+	Amount        money.Amount
+	InvoiceNumber string
+}
+
+// Data for the email sent when the freelancer has completed the requirements
+type MilestoneCompletedData struct {
+	Recipient
+}
+
+// Data for the email sent when either party disputes the contract
+type DisputeOpenedData struct {
+	Recipient
+}
+
+// Data for the email sent when a dispute is settled without a refund
+type DisputeResolvedData struct {
+	Recipient
+	RequirementsCompleted bool // Whether the work was accepted as complete
+}
+
+// Data for the email sent when escrowed funds are paid to the freelancer
+type FundsReleasedData struct {
+	Recipient
+	Amount        money.Amount
+	ReceiptNumber string
+}
+
+// Data for the email sent when escrowed funds are returned to the client
+type RefundIssuedData struct {
+	Recipient
+	Amount money.Amount
+}
+
+// Data for the email sent when an unpaid contract is cancelled
+type ContractCancelledData struct {
+	Recipient
+}
+
+// Data for the email sent when a party asks to cancel a paid contract
+type CancellationRequestedData struct {
+	Recipient
+	RequestedBy string
+	Reason      string
+}
+
+// Data for the email sent when a confirmed contract's quote is about to expire
+type ContractExpiringData struct {
+	Recipient
+	AmountDue money.Amount
+	ExpiresAt time.Time
+}
+
+// Data for the email asking the freelancer to fix a client's address after mail to it bounced or was reported as spam
+type ClientEmailBouncedData struct {
+	Recipient
+	ClientName  string
+	ClientEmail string
+	Complaint   bool // The client reported our email as spam rather than it bouncing
+	Reason      string
+}
+
+func render(name string, catalog *i18n.Catalog, data interface{}) (*Rendered, error) {
+	base := filepath.Join(TemplateDir, name)
+
+	subject, err := renderText(base+".subject.txt", catalog, data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := renderText(base+".txt", catalog, data)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := renderHTML(base+".html", catalog, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rendered{
+		Subject: strings.TrimSpace(subject),
+		Text:    text,
+		HTML:    html,
+	}, nil
+}
+
+func renderText(path string, catalog *i18n.Catalog, data interface{}) (string, error) {
+	tmpl, err := texttemplate.New(filepath.Base(path)).Funcs(catalog.Funcs()).ParseFiles(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse email template: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute email template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// Renders an HTML part inside the branded layout
+func renderHTML(path string, catalog *i18n.Catalog, data interface{}) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read email template: %v", err)
+	}
+
+	funcs := htmltemplate.FuncMap(catalog.Funcs())
+	funcs["button"] = button
+	tmpl, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFiles(filepath.Join(TemplateDir, "layout.html"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse email layout: %v", err)
+	}
+	if _, err := tmpl.New("content").Parse(string(content)); err != nil {
+		return "", fmt.Errorf("failed to parse email template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", fmt.Errorf("failed to execute email template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// Renders a link as a button; styles are inline because many mail clients drop <style> blocks
+func button(link, label string) htmltemplate.HTML {
+	if u, err := url.Parse(link); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		link = "#"
+	}
+	return htmltemplate.HTML(fmt.Sprintf(`<table role="presentation" cellpadding="0" cellspacing="0" style="margin:8px 0 20px;"><tr>`+
+		`<td style="border-radius:4px;background-color:#007BFF;">`+
+		`<a href="%s" style="display:inline-block;padding:12px 24px;font-family:Arial, sans-serif;font-size:15px;font-weight:bold;color:#ffffff;text-decoration:none;border-radius:4px;">%s</a>`+
+		`</td></tr></table>`, html.EscapeString(link), html.EscapeString(label)))
+}
+
+// Splits requirements written as lines, optionally bulleted or numbered, into list items
+func RequirementItems(requirements string) []string {
+	var items []string
+	for _, line := range strings.Split(requirements, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*•"))
+		if i := strings.IndexAny(line, ".)"); i > 0 && i <= 3 && strings.Trim(line[:i], "0123456789") == "" {
+			line = strings.TrimSpace(line[i+1:])
+		}
+		if line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
@@ -0,0 +1,115 @@
+package email
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"smart_contract/pkg/i18n"
+	"smart_contract/pkg/money"
+)
+
+func TestMain(m *testing.M) {
+	TemplateDir = "../../templates/email"
+	i18n.Dir = "../../locales"
+	os.Exit(m.Run())
+}
+
+// Every template must render in every locale with all of its messages and placeholders filled in
+func TestTemplatesRenderInEveryLocale(t *testing.T) {
+	locales, err := i18n.Supported()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amount, _ := money.Parse("1.5 ETH")
+	quoted, _ := money.Parse("500 USD")
+	expires := time.Now().Add(time.Hour)
+	r := Recipient{FirstName: "Ana", ContractID: 7, DashboardLink: "https://example.com/contracts/7"}
+
+	for _, locale := range locales {
+		renders := map[string]func() (*Rendered, error){
+			"escrow_initiated": func() (*Rendered, error) {
+				return GenerateEmail(EmailData{ClientFirstName: "Ana", UserFirstName: "Luis", Requirements: "- One\n- Two",
+					PaymentLink: "https://example.com/pay", DashboardLink: r.DashboardLink, Locale: locale})
+			},
+			"requirements_confirmed": func() (*Rendered, error) {
+				return RequirementsConfirmed.Render(locale, RequirementsConfirmedData{Recipient: r, AmountDue: amount, Quoted: &quoted, ExpiresAt: expires})
+			},
+			"payment_received": func() (*Rendered, error) {
+				return PaymentReceived.Render(locale, PaymentReceivedData{Recipient: r, Amount: amount, InvoiceNumber: "INV-1"})
+			},
+			"milestone_completed": func() (*Rendered, error) {
+				return MilestoneCompleted.Render(locale, MilestoneCompletedData{Recipient: r})
+			},
+			"dispute_opened": func() (*Rendered, error) {
+				return DisputeOpened.Render(locale, DisputeOpenedData{Recipient: r})
+			},
+			"dispute_resolved": func() (*Rendered, error) {
+				return DisputeResolved.Render(locale, DisputeResolvedData{Recipient: r})
+			},
+			"funds_released": func() (*Rendered, error) {
+				return FundsReleased.Render(locale, FundsReleasedData{Recipient: r, Amount: amount, ReceiptNumber: "RCT-1"})
+			},
+			"refund_issued": func() (*Rendered, error) {
+				return RefundIssued.Render(locale, RefundIssuedData{Recipient: r, Amount: amount})
+			},
+			"contract_cancelled": func() (*Rendered, error) {
+				return ContractCancelled.Render(locale, ContractCancelledData{Recipient: r})
+			},
+			"cancellation_requested": func() (*Rendered, error) {
+				return CancellationRequested.Render(locale, CancellationRequestedData{Recipient: r, RequestedBy: "client", Reason: "On hold"})
+			},
+			"contract_expiring": func() (*Rendered, error) {
+				return ContractExpiring.Render(locale, ContractExpiringData{Recipient: r, AmountDue: amount, ExpiresAt: expires})
+			},
+			"client_email_bounced": func() (*Rendered, error) {
+				return ClientEmailBounced.Render(locale, ClientEmailBouncedData{Recipient: r, ClientName: "Ana", ClientEmail: "ana@example.com", Reason: "550 5.1.1 User unknown"})
+			},
+		}
+
+		for name, render := range renders {
+			rendered, err := render()
+			if err != nil {
+				t.Errorf("%s/%s: %v", locale, name, err)
+				continue
+			}
+			if rendered.Subject == "" {
+				t.Errorf("%s/%s: empty subject", locale, name)
+			}
+			for part, content := range map[string]string{"subject": rendered.Subject, "text": rendered.Text, "html": rendered.HTML} {
+				if strings.Contains(content, name+".") || strings.Contains(content, "email.") {
+					t.Errorf("%s/%s: %s contains an untranslated key:\n%s", locale, name, part, content)
+				}
+				if strings.Contains(content, "{") {
+					t.Errorf("%s/%s: %s contains an unfilled placeholder:\n%s", locale, name, part, content)
+				}
+			}
+			if !strings.Contains(rendered.HTML, `lang="`+locale+`"`) {
+				t.Errorf("%s/%s: HTML isn't marked as %s", locale, name, locale)
+			}
+		}
+	}
+}
+
+func TestTemplatesUseRecipientLanguage(t *testing.T) {
+	amount, _ := money.Parse("1234.5 USD")
+	data := RefundIssuedData{Recipient: Recipient{FirstName: "Ana", ContractID: 7}, Amount: amount}
+
+	en, err := RefundIssued.Render("en", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es, err := RefundIssued.Render("es", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(en.Text, "Hi Ana,") || !strings.Contains(en.Text, "1,234.50 USD") {
+		t.Errorf("unexpected English text:\n%s", en.Text)
+	}
+	if !strings.Contains(es.Text, "Hola Ana:") || !strings.Contains(es.Text, "1.234,50 USD") {
+		t.Errorf("unexpected Spanish text:\n%s", es.Text)
+	}
+}
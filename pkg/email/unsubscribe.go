@@ -0,0 +1,49 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// Kinds of notices a recipient can opt out of. Transactional emails, such as payment
+// confirmations and receipts, are always sent.
+type Category string
+
+const (
+	Transactional Category = ""
+	Updates       Category = "updates"   // Progress on a contract that needs no action
+	Reminders     Category = "reminders" // Nudges such as an expiring quote
+)
+
+// Categories a recipient can unsubscribe from
+var OptionalCategories = []Category{Updates, Reminders}
+
+// Builds and verifies signed one-click unsubscribe links, so recipients need no account to opt out
+type UnsubscribeLinks struct {
+	BaseURL string
+	Secret  []byte
+}
+
+// Returns the link unsubscribing an address from a category
+func (u *UnsubscribeLinks) For(address string, category Category) string {
+	query := url.Values{
+		"email":    {address},
+		"category": {string(category)},
+		"token":    {u.sign(address, category)},
+	}
+	return u.BaseURL + "/unsubscribe?" + query.Encode()
+}
+
+// Reports whether token was issued for the address and category
+func (u *UnsubscribeLinks) Verify(address string, category Category, token string) bool {
+	return hmac.Equal([]byte(token), []byte(u.sign(address, category)))
+}
+
+func (u *UnsubscribeLinks) sign(address string, category Category) string {
+	mac := hmac.New(sha256.New, u.Secret)
+	mac.Write([]byte(strings.ToLower(address) + "\n" + string(category)))
+	return hex.EncodeToString(mac.Sum(nil))[:24]
+}
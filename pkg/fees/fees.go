@@ -0,0 +1,243 @@
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"smart_contract/pkg/money"
+)
+
+// Identifies how a fee rule computes the platform fee
+type RuleType string
+
+const (
+	Percentage RuleType = "percentage"
+	Flat       RuleType = "flat"
+	Tiered     RuleType = "tiered"
+)
+
+// A bracket of a tiered rule; the first tier whose UpTo covers the gross amount applies
+type Tier struct {
+	UpTo *money.Amount `json:"up_to,omitempty"` // nil means no upper bound
+	Rate float64       `json:"rate"`
+	Flat *money.Amount `json:"flat,omitempty"`
+}
+
+// Describes how the platform fee is calculated for a payment
+type Rule struct {
+	Type    RuleType      `json:"type"`
+	Rate    float64       `json:"rate,omitempty"`
+	Amount  *money.Amount `json:"amount,omitempty"`
+	Tiers   []Tier        `json:"tiers,omitempty"`
+	Minimum *money.Amount `json:"minimum,omitempty"`
+	Maximum *money.Amount `json:"maximum,omitempty"`
+}
+
+// A versioned set of fee rules for one currency, with per-plan and per-user overrides
+type Schedule struct {
+	Version            string          `json:"version"`
+	Currency           string          `json:"currency"`
+	Default            Rule            `json:"default"`
+	Plans              map[string]Rule `json:"plans,omitempty"`
+	Users              map[string]Rule `json:"users,omitempty"`
+	NetworkFeeEstimate money.Amount    `json:"network_fee_estimate"`
+}
+
+// Holds every fee schedule ever published and the one currently quoted
+type Config struct {
+	Current   string     `json:"current"`
+	Schedules []Schedule `json:"schedules"`
+}
+
+// The itemised result of quoting a payment against a schedule
+type Breakdown struct {
+	ScheduleVersion string       `json:"schedule_version"`
+	Gross           money.Amount `json:"gross"`
+	PlatformFee     money.Amount `json:"platform_fee"`
+	NetworkFee      money.Amount `json:"network_fee"`
+	Net             money.Amount `json:"net"`
+}
+
+// Reads the fee configuration from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %v", path, err)
+	}
+
+	for _, schedule := range config.Schedules {
+		if schedule.Version == config.Current {
+			return &config, nil
+		}
+	}
+	return nil, fmt.Errorf("fee schedule %q not found", config.Current)
+}
+
+// Looks up a schedule by version and currency so historical quotes can be reproduced
+func (c *Config) Schedule(version, currency string) (*Schedule, error) {
+	for i := range c.Schedules {
+		if c.Schedules[i].Version == version && c.Schedules[i].Currency == currency {
+			return &c.Schedules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("fee schedule %q not found for %s", version, currency)
+}
+
+// Quotes a payment against the current schedule for its currency
+func (c *Config) Quote(userID int, plan string, gross money.Amount) (Breakdown, error) {
+	return c.QuoteAt(c.Current, userID, plan, gross)
+}
+
+// Quotes a payment against a specific schedule version, e.g. the one a contract was first quoted with
+func (c *Config) QuoteAt(version string, userID int, plan string, gross money.Amount) (Breakdown, error) {
+	schedule, err := c.Schedule(version, gross.Currency.Code)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	return schedule.Quote(userID, plan, gross)
+}
+
+// Picks the rule that applies to a user, preferring user overrides over plan overrides
+func (s *Schedule) RuleFor(userID int, plan string) Rule {
+	if rule, ok := s.Users[strconv.Itoa(userID)]; ok {
+		return rule
+	}
+	if rule, ok := s.Plans[plan]; ok {
+		return rule
+	}
+	return s.Default
+}
+
+// Calculates the fee breakdown for a gross payment amount
+func (s *Schedule) Quote(userID int, plan string, gross money.Amount) (Breakdown, error) {
+	if gross.Sign() <= 0 {
+		return Breakdown{}, fmt.Errorf("payment amount must be positive")
+	}
+
+	platformFee, err := s.RuleFor(userID, plan).Apply(gross)
+	if err != nil {
+		return Breakdown{}, err
+	}
+
+	networkFee := s.NetworkFeeEstimate
+	if networkFee.Units == nil {
+		networkFee = money.Zero(gross.Currency)
+	}
+
+	net, err := gross.Sub(platformFee)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	net, err = net.Sub(networkFee)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	if net.Sign() < 0 {
+		return Breakdown{}, fmt.Errorf("fees exceed payment amount")
+	}
+
+	return Breakdown{
+		ScheduleVersion: s.Version,
+		Gross:           gross,
+		PlatformFee:     platformFee,
+		NetworkFee:      networkFee,
+		Net:             net,
+	}, nil
+}
+
+// Replaces the network fee estimate with the network fees actually spent, recomputing the net amount. The client
+// was quoted the estimate, so spending more than it never takes more than the estimate from the freelancer
+func (b Breakdown) WithNetworkFee(spent money.Amount) (Breakdown, error) {
+	cmp, err := spent.Cmp(b.NetworkFee)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	if spent.Sign() < 0 {
+		return Breakdown{}, fmt.Errorf("network fees spent can't be negative")
+	}
+	if cmp > 0 {
+		return b, nil
+	}
+
+	net, err := b.Gross.Sub(b.PlatformFee)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	if b.Net, err = net.Sub(spent); err != nil {
+		return Breakdown{}, err
+	}
+	b.NetworkFee = spent
+	return b, nil
+}
+
+// Computes the platform fee for a gross amount, clamped to the rule's minimum and maximum
+func (r Rule) Apply(gross money.Amount) (money.Amount, error) {
+	var fee money.Amount
+
+	switch r.Type {
+	case Percentage:
+		fee = gross.MulRate(r.Rate)
+	case Flat:
+		if r.Amount == nil {
+			return money.Amount{}, fmt.Errorf("flat fee rule has no amount")
+		}
+		fee = *r.Amount
+	case Tiered:
+		tier, err := r.tierFor(gross)
+		if err != nil {
+			return money.Amount{}, err
+		}
+		fee = gross.MulRate(tier.Rate)
+		if tier.Flat != nil {
+			if fee, err = fee.Add(*tier.Flat); err != nil {
+				return money.Amount{}, err
+			}
+		}
+	default:
+		return money.Amount{}, fmt.Errorf("unknown fee rule type %q", r.Type)
+	}
+
+	if r.Minimum != nil {
+		cmp, err := fee.Cmp(*r.Minimum)
+		if err != nil {
+			return money.Amount{}, err
+		}
+		if cmp < 0 {
+			fee = *r.Minimum
+		}
+	}
+	if r.Maximum != nil {
+		cmp, err := fee.Cmp(*r.Maximum)
+		if err != nil {
+			return money.Amount{}, err
+		}
+		if cmp > 0 {
+			fee = *r.Maximum
+		}
+	}
+	return fee, nil
+}
+
+// Finds the tier bracket covering the gross amount
+func (r Rule) tierFor(gross money.Amount) (Tier, error) {
+	for _, tier := range r.Tiers {
+		if tier.UpTo == nil {
+			return tier, nil
+		}
+		cmp, err := gross.Cmp(*tier.UpTo)
+		if err != nil {
+			return Tier{}, err
+		}
+		if cmp <= 0 {
+			return tier, nil
+		}
+	}
+	return Tier{}, fmt.Errorf("no fee tier covers amount %s", gross)
+}
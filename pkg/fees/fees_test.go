@@ -0,0 +1,211 @@
+package fees
+
+import (
+	"testing"
+
+	"smart_contract/pkg/money"
+)
+
+func eth(t *testing.T, s string) money.Amount {
+	t.Helper()
+	amount, err := money.ParseIn(s, money.ETH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amount
+}
+
+func ethPtr(t *testing.T, s string) *money.Amount {
+	amount := eth(t, s)
+	return &amount
+}
+
+func TestRuleApply(t *testing.T) {
+	tiered := Rule{Type: Tiered, Tiers: []Tier{
+		{UpTo: ethPtr(t, "1"), Rate: 0.05},
+		{UpTo: ethPtr(t, "10"), Rate: 0.04, Flat: ethPtr(t, "0.01")},
+		{Rate: 0.03},
+	}}
+
+	tests := []struct {
+		name  string
+		rule  Rule
+		gross string
+		want  string
+	}{
+		{"percentage", Rule{Type: Percentage, Rate: 0.05}, "2", "0.1"},
+		{"percentage rounds down to whole wei", Rule{Type: Percentage, Rate: 0.05}, "0.000000000000000019", "0.000000000000000000"},
+		{"flat", Rule{Type: Flat, Amount: ethPtr(t, "0.02")}, "5", "0.02"},
+		{"first tier", tiered, "1", "0.05"},
+		{"second tier with flat part", tiered, "5", "0.21"},
+		{"unbounded last tier", tiered, "100", "3"},
+		{"raised to minimum", Rule{Type: Percentage, Rate: 0.01, Minimum: ethPtr(t, "0.05")}, "1", "0.05"},
+		{"capped at maximum", Rule{Type: Percentage, Rate: 0.5, Maximum: ethPtr(t, "1")}, "10", "1"},
+		{"between minimum and maximum", Rule{Type: Percentage, Rate: 0.1, Minimum: ethPtr(t, "0.05"), Maximum: ethPtr(t, "1")}, "2", "0.2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fee, err := test.rule.Apply(eth(t, test.gross))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := eth(t, test.want); fee.Units.Cmp(want.Units) != 0 || fee.Currency != money.ETH {
+				t.Errorf("fee = %s, want %s", fee, want)
+			}
+		})
+	}
+}
+
+func TestRuleApplyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"flat without amount", Rule{Type: Flat}},
+		{"unknown type", Rule{Type: "bogus"}},
+		{"no tier covers amount", Rule{Type: Tiered, Tiers: []Tier{{UpTo: ethPtr(t, "1"), Rate: 0.05}}}},
+		{"minimum in another currency", Rule{Type: Percentage, Rate: 0.05, Minimum: &money.Amount{Units: eth(t, "1").Units, Currency: money.USD}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if fee, err := test.rule.Apply(eth(t, "2")); err == nil {
+				t.Errorf("got fee %s, want an error", fee)
+			}
+		})
+	}
+}
+
+func TestRuleFor(t *testing.T) {
+	user := Rule{Type: Percentage, Rate: 0.01}
+	plan := Rule{Type: Percentage, Rate: 0.02}
+	s := Schedule{
+		Default: Rule{Type: Percentage, Rate: 0.05},
+		Plans:   map[string]Rule{"pro": plan},
+		Users:   map[string]Rule{"7": user},
+	}
+
+	tests := []struct {
+		name   string
+		userID int
+		plan   string
+		want   float64
+	}{
+		{"default", 1, "", 0.05},
+		{"plan override", 1, "pro", 0.02},
+		{"user override beats plan", 7, "pro", 0.01},
+		{"unknown plan falls back to default", 1, "enterprise", 0.05},
+	}
+	for _, test := range tests {
+		if got := s.RuleFor(test.userID, test.plan); got.Rate != test.want {
+			t.Errorf("%s: rate = %v, want %v", test.name, got.Rate, test.want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	s := Schedule{
+		Version:            "v1",
+		Currency:           "ETH",
+		Default:            Rule{Type: Percentage, Rate: 0.05},
+		NetworkFeeEstimate: eth(t, "0.01"),
+	}
+
+	breakdown, err := s.Quote(1, "", eth(t, "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if breakdown.ScheduleVersion != "v1" {
+		t.Errorf("schedule version = %q, want v1", breakdown.ScheduleVersion)
+	}
+	for name, got := range map[string]money.Amount{
+		"0.1":  breakdown.PlatformFee,
+		"0.01": breakdown.NetworkFee,
+		"1.89": breakdown.Net,
+		"2":    breakdown.Gross,
+	} {
+		if got.Units.Cmp(eth(t, name).Units) != 0 {
+			t.Errorf("got %s, want %s ETH", got, name)
+		}
+	}
+
+	// Gross is always platform fee, network fee and net together
+	total, _ := breakdown.PlatformFee.Add(breakdown.NetworkFee)
+	total, _ = total.Add(breakdown.Net)
+	if cmp, _ := total.Cmp(breakdown.Gross); cmp != 0 {
+		t.Errorf("fees and net add up to %s, not the gross %s", total, breakdown.Gross)
+	}
+
+	if _, err := s.Quote(1, "", eth(t, "0")); err == nil {
+		t.Error("quoting a zero payment succeeded")
+	}
+	if _, err := s.Quote(1, "", eth(t, "0.005")); err == nil {
+		t.Error("quoting a payment smaller than its fees succeeded")
+	}
+}
+
+func TestConfigQuotesHistoricalVersions(t *testing.T) {
+	config, err := LoadConfig("../../fee_schedule.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := config.Quote(1, "", eth(t, "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := config.QuoteAt("2024-01", 1, "", eth(t, "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.ScheduleVersion != config.Current || old.ScheduleVersion != "2024-01" {
+		t.Errorf("quoted versions %q and %q", current.ScheduleVersion, old.ScheduleVersion)
+	}
+	// 4% of the second tier now, a flat 5% then
+	if current.PlatformFee.Units.Cmp(eth(t, "0.08").Units) != 0 || old.PlatformFee.Units.Cmp(eth(t, "0.1").Units) != 0 {
+		t.Errorf("platform fees %s and %s, want 0.08 ETH and 0.1 ETH", current.PlatformFee, old.PlatformFee)
+	}
+
+	if _, err := config.QuoteAt("1999-01", 1, "", eth(t, "2")); err == nil {
+		t.Error("quoting against an unknown version succeeded")
+	}
+}
+
+func TestWithNetworkFee(t *testing.T) {
+	quoted := Breakdown{ScheduleVersion: "v1", Gross: eth(t, "2"), PlatformFee: eth(t, "0.1"), NetworkFee: eth(t, "0.01"), Net: eth(t, "1.89")}
+	usd, _ := money.ParseIn("0.004", money.USD)
+
+	tests := []struct {
+		name       string
+		spent      money.Amount
+		networkFee string
+		net        string
+		valid      bool
+	}{
+		{"less than estimated", eth(t, "0.004"), "0.004", "1.896", true},
+		{"as estimated", eth(t, "0.01"), "0.01", "1.89", true},
+		{"nothing spent", eth(t, "0"), "0", "1.9", true},
+		{"more than estimated", eth(t, "0.05"), "0.01", "1.89", true},
+		{"negative", eth(t, "-0.01"), "", "", false},
+		{"other currency", usd, "", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settled, err := quoted.WithNetworkFee(test.spent)
+			if !test.valid {
+				if err == nil {
+					t.Errorf("settled as %+v", settled)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if settled.NetworkFee.Units.Cmp(eth(t, test.networkFee).Units) != 0 || settled.Net.Units.Cmp(eth(t, test.net).Units) != 0 {
+				t.Errorf("network fee %s and net %s, want %s and %s ETH", settled.NetworkFee, settled.Net, test.networkFee, test.net)
+			}
+			if settled.Gross != quoted.Gross || settled.PlatformFee != quoted.PlatformFee || settled.ScheduleVersion != "v1" {
+				t.Errorf("changed more than the network fee: %+v", settled)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smart_contract/pkg/money"
+)
+
+// Directory holding one <locale>.json catalog per supported locale
+var Dir = "locales"
+
+// Locale used when a user has none or theirs isn't supported; every message must exist in it
+const DefaultLocale = "en"
+
+// How numbers and dates are written in a locale
+type Format struct {
+	Decimal  string   `json:"decimal"`  // Decimal separator
+	Group    string   `json:"group"`    // Thousands separator
+	Date     string   `json:"date"`     // Uses {day}, {month} and {year}
+	DateTime string   `json:"datetime"` // Uses {date} and {time}
+	Months   []string `json:"months"`
+}
+
+// The messages and formats of one locale
+type Catalog struct {
+	Locale   string            `json:"locale"`
+	Name     string            `json:"name"` // The locale's name in its own language
+	Format   Format            `json:"format"`
+	Messages map[string]string `json:"messages"`
+
+	fallback *Catalog
+}
+
+var (
+	mu       sync.Mutex
+	catalogs = map[string]*Catalog{}
+)
+
+// Loads the catalog for a locale such as "es" or "es-MX", falling back to its base language
+func Load(locale string) (*Catalog, error) {
+	locale = normalize(locale)
+
+	mu.Lock()
+	defer mu.Unlock()
+	return load(locale)
+}
+
+func load(locale string) (*Catalog, error) {
+	if catalog, ok := catalogs[locale]; ok {
+		return catalog, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(Dir, locale+".json"))
+	if os.IsNotExist(err) && strings.Contains(locale, "-") {
+		return load(locale[:strings.Index(locale, "-")])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog for %s: %v", locale, err)
+	}
+
+	catalog := &Catalog{}
+	if err := json.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog for %s: %v", locale, err)
+	}
+	if len(catalog.Format.Months) != 12 {
+		return nil, fmt.Errorf("catalog for %s must name 12 months", locale)
+	}
+
+	if locale != DefaultLocale {
+		if catalog.fallback, err = load(DefaultLocale); err != nil {
+			return nil, err
+		}
+	}
+	catalogs[locale] = catalog
+	return catalog, nil
+}
+
+// Returns the catalog for a locale, or the default one if it isn't supported
+func For(locale string) *Catalog {
+	catalog, err := Load(locale)
+	if err == nil {
+		return catalog
+	}
+	if locale != "" {
+		log.Printf("Error loading locale %q, using %s: %v", locale, DefaultLocale, err)
+	}
+	if catalog, err = Load(DefaultLocale); err != nil {
+		log.Printf("Error loading default locale: %v", err)
+		return &Catalog{Locale: DefaultLocale, Format: Format{Decimal: ".", Group: ",", Date: "{year}-{month}-{day}", DateTime: "{date} {time}"}}
+	}
+	return catalog
+}
+
+// Lists the locales that have a catalog
+func Supported() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(Dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalogs: %v", err)
+	}
+	var locales []string
+	for _, path := range paths {
+		locales = append(locales, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	sort.Strings(locales)
+	return locales, nil
+}
+
+// Picks the best supported locale for an Accept-Language header, or the default
+func Match(acceptLanguage string) string {
+	supported, err := Supported()
+	if err != nil {
+		return DefaultLocale
+	}
+
+	type preference struct {
+		locale string
+		q      float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		p := preference{locale: normalize(fields[0]), q: 1}
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				p.q, _ = strconv.ParseFloat(value, 64)
+			}
+		}
+		if p.locale != "" && p.q > 0 {
+			preferences = append(preferences, p)
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].q > preferences[j].q })
+
+	for _, p := range preferences {
+		for _, candidate := range []string{p.locale, strings.SplitN(p.locale, "-", 2)[0]} {
+			for _, locale := range supported {
+				if candidate == locale {
+					return locale
+				}
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// Translates a message, replacing each {name} placeholder with the value following that name in args.
+// Amounts and times are formatted for the locale; integers are left as is since they are mostly IDs.
+func (c *Catalog) T(key string, args ...interface{}) string {
+	message, ok := c.Messages[key]
+	if !ok && c.fallback != nil {
+		message, ok = c.fallback.Messages[key]
+	}
+	if !ok {
+		log.Printf("Missing message %q for locale %s", key, c.Locale)
+		return key
+	}
+
+	for i := 0; i+1 < len(args); i += 2 {
+		name := fmt.Sprint(args[i])
+		message = strings.ReplaceAll(message, "{"+name+"}", c.format(args[i+1]))
+	}
+	return message
+}
+
+func (c *Catalog) format(value interface{}) string {
+	switch v := value.(type) {
+	case money.Amount:
+		return c.Money(v)
+	case *money.Amount:
+		if v == nil {
+			return ""
+		}
+		return c.Money(*v)
+	case time.Time:
+		return c.DateTime(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// Formats an amount with the locale's separators, e.g. "1,234.50 USD" or "1.234,50 USD"
+func (c *Catalog) Money(a money.Amount) string {
+	decimal := a.Decimal()
+	sign := ""
+	if strings.HasPrefix(decimal, "-") {
+		sign, decimal = "-", decimal[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(decimal, ".")
+	// Fiat amounts always show their cents
+	if a.Currency.Decimals == 2 {
+		fraction = (fraction + "00")[:2]
+	}
+
+	formatted := sign + c.group(whole)
+	if fraction != "" {
+		formatted += c.Format.Decimal + fraction
+	}
+	return formatted + " " + a.Currency.Code
+}
+
+// Formats an integer with the locale's thousands separator
+func (c *Catalog) Number(n int64) string {
+	if n < 0 {
+		return "-" + c.group(strconv.FormatInt(-n, 10))
+	}
+	return c.group(strconv.FormatInt(n, 10))
+}
+
+func (c *Catalog) group(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(c.Format.Group)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// Formats a date, e.g. "2 January 2006" or "2 de enero de 2006"
+func (c *Catalog) Date(t time.Time) string {
+	month := strconv.Itoa(int(t.Month()))
+	if len(c.Format.Months) == 12 {
+		month = c.Format.Months[t.Month()-1]
+	}
+	return strings.NewReplacer(
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", month,
+		"{year}", strconv.Itoa(t.Year()),
+	).Replace(c.Format.Date)
+}
+
+// Formats a date and time of day with its time zone
+func (c *Catalog) DateTime(t time.Time) string {
+	return strings.NewReplacer(
+		"{date}", c.Date(t),
+		"{time}", t.Format("15:04 MST"),
+	).Replace(c.Format.DateTime)
+}
+
+// Template functions for the locale: t, money, number, date, datetime and locale
+func (c *Catalog) Funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"locale":   func() string { return c.Locale },
+		"t":        c.T,
+		"money":    c.Money,
+		"number":   func(n int) string { return c.Number(int64(n)) },
+		"date":     c.Date,
+		"datetime": c.DateTime,
+	}
+}
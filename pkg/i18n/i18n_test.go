@@ -0,0 +1,123 @@
+package i18n
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"smart_contract/pkg/money"
+)
+
+func TestMain(m *testing.M) {
+	Dir = "../../locales"
+	os.Exit(m.Run())
+}
+
+func TestCatalogsHaveTheSameMessages(t *testing.T) {
+	locales, err := Supported()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locales) < 2 {
+		t.Fatalf("expected at least two locales, got %v", locales)
+	}
+
+	base, err := Load(DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, locale := range locales {
+		catalog, err := Load(locale)
+		if err != nil {
+			t.Fatalf("loading %s: %v", locale, err)
+		}
+		for key := range base.Messages {
+			if _, ok := catalog.Messages[key]; !ok {
+				t.Errorf("%s is missing %q", locale, key)
+			}
+		}
+		for key := range catalog.Messages {
+			if _, ok := base.Messages[key]; !ok {
+				t.Errorf("%s has %q, which %s doesn't", locale, key, DefaultLocale)
+			}
+		}
+	}
+}
+
+func TestMoney(t *testing.T) {
+	usd, _ := money.Parse("1234.5 USD")
+	eth, _ := money.Parse("1234567.125 ETH")
+
+	tests := []struct {
+		locale string
+		amount money.Amount
+		want   string
+	}{
+		{"en", usd, "1,234.50 USD"},
+		{"es", usd, "1.234,50 USD"},
+		{"en", eth, "1,234,567.125 ETH"},
+		{"es", eth, "1.234.567,125 ETH"},
+		{"en", money.Zero(money.USD), "0.00 USD"},
+	}
+	for _, test := range tests {
+		if got := For(test.locale).Money(test.amount); got != test.want {
+			t.Errorf("%s: Money(%s) = %q, want %q", test.locale, test.amount, got, test.want)
+		}
+	}
+}
+
+func TestDates(t *testing.T) {
+	at := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+
+	if got, want := For("en").DateTime(at), "5 March 2024 at 14:30 UTC"; got != want {
+		t.Errorf("en DateTime = %q, want %q", got, want)
+	}
+	if got, want := For("es").DateTime(at), "5 de marzo de 2024, 14:30 UTC"; got != want {
+		t.Errorf("es DateTime = %q, want %q", got, want)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	if got := For("en").Number(-1234567); got != "-1,234,567" {
+		t.Errorf("en Number = %q", got)
+	}
+	if got := For("es").Number(999); got != "999" {
+		t.Errorf("es Number = %q", got)
+	}
+}
+
+func TestT(t *testing.T) {
+	amount, _ := money.Parse("10 USD")
+
+	if got, want := For("es").T("refund_issued.body", "contract", 7, "amount", amount),
+		"El depósito n.º 7 se ha cancelado y se están devolviendo 10,00 USD al cliente."; got != want {
+		t.Errorf("T = %q, want %q", got, want)
+	}
+	if got := For("en").T("no.such.key"); got != "no.such.key" {
+		t.Errorf("missing key rendered as %q", got)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	if got := For("es-MX").Locale; got != "es" {
+		t.Errorf("es-MX resolved to %q, want es", got)
+	}
+	if got := For("fr").Locale; got != DefaultLocale {
+		t.Errorf("fr resolved to %q, want %s", got, DefaultLocale)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"es-ES,es;q=0.9,en;q=0.8": "es",
+		"en;q=0.5, es;q=0.8":      "es",
+		"fr-FR,fr;q=0.9":          "en",
+		"":                        "en",
+		"EN-gb":                   "en",
+	}
+	for header, want := range tests {
+		if got := Match(header); got != want {
+			t.Errorf("Match(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package inbound
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// One method's verdict from an Authentication-Results header (RFC 8601), e.g. "dkim=pass header.d=example.com"
+type authResult struct {
+	Method     string
+	Result     string
+	Properties map[string]string // Keyed by ptype.property, e.g. "header.d" or "smtp.mailfrom"
+}
+
+// Checks that our receiving server verified the message as coming from the domain in its From address, through a
+// DKIM signature or SPF pass whose domain is aligned with it. Only results stamped with authServID are trusted, since
+// anyone can add an Authentication-Results header before the message reaches us.
+func authenticate(header mail.Header, authServID, from string) error {
+	if authServID == "" {
+		return reject("no trusted authentication server is configured")
+	}
+	_, domain, ok := strings.Cut(from, "@")
+	if !ok {
+		return reject("invalid From address %s", from)
+	}
+
+	for _, value := range header["Authentication-Results"] {
+		id, results := parseAuthResults(value)
+		if !strings.EqualFold(id, authServID) {
+			continue
+		}
+		for _, result := range results {
+			if result.Result != "pass" {
+				continue
+			}
+			switch result.Method {
+			case "dkim":
+				if aligned(result.Properties["header.d"], domain) {
+					return nil
+				}
+			case "spf":
+				mailFrom := result.Properties["smtp.mailfrom"]
+				if _, d, ok := strings.Cut(mailFrom, "@"); ok {
+					mailFrom = d
+				}
+				if aligned(mailFrom, domain) {
+					return nil
+				}
+			}
+		}
+	}
+	return reject("no DKIM or SPF pass from %s aligned with %s", authServID, domain)
+}
+
+// Relaxed alignment: the authenticated domain is the From domain or a parent or subdomain of it
+func aligned(authenticated, from string) bool {
+	authenticated, from = strings.ToLower(strings.TrimSuffix(authenticated, ".")), strings.ToLower(strings.TrimSuffix(from, "."))
+	if authenticated == "" || from == "" {
+		return false
+	}
+	// A bare top-level domain would align with everything under it
+	if !strings.Contains(authenticated, ".") || !strings.Contains(from, ".") {
+		return false
+	}
+	return authenticated == from || strings.HasSuffix(from, "."+authenticated) || strings.HasSuffix(authenticated, "."+from)
+}
+
+// Splits an Authentication-Results header into the server that added it and the results it reports
+func parseAuthResults(value string) (string, []authResult) {
+	parts := strings.Split(stripComments(value), ";")
+	// The authserv-id may be followed by a version number
+	id := strings.Fields(parts[0])
+	if len(id) == 0 {
+		return "", nil
+	}
+
+	var results []authResult
+	for _, part := range parts[1:] {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		method, verdict, ok := strings.Cut(fields[0], "=")
+		if !ok {
+			continue
+		}
+		method, _, _ = strings.Cut(method, "/")
+		result := authResult{Method: strings.ToLower(method), Result: strings.ToLower(verdict), Properties: map[string]string{}}
+		for _, field := range fields[1:] {
+			if key, value, ok := strings.Cut(field, "="); ok {
+				result.Properties[strings.ToLower(key)] = strings.Trim(value, `"`)
+			}
+		}
+		results = append(results, result)
+	}
+	return id[0], results
+}
+
+// Removes the parenthesized comments header fields may contain, e.g. "dkim=pass (2048-bit key)"
+func stripComments(value string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range value {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
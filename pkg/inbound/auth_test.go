@@ -0,0 +1,46 @@
+package inbound
+
+import (
+	"net/mail"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []string
+		from    string
+		valid   bool
+	}{
+		{"dkim pass", []string{"mx.tronch.io; dkim=pass (2048-bit key) header.d=example.com header.s=s1"}, "cleo@example.com", true},
+		{"spf pass", []string{"mx.tronch.io 1; spf=pass smtp.mailfrom=bounces@example.com"}, "cleo@example.com", true},
+		{"signed by parent domain", []string{"mx.tronch.io; dkim=pass header.d=example.com"}, "cleo@mail.example.com", true},
+		{"one of several results passes", []string{"mx.tronch.io; spf=fail smtp.mailfrom=x@example.com; dkim=pass header.d=example.com"}, "cleo@example.com", true},
+		{"mixed case", []string{"MX.tronch.io; DKIM=Pass header.d=Example.COM"}, "cleo@example.com", true},
+		{"no results", nil, "cleo@example.com", false},
+		{"dkim fail", []string{"mx.tronch.io; dkim=fail header.d=example.com"}, "cleo@example.com", false},
+		{"unaligned dkim", []string{"mx.tronch.io; dkim=pass header.d=attacker.test"}, "cleo@example.com", false},
+		{"lookalike domain", []string{"mx.tronch.io; dkim=pass header.d=badexample.com"}, "cleo@example.com", false},
+		{"top-level domain", []string{"mx.tronch.io; dkim=pass header.d=com"}, "cleo@example.com", false},
+		{"unaligned spf", []string{"mx.tronch.io; spf=pass smtp.mailfrom=x@attacker.test"}, "cleo@example.com", false},
+		{"added by someone else", []string{"mx.attacker.test; dkim=pass header.d=example.com"}, "cleo@example.com", false},
+		{"pass only in a comment", []string{"mx.tronch.io; dkim=fail (dkim=pass header.d=example.com) header.d=example.com"}, "cleo@example.com", false},
+		{"dmarc alone", []string{"mx.tronch.io; dmarc=pass header.from=example.com"}, "cleo@example.com", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := mail.Header{"Authentication-Results": test.results}
+			err := authenticate(header, "mx.tronch.io", test.from)
+			if test.valid && err != nil {
+				t.Errorf("rejected: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("accepted")
+			}
+		})
+	}
+
+	if err := authenticate(mail.Header{"Authentication-Results": tests[0].results}, "", "cleo@example.com"); err == nil {
+		t.Error("trusted results without a configured authentication server")
+	}
+}
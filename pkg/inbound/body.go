@@ -0,0 +1,142 @@
+package inbound
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Returns a message's plain text body, falling back to its HTML part with the markup removed
+func extractText(msg *mail.Message) (string, error) {
+	text, htmlBody, err := readPart(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return "", err
+	}
+	if text == "" && htmlBody != "" {
+		text = htmlToText(htmlBody)
+	}
+	return text, nil
+}
+
+// Walks a MIME part, returning the first text/plain and text/html bodies it contains
+func readPart(contentType, encoding string, body io.Reader) (string, string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var text, htmlBody string
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", "", fmt.Errorf("failed to read multipart body: %v", err)
+			}
+			if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+			t, h, err := readPart(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", "", err
+			}
+			if text == "" {
+				text = t
+			}
+			if htmlBody == "" {
+				htmlBody = h
+			}
+		}
+		return text, htmlBody, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineSkipper{body})
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode body: %v", err)
+	}
+
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if mediaType == "text/html" {
+		return "", content, nil
+	}
+	return content, "", nil
+}
+
+// Drops the line breaks base64 bodies are wrapped with
+type newlineSkipper struct {
+	r io.Reader
+}
+
+func (s *newlineSkipper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+var (
+	htmlQuote    = regexp.MustCompile(`(?is)<blockquote.*</blockquote>`)
+	htmlDropped  = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	htmlBreak    = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|tr|li|h[1-6])>`)
+	htmlTag      = regexp.MustCompile(`<[^>]*>`)
+	blankLines   = regexp.MustCompile(`\n{3,}`)
+	attribution  = regexp.MustCompile(`(?i)^(on|el) .+ (wrote|escribió):$`)
+	outlookQuote = regexp.MustCompile(`(?i)^-+\s*(original message|mensaje original)\s*-+$`)
+)
+
+// Reduces an HTML body to its text, dropping quoted replies
+func htmlToText(body string) string {
+	body = htmlQuote.ReplaceAllString(body, "")
+	body = htmlDropped.ReplaceAllString(body, "")
+	body = htmlBreak.ReplaceAllString(body, "\n")
+	body = html.UnescapeString(htmlTag.ReplaceAllString(body, ""))
+	return blankLines.ReplaceAllString(body, "\n\n")
+}
+
+// Strips the quoted message, reply attribution and signature from a reply, leaving what the sender wrote
+func StripQuoted(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var kept []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "--" && strings.HasPrefix(line, "-- ") || outlookQuote.MatchString(trimmed) {
+			break
+		}
+		// Attributions are often wrapped over two lines by the sender's client
+		if attribution.MatchString(trimmed) ||
+			i+1 < len(lines) && attribution.MatchString(trimmed+" "+strings.TrimSpace(lines[i+1])) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
@@ -0,0 +1,85 @@
+package inbound
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestStripQuoted(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain reply", "Looks good, thanks!\n", "Looks good, thanks!"},
+		{"crlf line endings", "Looks good.\r\nThanks\r\n", "Looks good.\nThanks"},
+		{"quoted lines", "Sounds good\n\n> Can you confirm?\n> Thanks", "Sounds good"},
+		{"interleaved reply", "> Can you start Monday?\nYes\n> And the budget?\nFine", "Yes\nFine"},
+		{"gmail attribution", "Confirm\n\nOn Mon, 3 Jun 2024 at 10:00, tronch <reply@tronch.io> wrote:\n> Please confirm", "Confirm"},
+		{"wrapped attribution", "Confirm\n\nOn Mon, 3 Jun 2024 at 10:00, tronch\n<reply@tronch.io> wrote:\nPlease confirm", "Confirm"},
+		{"spanish attribution", "Confirmar\n\nEl lun, 3 jun 2024 a las 10:00, tronch escribió:\n> Confirme", "Confirmar"},
+		{"outlook separator", "Dispute\n\n-----Original Message-----\nFrom: tronch", "Dispute"},
+		{"spanish outlook separator", "Disputar\n\n----- Mensaje original -----\nDe: tronch", "Disputar"},
+		{"signature", "Thanks!\n-- \nCleo\nSent from my phone", "Thanks!"},
+		{"dashes that aren't a signature", "Step one\n--\nStep two", "Step one\n--\nStep two"},
+		{"trailing whitespace", "Thanks   \n\t\n", "Thanks"},
+		{"only a quote", "> Please confirm\n> Thanks", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := StripQuoted(test.text); got != test.want {
+				t.Errorf("StripQuoted(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		body    string
+		want    string
+	}{
+		{"plain", "Content-Type: text/plain; charset=utf-8", "Confirm\n", "Confirm\n"},
+		{"no content type", "", "Confirm", "Confirm"},
+		{"quoted-printable", "Content-Type: text/plain; charset=utf-8\nContent-Transfer-Encoding: quoted-printable", "Confirmaci=C3=B3n recibida=\n, gracias", "Confirmación recibida, gracias"},
+		{"base64", "Content-Type: text/plain\nContent-Transfer-Encoding: base64", "Q29uZmlybQpUaGFu\na3M=", "Confirm\nThanks"},
+		{
+			"prefers plain text",
+			"Content-Type: multipart/alternative; boundary=b",
+			"--b\nContent-Type: text/html\n\n<p>HTML</p>\n--b\nContent-Type: text/plain\n\nPlain\n--b--\n",
+			"Plain",
+		},
+		{
+			"falls back to html",
+			"Content-Type: multipart/alternative; boundary=b",
+			"--b\nContent-Type: text/html\n\n<html><head><style>p{}</style></head><body><p>Dispute &amp; refund</p><div>Please</div><blockquote>Your contract</blockquote></body></html>\n--b--\n",
+			"Dispute & refund\nPlease\n",
+		},
+		{
+			"skips attachments",
+			"Content-Type: multipart/mixed; boundary=m",
+			"--m\nContent-Type: text/plain\nContent-Disposition: attachment; filename=notes.txt\n\nAttached notes\n--m\nContent-Type: multipart/alternative; boundary=a\n\n--a\nContent-Type: text/plain\n\nSee attached\n--a--\n--m--\n",
+			"See attached",
+		},
+		{"ignores other types", "Content-Type: application/pdf", "%PDF", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := "From: cleo@example.com\n" + test.headers + "\n\n" + test.body
+			msg, err := mail.ReadMessage(strings.NewReader(strings.ReplaceAll(raw, "\n", "\r\n")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := extractText(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(got) != strings.TrimSpace(test.want) {
+				t.Errorf("extractText = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
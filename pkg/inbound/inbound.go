@@ -0,0 +1,219 @@
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/rates"
+	"smart_contract/pkg/smart-contract"
+)
+
+// Commands a reply can start with to act on its contract
+const (
+	ConfirmCommand = "confirm"
+	DisputeCommand = "dispute"
+)
+
+// Words recognized as each command, in every language we email in
+var commands = map[string]string{
+	"confirm":   ConfirmCommand,
+	"confirmar": ConfirmCommand,
+	"dispute":   DisputeCommand,
+	"disputar":  DisputeCommand,
+}
+
+// A reply that can never be processed, e.g. one not addressed to a contract or sent by a stranger
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "rejected inbound email: " + e.Reason
+}
+
+// Reports whether an inbound email was rejected rather than failing to be processed
+func IsRejected(err error) bool {
+	var rejected *RejectedError
+	return errors.As(err, &rejected)
+}
+
+func reject(format string, args ...interface{}) error {
+	return &RejectedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Turns replies to contract emails into comments on the contract's timeline
+type Processor struct {
+	Replies    *email.ReplyAddresses
+	AuthServID string                       // Our receiving server's Authentication-Results id; commands and bounces are ignored without it
+	Rates      func() (rates.Source, error) // Loads exchange rates when a reply confirms a contract
+	PayIn      money.Currency
+	QuoteTTL   time.Duration
+}
+
+// What processing a reply did
+type Result struct {
+	Comment *db.ContractComment // Nil when the message was a bounce or complaint
+	Added   bool                // False if the reply had already been processed
+	Command string              // The command the reply ran, if any
+	Bounces []email.Bounce      // Delivery problems the message reported
+}
+
+// Parses a raw RFC 5322 message and records it on the contract it replies to.
+// Bounces and spam complaints sent back to us are recorded against the failed address instead.
+// Bounces are only recorded, and commands only run, when our server authenticated the sender.
+func (p *Processor) Process(ctx context.Context, raw []byte) (*Result, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, reject("failed to parse message: %v", err)
+	}
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return nil, reject("invalid From address: %v", err)
+	}
+
+	if bounces, ok := email.ParseReport(raw); ok {
+		if err := authenticate(msg.Header, p.AuthServID, from.Address); err != nil {
+			return nil, err
+		}
+		for _, bounce := range bounces {
+			if err := smart_contract.RecordBounce(bounce); err != nil {
+				return nil, err
+			}
+		}
+		return &Result{Bounces: bounces}, nil
+	}
+
+	contractID, party, ok := p.recipient(msg.Header)
+	if !ok {
+		return nil, reject("not addressed to a contract reply address")
+	}
+	if err := checkSender(contractID, party, from.Address); err != nil {
+		return nil, err
+	}
+
+	text, err := extractText(msg)
+	if err != nil {
+		return nil, reject("failed to read body: %v", err)
+	}
+	body := StripQuoted(text)
+	if body == "" {
+		return nil, reject("empty reply")
+	}
+
+	messageID := strings.TrimSpace(msg.Header.Get("Message-ID"))
+	if messageID == "" {
+		return nil, reject("missing Message-ID")
+	}
+
+	result := &Result{Comment: &db.ContractComment{
+		ContractID:  contractID,
+		AuthorParty: party,
+		AuthorEmail: from.Address,
+		Body:        body,
+		Source:      "email",
+		MessageID:   messageID,
+	}}
+	result.Added, err = db.AddContractComment(result.Comment)
+	if err != nil {
+		return nil, err
+	}
+	// Commands only run the first time a message is seen so redelivery can't repeat them
+	if !result.Added {
+		return result, nil
+	}
+
+	command := ParseCommand(body)
+	if command == "" {
+		return result, nil
+	}
+	// Anyone can put the party's address in From, so only replies our server authenticated act on the contract
+	if err := authenticate(msg.Header, p.AuthServID, from.Address); err != nil {
+		log.Printf("Reply %s to contract %d may not %s: %v", messageID, contractID, command, err)
+		return result, nil
+	}
+	result.Command = command
+	if err := p.run(ctx, contractID, party, command); err != nil {
+		log.Printf("Reply %s to contract %d could not %s: %v", messageID, contractID, command, err)
+	}
+	return result, nil
+}
+
+// Finds our reply address among the message's recipients
+func (p *Processor) recipient(header mail.Header) (int, string, bool) {
+	for _, key := range []string{"To", "Cc", "Delivered-To"} {
+		addresses, err := header.AddressList(key)
+		if err != nil {
+			continue
+		}
+		for _, address := range addresses {
+			if contractID, party, ok := p.Replies.Parse(address.Address); ok {
+				return contractID, party, true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// Checks that a reply came from the party its reply address was issued to
+func checkSender(contractID int, party, from string) error {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return reject("unknown contract %d", contractID)
+	}
+	client, err := db.GetClientByID(db.DB, contract.ClientID)
+	if err != nil {
+		return err
+	}
+
+	var expected string
+	switch smart_contract.Party(party) {
+	case smart_contract.ClientParty:
+		expected = client.Email
+	case smart_contract.FreelancerParty:
+		user, err := db.GetUserByID(client.UserID)
+		if err != nil {
+			return err
+		}
+		expected = user.Email
+	default:
+		return reject("unknown party %q", party)
+	}
+
+	if !strings.EqualFold(from, expected) {
+		return reject("%s is not the %s on contract %d", from, party, contractID)
+	}
+	return nil
+}
+
+// Returns the command a reply starts with, or "" if its first line isn't one
+func ParseCommand(body string) string {
+	first, _, _ := strings.Cut(body, "\n")
+	word := strings.ToLower(strings.Trim(strings.TrimSpace(first), ".!"))
+	return commands[word]
+}
+
+func (p *Processor) run(ctx context.Context, contractID int, party, command string) error {
+	switch command {
+	case ConfirmCommand:
+		if smart_contract.Party(party) != smart_contract.ClientParty {
+			return fmt.Errorf("only the client can confirm a contract")
+		}
+		source, err := p.Rates()
+		if err != nil {
+			return err
+		}
+		return smart_contract.ConfirmContract(ctx, contractID, source, p.PayIn, p.QuoteTTL)
+	case DisputeCommand:
+		return smart_contract.UpdateContractStatus(contractID, smart_contract.Disputed)
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
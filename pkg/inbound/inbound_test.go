@@ -0,0 +1,117 @@
+package inbound
+
+import (
+	"net/mail"
+	"path/filepath"
+	"testing"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"confirm", ConfirmCommand},
+		{"Confirm.", ConfirmCommand},
+		{"  CONFIRM!  \nThanks for the quick turnaround", ConfirmCommand},
+		{"Confirmar", ConfirmCommand},
+		{"dispute\nThe logo isn't what we agreed", DisputeCommand},
+		{"Disputar!", DisputeCommand},
+		{"I confirm", ""},
+		{"confirm the logo", ""},
+		{"Thanks\nconfirm", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := ParseCommand(test.body); got != test.want {
+			t.Errorf("ParseCommand(%q) = %q, want %q", test.body, got, test.want)
+		}
+	}
+}
+
+func TestRecipient(t *testing.T) {
+	replies := &email.ReplyAddresses{Domain: "reply.tronch.io", Secret: []byte("secret")}
+	other := &email.ReplyAddresses{Domain: "reply.tronch.io", Secret: []byte("other")}
+	processor := &Processor{Replies: replies}
+	address := replies.For(42, "client")
+
+	tests := []struct {
+		name     string
+		header   mail.Header
+		contract int
+		party    string
+		ok       bool
+	}{
+		{"to", mail.Header{"To": {address}}, 42, "client", true},
+		{"display name", mail.Header{"To": {"tronch <" + address + ">"}}, 42, "client", true},
+		{"among several recipients", mail.Header{"To": {"cleo@example.com, " + replies.For(7, "freelancer")}}, 7, "freelancer", true},
+		{"cc", mail.Header{"To": {"cleo@example.com"}, "Cc": {address}}, 42, "client", true},
+		{"delivered to", mail.Header{"To": {"undisclosed-recipients:;"}, "Delivered-To": {address}}, 42, "client", true},
+		{"upper case", mail.Header{"To": {"REPLY+42.CLIENT." + address[len("reply+42.client."):]}}, 42, "client", true},
+		{"signed with another secret", mail.Header{"To": {other.For(42, "client")}}, 0, "", false},
+		{"party changed", mail.Header{"To": {"reply+42.freelancer." + address[len("reply+42.client."):]}}, 0, "", false},
+		{"other domain", mail.Header{"To": {"reply+42.client." + address[len("reply+42.client."):len(address)-len("reply.tronch.io")] + "attacker.test"}}, 0, "", false},
+		{"not a reply address", mail.Header{"To": {"support@reply.tronch.io"}}, 0, "", false},
+		{"no recipients", mail.Header{}, 0, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contractID, party, ok := processor.recipient(test.header)
+			if ok != test.ok || contractID != test.contract || party != test.party {
+				t.Errorf("recipient = %d %q %v, want %d %q %v", contractID, party, ok, test.contract, test.party, test.ok)
+			}
+		})
+	}
+}
+
+func TestCheckSender(t *testing.T) {
+	if err := db.Initialize(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+	if err := db.CreateUser(db.User{FirstName: "Fran", Email: "fran@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := db.GetUserByEmail("fran@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &db.Client{UserID: user.ID, Name: "Cleo", Email: "cleo@example.com"}
+	if err := db.CreateClient(db.DB, client); err != nil {
+		t.Fatal(err)
+	}
+	contractID, err := db.CreateContract(&db.Contract{ClientID: client.ID, Description: "logo design", Status: "payment_made"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		contract int
+		party    string
+		from     string
+		ok       bool
+	}{
+		{"client", contractID, "client", "cleo@example.com", true},
+		{"client in another case", contractID, "client", "Cleo@Example.com", true},
+		{"freelancer", contractID, "freelancer", "fran@example.com", true},
+		{"freelancer on the client's address", contractID, "client", "fran@example.com", false},
+		{"stranger", contractID, "client", "mallory@example.com", false},
+		{"admin", contractID, "admin", "fran@example.com", false},
+		{"unknown contract", contractID + 1, "client", "cleo@example.com", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkSender(test.contract, test.party, test.from)
+			if test.ok && err != nil {
+				t.Errorf("rejected: %v", err)
+			}
+			if !test.ok && !IsRejected(err) {
+				t.Errorf("got %v, want the reply rejected", err)
+			}
+		})
+	}
+}
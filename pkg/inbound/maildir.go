@@ -0,0 +1,71 @@
+package inbound
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Processes messages delivered to a maildir's new/ folder every interval until the context is cancelled
+func (p *Processor) WatchMaildir(ctx context.Context, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.ProcessMaildir(ctx, dir); err != nil {
+			log.Printf("Error processing maildir %s: %v", dir, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Processes each new message in a maildir, moving it to cur/ once it was handled, and returns how many were
+func (p *Processor) ProcessMaildir(ctx context.Context, dir string) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read maildir: %v", err)
+	}
+
+	processed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, "new", entry.Name())
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Error reading %s: %v", path, err)
+			continue
+		}
+
+		result, err := p.Process(ctx, raw)
+		switch {
+		case IsRejected(err):
+			// Rejected mail would be rejected again, so it's filed away rather than retried
+			log.Printf("Skipping %s: %v", entry.Name(), err)
+		case err != nil:
+			log.Printf("Error processing %s, will retry: %v", entry.Name(), err)
+			continue
+		case result.Added:
+			log.Printf("Added reply %s to contract %d", entry.Name(), result.Comment.ContractID)
+		case len(result.Bounces) > 0:
+			log.Printf("Recorded %d bounces from %s", len(result.Bounces), entry.Name())
+		}
+
+		// Mark the message seen, following the maildir convention of an info suffix in cur/
+		if err := os.Rename(path, filepath.Join(dir, "cur", entry.Name()+":2,S")); err != nil {
+			return processed, fmt.Errorf("failed to move %s to cur: %v", entry.Name(), err)
+		}
+		processed++
+	}
+	return processed, nil
+}
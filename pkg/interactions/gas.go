@@ -0,0 +1,160 @@
+package interactions
+
+import (
+  "context"
+  "fmt"
+  "math/big"
+  "sort"
+
+  "github.com/ethereum/go-ethereum/core/types"
+  "smart_contract/pkg/chain"
+  "smart_contract/pkg/db"
+)
+
+const (
+  feeHistoryBlocks = 20 // Recent blocks the priority fee is picked from
+  tipPercentile    = 50 // Percentile of the tips paid in each of those blocks
+)
+
+// Picks gas limits and EIP-1559 fees within a network's gas policy, and keeps the gas paid for each contract within
+// the network fee quoted for it
+type gasPolicy struct {
+  backend Backend
+  network chain.Network
+}
+
+// The fees a transaction offers per unit of gas, along with the base fee they were picked against
+type gasFees struct {
+  Tip     *big.Int // maxPriorityFeePerGas
+  FeeCap  *big.Int // maxFeePerGas
+  BaseFee *big.Int // nil on chains without EIP-1559
+}
+
+// Returns the fees to offer: the median tip paid in recent blocks, and room for the base fee to double,
+// both capped by the network's policy. Fails when the base fee alone is already above what we are willing to pay
+func (g *gasPolicy) fees(ctx context.Context) (*gasFees, error) {
+  history, err := g.backend.FeeHistory(ctx, feeHistoryBlocks, nil, []float64{tipPercentile})
+  if err != nil {
+    return nil, fmt.Errorf("failed to get fee history: %v", err)
+  }
+  // The last base fee is the one the next block will charge
+  if len(history.BaseFee) == 0 || history.BaseFee[len(history.BaseFee)-1].Sign() == 0 {
+    return &gasFees{}, nil
+  }
+  baseFee := history.BaseFee[len(history.BaseFee)-1]
+
+  var tips []*big.Int
+  for _, rewards := range history.Reward {
+    if len(rewards) > 0 && rewards[0].Sign() > 0 {
+      tips = append(tips, rewards[0])
+    }
+  }
+  var tip *big.Int
+  if len(tips) > 0 {
+    sort.Slice(tips, func(a, b int) bool { return tips[a].Cmp(tips[b]) < 0 })
+    tip = new(big.Int).Set(tips[len(tips)/2])
+  } else if tip, err = g.backend.SuggestGasTipCap(ctx); err != nil {
+    return nil, fmt.Errorf("failed to suggest gas tip: %v", err)
+  }
+
+  policy := g.network.Gas
+  if limit := policy.MaxPriorityFeePerGas.Int; limit != nil && tip.Cmp(limit) > 0 {
+    tip = new(big.Int).Set(limit)
+  }
+  feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+  if limit := policy.MaxFeePerGas.Int; limit != nil && feeCap.Cmp(limit) > 0 {
+    if baseFee.Cmp(limit) >= 0 {
+      return nil, fmt.Errorf("base fee of %s wei per gas exceeds the %s network's maximum of %s", baseFee, g.network.Name, limit)
+    }
+    feeCap = new(big.Int).Set(limit)
+  }
+  if tip.Cmp(feeCap) > 0 {
+    tip = new(big.Int).Set(feeCap)
+  }
+  return &gasFees{Tip: tip, FeeCap: feeCap, BaseFee: baseFee}, nil
+}
+
+// Returns the gas limit to send with for an estimate, with the network's headroom added
+func (g *gasPolicy) gasLimit(estimate uint64) uint64 {
+  if g.network.Gas.GasLimitMultiplier <= 1 {
+    return estimate
+  }
+  return uint64(float64(estimate) * g.network.Gas.GasLimitMultiplier)
+}
+
+// Returns a copy of an unsigned transaction with the gas limit raised by the network's headroom, refusing fees
+// above the network's caps
+func (g *gasPolicy) prepare(tx *types.Transaction) (*types.Transaction, error) {
+  if err := g.checkFeeCap(tx.GasFeeCap()); err != nil {
+    return nil, err
+  }
+  gas := g.gasLimit(tx.Gas())
+  switch tx.Type() {
+  case types.DynamicFeeTxType:
+    return types.NewTx(&types.DynamicFeeTx{
+      ChainID: tx.ChainId(), Nonce: tx.Nonce(), GasTipCap: tx.GasTipCap(), GasFeeCap: tx.GasFeeCap(), Gas: gas,
+      To: tx.To(), Value: tx.Value(), Data: tx.Data(), AccessList: tx.AccessList(),
+    }), nil
+  case types.LegacyTxType:
+    return types.NewTx(&types.LegacyTx{
+      Nonce: tx.Nonce(), GasPrice: tx.GasPrice(), Gas: gas, To: tx.To(), Value: tx.Value(), Data: tx.Data(),
+    }), nil
+  }
+  return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+}
+
+// Refuses fees above the network's gas policy
+func (g *gasPolicy) checkFeeCap(feeCap *big.Int) error {
+  if limit := g.network.Gas.MaxFeePerGas.Int; limit != nil && feeCap.Cmp(limit) > 0 {
+    return fmt.Errorf("fee of %s wei per gas exceeds the %s network's maximum of %s", feeCap, g.network.Name, limit)
+  }
+  return nil
+}
+
+// Refuses a transaction for a contract whose expected cost, its gas limit at the current base fee plus its tip,
+// would take the gas paid for the contract over the network fee quoted for it. Contracts quoted without a network
+// fee have no budget, and those whose network fee is in another currency than the network's are refused, since
+// their gas can't be checked against it
+func (g *gasPolicy) checkBudget(contractID int, tx *types.Transaction, baseFee *big.Int) error {
+  if contractID == 0 {
+    return nil
+  }
+  c, err := db.GetContractByID(contractID)
+  if err != nil {
+    return err
+  }
+  budget := c.Fees.NetworkFee
+  if budget.Units == nil || budget.Sign() <= 0 {
+    return nil
+  }
+  if budget.Currency.Code != g.network.Currency {
+    return fmt.Errorf("contract %d's network fee is quoted in %s, so gas paid in %s on the %s network can't be budgeted",
+      contractID, budget.Currency.Code, g.network.Currency, g.network.Name)
+  }
+
+  price := tx.GasPrice()
+  if baseFee != nil && tx.Type() == types.DynamicFeeTxType {
+    if effective := new(big.Int).Add(baseFee, tx.GasTipCap()); effective.Cmp(tx.GasFeeCap()) < 0 {
+      price = effective
+    }
+  }
+  cost := new(big.Int).Mul(price, new(big.Int).SetUint64(tx.Gas()))
+
+  spent := big.NewInt(0)
+  if c.NetworkFeeSpent.Units != nil {
+    spent = c.NetworkFeeSpent.Units
+  }
+  if total := new(big.Int).Add(spent, cost); total.Cmp(budget.Units) > 0 {
+    return fmt.Errorf("transaction would cost about %s wei, taking contract %d's network fees to %s wei, over its budget of %s",
+      cost, contractID, total, budget.Units)
+  }
+  return nil
+}
+
+// Returns what a mined transaction paid for its gas
+func receiptFee(receipt *types.Receipt) *big.Int {
+  if receipt.EffectiveGasPrice == nil {
+    return nil
+  }
+  return new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+}
@@ -0,0 +1,179 @@
+//go:build integration
+
+package interactions
+
+import (
+  "context"
+  "math/big"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/ethereum/go-ethereum/common"
+  "github.com/ethereum/go-ethereum/params"
+
+  "smart_contract/pkg/chain"
+  "smart_contract/pkg/db"
+  "smart_contract/pkg/fees"
+  "smart_contract/pkg/money"
+)
+
+// Creates a contract quoted with the given network fee in wei, which budgets the gas paid for it
+func contractWithNetworkFee(t *testing.T, wei int64) int {
+  t.Helper()
+  eth, err := money.LookupCurrency("ETH")
+  if err != nil {
+    t.Fatal(err)
+  }
+  id, err := db.CreateContract(&db.Contract{Description: "logo design", Status: "Confirmed"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  gross := money.New(big.NewInt(params.Ether), eth)
+  breakdown := fees.Breakdown{Gross: gross, PlatformFee: money.Zero(eth), NetworkFee: money.New(big.NewInt(wei), eth), Net: gross}
+  if err := db.SaveContractFees(id, breakdown); err != nil {
+    t.Fatal(err)
+  }
+  return id
+}
+
+func TestRecordsGasSpentOnContract(t *testing.T) {
+  useTestDB(t)
+  backend, signer := newSimulatedChain(t)
+  mineInBackground(t, backend)
+  network := simulatedNetwork()
+  network.Gas.GasLimitMultiplier = 1.5
+  m := txManagerFor(backend.Client(), network, signer)
+  m.pollInterval = 10 * time.Millisecond
+  ctx := context.Background()
+
+  contractID := contractWithNetworkFee(t, params.Ether/100)
+  recipient := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  record, err := m.Submit(ctx, contractID, "transfer", transfer(recipient, big.NewInt(1000), big.NewInt(params.GWei)))
+  if err != nil {
+    t.Fatal(err)
+  }
+  receipt, err := m.Wait(ctx, record.Hash)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  tx, _, err := backend.Client().TransactionByHash(ctx, receipt.TxHash)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if tx.Gas() != 31500 {
+    t.Errorf("gas limit = %d, want 21000 with 50%% headroom", tx.Gas())
+  }
+
+  paid := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+  c, err := db.GetContractByID(contractID)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if c.NetworkFeeSpent.Units.Cmp(paid) != 0 || c.NetworkFeeSpent.Currency.Code != network.Currency {
+    t.Errorf("contract spent %s on gas, want %s wei in %s", c.NetworkFeeSpent, paid, network.Currency)
+  }
+  final, err := db.GetChainTransaction(record.ID)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if final.GasUsed != 21000 || final.Fee == nil || final.Fee.Cmp(paid) != 0 {
+    t.Errorf("recorded %d gas costing %v, want 21000 costing %s", final.GasUsed, final.Fee, paid)
+  }
+}
+
+func TestRefusesTransactionsOverBudget(t *testing.T) {
+  useTestDB(t)
+  backend, signer := newSimulatedChain(t)
+  mineInBackground(t, backend)
+  m := txManagerFor(backend.Client(), simulatedNetwork(), signer)
+  ctx := context.Background()
+
+  // Far less than a transfer costs at a one gwei tip
+  contractID := contractWithNetworkFee(t, 1000)
+  recipient := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  _, err := m.Submit(ctx, contractID, "transfer", transfer(recipient, big.NewInt(1000), big.NewInt(params.GWei)))
+  if err == nil || !strings.Contains(err.Error(), "over its budget") {
+    t.Fatalf("submitting returned %v, want the budget to be exceeded", err)
+  }
+
+  // The refused transaction did not use up a nonce
+  if next, err := db.GetNextChainNonce(signer.Address().Hex()); err != nil || next != 0 {
+    t.Errorf("next recorded nonce = %d (%v), want 0", next, err)
+  }
+}
+
+func TestRefusesTransactionsWithoutBudgetInNetworkCurrency(t *testing.T) {
+  useTestDB(t)
+  backend, signer := newSimulatedChain(t)
+  mineInBackground(t, backend)
+  m := txManagerFor(backend.Client(), simulatedNetwork(), signer)
+  ctx := context.Background()
+
+  // A network fee quoted in MATIC says nothing about how much ETH may be spent on gas
+  contractID := contractWithNetworkFee(t, params.Ether/100)
+  gross := money.New(big.NewInt(params.Ether), money.MATIC)
+  breakdown := fees.Breakdown{Gross: gross, PlatformFee: money.Zero(money.MATIC), NetworkFee: money.New(big.NewInt(params.Ether/100), money.MATIC), Net: gross}
+  if err := db.SaveContractFees(contractID, breakdown); err != nil {
+    t.Fatal(err)
+  }
+  recipient := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  _, err := m.Submit(ctx, contractID, "transfer", transfer(recipient, big.NewInt(1000), big.NewInt(params.GWei)))
+  if err == nil || !strings.Contains(err.Error(), "can't be budgeted") {
+    t.Fatalf("submitting returned %v, want the transaction refused", err)
+  }
+  if next, err := db.GetNextChainNonce(signer.Address().Hex()); err != nil || next != 0 {
+    t.Errorf("next recorded nonce = %d (%v), want 0", next, err)
+  }
+
+  // Contracts quoted without a network fee aren't budgeted at all
+  breakdown.NetworkFee = money.Zero(money.MATIC)
+  if err := db.SaveContractFees(contractID, breakdown); err != nil {
+    t.Fatal(err)
+  }
+  if _, err := m.Submit(ctx, contractID, "transfer", transfer(recipient, big.NewInt(1000), big.NewInt(params.GWei))); err != nil {
+    t.Errorf("submitting without a network fee returned %v", err)
+  }
+}
+
+func TestRefusesFeesOverNetworkCap(t *testing.T) {
+  useTestDB(t)
+  backend, signer := newSimulatedChain(t)
+  mineInBackground(t, backend)
+  network := simulatedNetwork()
+  network.Gas = chain.GasPolicy{MaxFeePerGas: chain.Wei{Int: big.NewInt(params.GWei)}}
+  m := txManagerFor(backend.Client(), network, signer)
+  ctx := context.Background()
+
+  recipient := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  _, err := m.Submit(ctx, 0, "transfer", transfer(recipient, big.NewInt(1000), big.NewInt(params.GWei)))
+  if err == nil || !strings.Contains(err.Error(), "maximum") {
+    t.Fatalf("submitting returned %v, want the fee cap to be exceeded", err)
+  }
+}
+
+func TestFeesStayWithinPolicy(t *testing.T) {
+  backend, _ := newSimulatedChain(t)
+  mineInBackground(t, backend)
+  network := simulatedNetwork()
+  network.Gas = chain.GasPolicy{
+    MaxFeePerGas:         chain.Wei{Int: big.NewInt(50 * params.GWei)},
+    MaxPriorityFeePerGas: chain.Wei{Int: big.NewInt(params.GWei / 2)},
+  }
+  g := &gasPolicy{backend: backend.Client(), network: network}
+
+  fees, err := g.fees(context.Background())
+  if err != nil {
+    t.Fatal(err)
+  }
+  if fees.BaseFee == nil {
+    t.Fatal("no base fee on a London chain")
+  }
+  if fees.Tip.Cmp(network.Gas.MaxPriorityFeePerGas.Int) > 0 || fees.FeeCap.Cmp(network.Gas.MaxFeePerGas.Int) > 0 {
+    t.Errorf("picked tip %s and fee cap %s, over the policy's caps", fees.Tip, fees.FeeCap)
+  }
+  if fees.FeeCap.Cmp(fees.BaseFee) <= 0 {
+    t.Errorf("fee cap %s does not cover base fee %s", fees.FeeCap, fees.BaseFee)
+  }
+}
@@ -2,122 +2,157 @@ package interactions
 
 import (
   "context"
+  "fmt"
   "log"
   "math/big"
+  "strings"
 
+  "github.com/ethereum/go-ethereum"
+  "github.com/ethereum/go-ethereum/accounts/abi"
   "github.com/ethereum/go-ethereum/accounts/abi/bind"
   "github.com/ethereum/go-ethereum/common"
-  "github.com/ethereum/go-ethereum/crypto"
-  "github.com/ethereum/go-ethereum/ethclient"
-  "smart_contract/pkg/smart-contract/contract" // Import the compiled contract binding
+  "github.com/ethereum/go-ethereum/core/types"
+  "smart_contract/pkg/chain"
+  "smart_contract/pkg/db"
+  "smart_contract/pkg/money"
+  "smart_contract/pkg/smart-contract/contract" // Generated bindings, see cmd/bindgen
 )
 
 // Should be the address where your deployed contract resides
 var ContractAddress = common.HexToAddress("YOUR_CONTRACT_ADDRESS_HERE")
 
+// The node API the Interactor uses, satisfied by *ethclient.Client and by the client of go-ethereum's simulated backend
+type Backend interface {
+  bind.ContractBackend
+  bind.DeployBackend
+  ethereum.BlockNumberReader
+  ethereum.ChainStateReader
+  ethereum.FeeHistoryReader
+  ethereum.TransactionReader
+}
+
 // Provides functionalities to trigger interactions with the smart contract
 type Interactor struct {
-  ethClient *ethclient.Client
+  ethClient Backend
+  network   chain.Network // The chain the client is connected to, see chain.Dial
+  signer    Signer        // Signs every transaction the Interactor sends
+  txs       *TxManager    // Assigns nonces to, and follows, the signer's transactions
 }
 
-// Creates a new instance of Interactor
-func NewInteractor(nodeURL string) (*Interactor, error) {
-  client, err := ethclient.Dial(nodeURL)
-  if err != nil {
-    return nil, err
+// Creates a new instance of Interactor sending transactions over client, e.g. from chain.Dial, signed by signer
+func NewInteractor(client Backend, network chain.Network, signer Signer) (*Interactor, error) {
+  if signer == nil {
+    return nil, fmt.Errorf("a signer is required")
   }
   return &Interactor{
     ethClient: client,
+    network:   network,
+    signer:    signer,
+    txs:       txManagerFor(client, network, signer),
   }, nil
 }
 
-// Creates a new transactor
-func bindNewTransactor() (*bind.TransactOpts, error) {
-  privateKey, err := crypto.HexToECDSA("YOUR_PRIVATE_KEY_HERE")
+// Sends a transaction to an escrow contract through the signer's queue, paying its gas from the contract's
+// network fee budget, and waits until it is final
+func (i *Interactor) send(ctx context.Context, contractAddress, description string, build func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Receipt, error) {
+  contractID, err := db.GetContractIDByAddress(common.HexToAddress(contractAddress).Hex())
   if err != nil {
     return nil, err
   }
-
-  auth := bind.NewKeyedTransactor(privateKey)
-  return auth, nil
-}
-
-
-
-// Triggers the deployment and execution of the generated smart contract
-func (i *Interactor) ExecuteContract(ctx context.Context, contractCodeFilePath string) (string, error) {
-  // Read the contract code from the provided file path
-  contractCode, err := os.ReadFile(contractCodeFilePath)
+  record, err := i.txs.Submit(ctx, contractID, description+" on "+contractAddress, build)
   if err != nil {
-    return "", fmt.Errorf("failed to read contract code: %v", err)
+    return nil, err
   }
+  return i.txs.Wait(ctx, record.Hash)
+}
 
-  // Initialize the Ethereum client
-  client, err := ethclient.Dial("https://polygon-mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID")
-  if err != nil {
-    return "", fmt.Errorf("failed to connect to Ethereum client: %v", err)
-  }
+// Follows transactions left pending by a previous run to a final status
+func (i *Interactor) Resume(ctx context.Context) error {
+  return i.txs.Resume(ctx)
+}
 
-  // Admin address and private key (this should be stored securely and not hardcoded)
-  adminAddress := common.HexToAddress("YOUR_ADMIN_ADDRESS")
-  adminPrivateKey, err := crypto.HexToECDSA("YOUR_ADMIN_PRIVATE_KEY")
+// Sends a transaction deploying a compiled contract with the given constructor arguments and returns its hash without waiting for it.
+// The gas is paid from the network fee budget of the contract with contractID, if it is not 0
+func (i *Interactor) Deploy(ctx context.Context, contractID int, artifact contract.Artifact, args ...interface{}) (string, error) {
+  if artifact.Bin == "" {
+    return "", fmt.Errorf("%s has no bytecode, run go generate ./pkg/smart-contract/contract with solc installed", artifact.Name)
+  }
+  parsed, err := abi.JSON(strings.NewReader(artifact.ABI))
   if err != nil {
-    return "", fmt.Errorf("failed to parse private key: %v", err)
+    return "", fmt.Errorf("failed to parse contract ABI: %v", err)
   }
 
-  // Deploy the smart contract
-  auth := bind.NewKeyedTransactor(adminPrivateKey)
-  contractAddress, _, _, err := deployContract(auth, client, contractCode)
+  // Constructor arguments are encoded against the artifact's ABI, so a mismatch fails before anything is sent
+  var address common.Address
+  record, err := i.txs.Submit(ctx, contractID, "deploy "+artifact.Name, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    deployed, tx, _, err := bind.DeployContract(opts, parsed, common.FromHex(artifact.Bin), i.ethClient, args...)
+    address = deployed
+    return tx, err
+  })
   if err != nil {
     return "", fmt.Errorf("failed to deploy contract: %v", err)
   }
 
-  log.Printf("Smart contract deployed at address: %s", contractAddress.Hex())
+  log.Printf("Deploying %s to %s. Transaction hash: %s", artifact.Name, address.Hex(), record.Hash)
 
-  // Execute the smart contract
-  contract, err := NewEscrowService(contractAddress, client)
-  if err != nil {
-    return "", fmt.Errorf("failed to instantiate smart contract: %v", err)
-  }
+  return record.Hash, nil
+}
 
-  // Call the executeContract function
-  tx, err := contract.ExecuteContract(&bind.TransactOpts{
-    From: adminAddress,
-    Signer: func(signer bind.SignerFn, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-      return types.SignTx(tx, types.NewEIP155Signer(big.NewInt(137)), adminPrivateKey)
-    },
-  })
+// Waits until a deployment transaction is confirmed and returns where the contract was deployed
+func (i *Interactor) WaitDeployed(ctx context.Context, txHash string) (*contract.Deployment, error) {
+  // A replacement deploys to the same address, so whichever version is mined will do
+  receipt, err := i.txs.Wait(ctx, txHash)
   if err != nil {
-    return "", fmt.Errorf("failed to execute contract: %v", err)
+    return nil, fmt.Errorf("failed to wait for deployment to be mined: %v", err)
+  }
+  if receipt.ContractAddress == (common.Address{}) {
+    return nil, fmt.Errorf("transaction %s is not a contract deployment", txHash)
   }
 
-  log.Printf("Executing contract. Transaction hash: %s", tx.Hash().Hex())
-
-  // Wait for the transaction to be mined
-  receipt, err := bind.WaitMined(ctx, client, tx)
+  code, err := i.ethClient.CodeAt(ctx, receipt.ContractAddress, nil)
   if err != nil {
-    return "", fmt.Errorf("failed to wait for transaction to be mined: %v", err)
+    return nil, fmt.Errorf("failed to get deployed code: %v", err)
+  }
+  if len(code) == 0 {
+    return nil, bind.ErrNoCodeAfterDeploy
   }
 
-  log.Printf("Transaction mined. Receipt: %v", receipt)
+  log.Printf("Smart contract deployed at address: %s", receipt.ContractAddress.Hex())
 
-  return contractAddress.Hex(), nil
+  return &contract.Deployment{
+    Address:     receipt.ContractAddress.Hex(),
+    TxHash:      receipt.TxHash.Hex(),
+    BlockNumber: receipt.BlockNumber.Uint64(),
+  }, nil
 }
 
-// Deploys the smart contract to the Polygon chain
-func deployContract(auth *bind.TransactOpts, client *ethclient.Client, contractCode []byte) (common.Address, *types.Transaction, *EscrowService, error) {
-  // Compile and deploy the contract
-  parsedContract, err := solidity.ABI("EscrowService", string(contractCode))
+// Triggers the execution of a deployed escrow contract, after which it accepts deposits; does nothing if it was already executed
+func (i *Interactor) ExecuteContract(ctx context.Context, contractAddress string) error {
+  // Load the smart contract
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
+  if err != nil {
+    return fmt.Errorf("failed to instantiate smart contract: %v", err)
+  }
+
+  executed, err := escrow.IsExecuted(&bind.CallOpts{Context: ctx})
   if err != nil {
-    return common.Address{}, nil, nil, fmt.Errorf("failed to parse contract ABI: %v", err)
+    return fmt.Errorf("failed to check whether the contract was executed: %v", err)
+  }
+  if executed {
+    return nil
   }
 
-  address, tx, contract, err := bind.DeployContract(auth, parsedContract, client, parsedContract.Constructor.Inputs[0].Args[0].Value.(*bind.TransactOpts).From)
+  // Call the executeContract function
+  receipt, err := i.send(ctx, contractAddress, "executeContract", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    return escrow.ExecuteContract(opts)
+  })
   if err != nil {
-    return common.Address{}, nil, nil, fmt.Errorf("failed to deploy contract: %v", err)
+    return fmt.Errorf("failed to execute contract: %v", err)
   }
 
-  return address, tx, contract, nil
+  log.Printf("Transaction mined. Receipt: %v", receipt)
+
+  return nil
 }
 
 
@@ -128,44 +163,20 @@ func (i *Interactor) MarkRequirementsComplete(ctx context.Context, contractAddre
   // Implement interaction to mark requirements as complete
   log.Println("Marking requirements as complete...")
 
-  // Initialize the Ethereum client
-  client, err := ethclient.Dial("https://polygon-mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID")
-  if err != nil {
-    return fmt.Errorf("failed to connect to Ethereum client: %v", err)
-  }
-
   // Load the smart contract
-  contract, err := NewEscrowService(common.HexToAddress(contractAddress), client)
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
   if err != nil {
     return fmt.Errorf("failed to instantiate smart contract: %v", err)
   }
 
-  // Admin address and private key (this should be stored securely and not hardcoded)
-  adminAddress := common.HexToAddress("YOUR_ADMIN_ADDRESS")
-  adminPrivateKey, err := crypto.HexToECDSA("YOUR_ADMIN_PRIVATE_KEY")
-  if err != nil {
-    return fmt.Errorf("failed to parse private key: %v", err)
-  }
-
   // Call the markRequirementsComplete function
-  tx, err := contract.MarkRequirementsComplete(&bind.TransactOpts{
-    From: adminAddress,
-    Signer: func(signer bind.SignerFn, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-      return types.SignTx(tx, types.NewEIP155Signer(big.NewInt(137)), adminPrivateKey)
-    },
+  receipt, err := i.send(ctx, contractAddress, "markRequirementsComplete", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    return escrow.MarkRequirementsComplete(opts)
   })
   if err != nil {
     return fmt.Errorf("failed to mark requirements as complete: %v", err)
   }
 
-  log.Printf("Marking requirements as complete. Transaction hash: %s", tx.Hash().Hex())
-
-  // Wait for the transaction to be mined
-  receipt, err := bind.WaitMined(ctx, client, tx)
-  if err != nil {
-    return fmt.Errorf("failed to wait for transaction to be mined: %v", err)
-  }
-
   log.Printf("Transaction mined. Receipt: %v", receipt)
 
   // Now, let's confirm the requirements
@@ -177,47 +188,22 @@ func (i *Interactor) MarkRequirementsComplete(ctx context.Context, contractAddre
 
 // Triggers the interaction for the buyer to confirm the requirements
 func (i *Interactor) ConfirmReqs(ctx context.Context, contractAddress string) error {
-  // TODO: Implement interaction for buyer to confirm requirements
   log.Println("Confirming requirements...")
 
-  // Initialize the Ethereum client
-  client, err := ethclient.Dial("https://polygon-mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID")
-  if err != nil {
-    return fmt.Errorf("failed to connect to Ethereum client: %v", err)
-  }
-
   // Load the smart contract
-  contract, err := NewEscrowService(common.HexToAddress(contractAddress), client)
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
   if err != nil {
     return fmt.Errorf("failed to instantiate smart contract: %v", err)
   }
 
-  // Admin address and private key (this should be stored securely and not hardcoded)
-  adminAddress := common.HexToAddress("YOUR_ADMIN_ADDRESS")
-  adminPrivateKey, err := crypto.HexToECDSA("YOUR_ADMIN_PRIVATE_KEY")
-  if err != nil {
-    return fmt.Errorf("failed to parse private key: %v", err)
-  }
-
   // Call the confirmReqs function
-  tx, err := contract.ConfirmReqs(&bind.TransactOpts{
-    From: adminAddress,
-    Signer: func(signer bind.SignerFn, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-      return types.SignTx(tx, types.NewEIP155Signer(big.NewInt(137)), adminPrivateKey)
-    },
+  receipt, err := i.send(ctx, contractAddress, "confirmReqs", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    return escrow.ConfirmReqs(opts)
   })
   if err != nil {
     return fmt.Errorf("failed to confirm requirements: %v", err)
   }
 
-  log.Printf("Confirming requirements. Transaction hash: %s", tx.Hash().Hex())
-
-  // Wait for the transaction to be mined
-  receipt, err := bind.WaitMined(ctx, client, tx)
-  if err != nil {
-    return fmt.Errorf("failed to wait for transaction to be mined: %v", err)
-  }
-
   log.Printf("Transaction mined. Receipt: %v", receipt)
 
   return nil
@@ -227,47 +213,22 @@ func (i *Interactor) ConfirmReqs(ctx context.Context, contractAddress string) er
 
 // Triggers the interaction to initiate a dispute
 func (i *Interactor) InitiateDispute(ctx context.Context, contractAddress string) error {
-  // TODO: Implement interaction to initiate a dispute
   log.Println("Initiating dispute...")
 
-  // Initialize the Ethereum client
-  client, err := ethclient.Dial("https://polygon-mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID")
-  if err != nil {
-    return fmt.Errorf("failed to connect to Ethereum client: %v", err)
-  }
-
   // Load the smart contract
-  contract, err := NewEscrowService(common.HexToAddress(contractAddress), client)
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
   if err != nil {
     return fmt.Errorf("failed to instantiate smart contract: %v", err)
   }
 
-  // Admin address and private key (this should be stored securely and not hardcoded)
-  adminAddress := common.HexToAddress("YOUR_ADMIN_ADDRESS")
-  adminPrivateKey, err := crypto.HexToECDSA("YOUR_ADMIN_PRIVATE_KEY")
-  if err != nil {
-    return fmt.Errorf("failed to parse private key: %v", err)
-  }
-
   // Call the initiateDispute function
-  tx, err := contract.InitiateDispute(&bind.TransactOpts{
-    From: adminAddress,
-    Signer: func(signer bind.SignerFn, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-      return types.SignTx(tx, types.NewEIP155Signer(big.NewInt(137)), adminPrivateKey)
-    },
+  receipt, err := i.send(ctx, contractAddress, "initiateDispute", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    return escrow.InitiateDispute(opts)
   })
   if err != nil {
     return fmt.Errorf("failed to initiate dispute: %v", err)
   }
 
-  log.Printf("Initiating dispute. Transaction hash: %s", tx.Hash().Hex())
-
-  // Wait for the transaction to be mined
-  receipt, err := bind.WaitMined(ctx, client, tx)
-  if err != nil {
-    return fmt.Errorf("failed to wait for transaction to be mined: %v", err)
-  }
-
   log.Printf("Transaction mined. Receipt: %v", receipt)
 
   return nil
@@ -277,45 +238,46 @@ func (i *Interactor) InitiateDispute(ctx context.Context, contractAddress string
 
 // Triggers the interaction to resolve a dispute
 func (i *Interactor) ResolveDispute(ctx context.Context, contractAddress string, resolution string) error {
-  // TODO: Implement interaction to resolve a dispute
   log.Printf("Resolving dispute with resolution: %s...", resolution)
 
-  // Initialize the Ethereum client
-  client, err := ethclient.Dial("https://polygon-mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID")
-  if err != nil {
-    return fmt.Errorf("failed to connect to Ethereum client: %v", err)
-  }
-
   // Load the smart contract
-  contract, err := NewEscrowService(common.HexToAddress(contractAddress), client)
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
   if err != nil {
     return fmt.Errorf("failed to instantiate smart contract: %v", err)
   }
 
-  // Admin address and private key (this should be stored securely and not hardcoded)
-  adminAddress := common.HexToAddress("YOUR_ADMIN_ADDRESS")
-  adminPrivateKey, err := crypto.HexToECDSA("YOUR_ADMIN_PRIVATE_KEY")
+  // Call the resolveDispute function
+  receipt, err := i.send(ctx, contractAddress, "resolveDispute", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    return escrow.ResolveDispute(opts, resolution)
+  })
   if err != nil {
-    return fmt.Errorf("failed to parse private key: %v", err)
+    return fmt.Errorf("failed to resolve dispute: %v", err)
   }
 
-  // Call the resolveDispute function
-  tx, err := contract.ResolveDispute(&bind.TransactOpts{
-    From: adminAddress,
-    Signer: func(signer bind.SignerFn, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-      return types.SignTx(tx, types.NewEIP155Signer(big.NewInt(137)), adminPrivateKey)
-    },
-  }, resolution)
+  log.Printf("Transaction mined. Receipt: %v", receipt)
+
+  return nil
+}
+
+
+
+// Triggers the interaction to update the contract's progression
+func (i *Interactor) UpdateContractProgress(ctx context.Context, contractAddress string, progressStatus uint8) error {
+  log.Printf("Updating contract progression to status: %d...", progressStatus)
+
+  // Load the smart contract
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
   if err != nil {
-    return fmt.Errorf("failed to resolve dispute: %v", err)
+    return fmt.Errorf("failed to instantiate smart contract: %v", err)
   }
 
-  log.Printf("Resolving dispute. Transaction hash: %s", tx.Hash().Hex())
 
-  // Wait for the transaction to be mined
-  receipt, err := bind.WaitMined(ctx, client, tx)
+  // Call the updateContractProgress function
+  receipt, err := i.send(ctx, contractAddress, "updateContractProgress", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    return escrow.UpdateContractProgress(opts, progressStatus)
+  })
   if err != nil {
-    return fmt.Errorf("failed to wait for transaction to be mined: %v", err)
+    return fmt.Errorf("failed to update contract progression: %v", err)
   }
 
   log.Printf("Transaction mined. Receipt: %v", receipt)
@@ -325,49 +287,53 @@ func (i *Interactor) ResolveDispute(ctx context.Context, contractAddress string,
 
 
 
-// Triggers the interaction to update the contract's progression
-func (i *Interactor) UpdateContractProgress(ctx context.Context, contractAddress string, progressStatus uint8) error {
-  // TODO: Implement interaction to update contract's progression
-  log.Printf("Updating contract progression to status: %d...", progressStatus)
+// Triggers the interaction to fund the escrow with the contract's payment amount
+func (i *Interactor) InitiateEscrow(ctx context.Context, contractAddress string, amount money.Amount) error {
+  log.Printf("Initiating escrow with %s...", amount)
 
-  // Initialize the Ethereum client
-  client, err := ethclient.Dial("https://polygon-mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID")
-  if err != nil {
-    return fmt.Errorf("failed to connect to Ethereum client: %v", err)
+  // Deposits are made in the chain's native coin, so the amount must already be in wei
+  if amount.Currency.Decimals != 18 {
+    return fmt.Errorf("cannot deposit %s on-chain", amount.Currency.Code)
   }
 
   // Load the smart contract
-  contract, err := NewEscrowService(common.HexToAddress(contractAddress), client)
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
   if err != nil {
     return fmt.Errorf("failed to instantiate smart contract: %v", err)
   }
 
-  // Admin address and private key (this should be stored securely and not hardcoded)
-  adminAddress := common.HexToAddress("YOUR_ADMIN_ADDRESS")
-  adminPrivateKey, err := crypto.HexToECDSA("YOUR_ADMIN_PRIVATE_KEY")
+  // Call the initiateEscrow function, sending the payment amount as value
+  receipt, err := i.send(ctx, contractAddress, "initiateEscrow", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    opts.Value = amount.Units
+    return escrow.InitiateEscrow(opts)
+  })
   if err != nil {
-    return fmt.Errorf("failed to parse private key: %v", err)
+    return fmt.Errorf("failed to initiate escrow: %v", err)
   }
 
-  
+  log.Printf("Transaction mined. Receipt: %v", receipt)
 
-  // Call the updateContractProgress function
-  tx, err := contract.UpdateContractProgress(&bind.TransactOpts{
-    From: adminAddress,
-    Signer: func(signer bind.SignerFn, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-      return types.SignTx(tx, types.NewEIP155Signer(big.NewInt(137)), adminPrivateKey)
-    },
-  }, progressStatus)
+  return nil
+}
+
+
+
+// Triggers the interaction to return the escrowed funds to the client
+func (i *Interactor) Refund(ctx context.Context, contractAddress string) error {
+  log.Println("Refunding escrow...")
+
+  // Load the smart contract
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
   if err != nil {
-    return fmt.Errorf("failed to update contract progression: %v", err)
+    return fmt.Errorf("failed to instantiate smart contract: %v", err)
   }
 
-  log.Printf("Updating contract progression. Transaction hash: %s", tx.Hash().Hex())
-
-  // Wait for the transaction to be mined
-  receipt, err := bind.WaitMined(ctx, client, tx)
+  // Call the refund function
+  receipt, err := i.send(ctx, contractAddress, "refund", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    return escrow.Refund(opts)
+  })
   if err != nil {
-    return fmt.Errorf("failed to wait for transaction to be mined: %v", err)
+    return fmt.Errorf("failed to refund escrow: %v", err)
   }
 
   log.Printf("Transaction mined. Receipt: %v", receipt)
@@ -375,3 +341,47 @@ func (i *Interactor) UpdateContractProgress(ctx context.Context, contractAddress
   return nil
 }
 
+
+
+// Returns what an escrow contract holds on-chain, in base units of the network's native currency
+func (i *Interactor) EscrowBalance(ctx context.Context, contractAddress string) (*big.Int, error) {
+  return i.ethClient.BalanceAt(ctx, common.HexToAddress(contractAddress), nil)
+}
+
+// Triggers the interaction to release the escrowed funds to the freelancer's wallet. Releasing again with the same
+// idempotency key waits for the transaction already sent, unless it failed, instead of sending another
+func (i *Interactor) Release(ctx context.Context, contractAddress, to string, amount money.Amount, idempotencyKey string) (string, error) {
+  description := fmt.Sprintf("release %s", idempotencyKey)
+  sent, err := db.GetLatestChainTransaction(description + " on " + contractAddress)
+  if err != nil {
+    return "", err
+  }
+  if sent != nil && (sent.Status == db.ChainTxPending || sent.Status == db.ChainTxConfirmed) {
+    log.Printf("Release %s was already sent in %s", idempotencyKey, sent.Hash)
+    receipt, err := i.txs.Wait(ctx, sent.Hash)
+    if err != nil {
+      return "", fmt.Errorf("failed to release escrow: %v", err)
+    }
+    return receipt.TxHash.Hex(), nil
+  }
+
+  log.Printf("Releasing %s to %s...", amount, to)
+
+  // Load the smart contract
+  escrow, err := contract.NewEscrowService(common.HexToAddress(contractAddress), i.ethClient)
+  if err != nil {
+    return "", fmt.Errorf("failed to instantiate smart contract: %v", err)
+  }
+
+  // Call the release function
+  receipt, err := i.send(ctx, contractAddress, description, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    return escrow.Release(opts, common.HexToAddress(to), amount.Units)
+  })
+  if err != nil {
+    return "", fmt.Errorf("failed to release escrow: %v", err)
+  }
+
+  log.Printf("Transaction mined. Receipt: %v", receipt)
+
+  return receipt.TxHash.Hex(), nil
+}
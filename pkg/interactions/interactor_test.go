@@ -0,0 +1,329 @@
+//go:build integration
+
+// Runs the Interactor against go-ethereum's simulated backend:
+//
+//   go test -tags integration ./pkg/interactions
+//
+// Go 1.23 and later also need -ldflags=-checklinkname=0 to link the simulated node.
+package interactions
+
+import (
+  "context"
+  "math/big"
+  "os/exec"
+  "path/filepath"
+  "testing"
+  "time"
+
+  "github.com/ethereum/go-ethereum/accounts/abi/bind"
+  "github.com/ethereum/go-ethereum/common"
+  "github.com/ethereum/go-ethereum/core/types"
+  "github.com/ethereum/go-ethereum/crypto"
+  "github.com/ethereum/go-ethereum/eth/ethconfig"
+  "github.com/ethereum/go-ethereum/ethclient/simulated"
+  "github.com/ethereum/go-ethereum/node"
+  "github.com/ethereum/go-ethereum/params"
+
+  "smart_contract/pkg/chain"
+  "smart_contract/pkg/db"
+  "smart_contract/pkg/money"
+  "smart_contract/pkg/smart-contract/contract"
+)
+
+// An escrow deployed on a fresh simulated chain, with blocks mined in the background
+type escrowFixture struct {
+  backend    *simulated.Backend
+  interactor *Interactor
+  escrow     *contract.EscrowService
+  address    string
+  client     common.Address // The client's wallet, which refunds go to
+  amount     money.Amount
+}
+
+// Returns the compiled escrow template, compiling it when the checked-in bindings have no bytecode
+func escrowArtifact(t *testing.T) contract.Artifact {
+  t.Helper()
+  artifact := contract.EscrowServiceArtifact()
+  if artifact.Bin != "" {
+    return artifact
+  }
+  solc, err := exec.LookPath("solc")
+  if err != nil {
+    t.Fatal("the escrow bindings have no bytecode and solc is not installed, run go generate ./pkg/smart-contract/contract")
+  }
+  compiled, err := contract.Compile(context.Background(), solc, "../smart-contract/contract/EscrowService.sol", "EscrowService")
+  if err != nil {
+    t.Fatal(err)
+  }
+  return *compiled
+}
+
+// A fresh database for the transactions the Interactor records
+func useTestDB(t *testing.T) {
+  t.Helper()
+  if err := db.Initialize(filepath.Join(t.TempDir(), "test.db")); err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { db.DB.Close() })
+}
+
+// Starts a simulated chain with a funded operator account
+func newSimulatedChain(t *testing.T, options ...func(*node.Config, *ethconfig.Config)) (*simulated.Backend, *KeySigner) {
+  t.Helper()
+  key, err := crypto.GenerateKey()
+  if err != nil {
+    t.Fatal(err)
+  }
+  operator := crypto.PubkeyToAddress(key.PublicKey)
+  backend := simulated.NewBackend(types.GenesisAlloc{
+    operator: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+  }, options...)
+  t.Cleanup(func() { backend.Close() })
+  return backend, &KeySigner{key: key, address: operator}
+}
+
+// Mines a block every few milliseconds so waiting for transactions behaves like a live chain
+func mineInBackground(t *testing.T, backend *simulated.Backend) {
+  ctx, cancel := context.WithCancel(context.Background())
+  t.Cleanup(cancel)
+  go func() {
+    ticker := time.NewTicker(20 * time.Millisecond)
+    defer ticker.Stop()
+    for {
+      select {
+      case <-ctx.Done():
+        return
+      case <-ticker.C:
+        backend.Commit()
+      }
+    }
+  }()
+}
+
+// A network matching the simulated chain
+func simulatedNetwork() chain.Network {
+  return chain.Network{Name: "simulated", ChainID: params.AllDevChainProtocolChanges.ChainID.Int64(), Currency: "ETH", Confirmations: 2}
+}
+
+func newEscrowFixture(t *testing.T) *escrowFixture {
+  t.Helper()
+  artifact := escrowArtifact(t)
+  useTestDB(t)
+  backend, signer := newSimulatedChain(t)
+  mineInBackground(t, backend)
+  ctx := context.Background()
+
+  interactor, err := NewInteractor(backend.Client(), simulatedNetwork(), signer)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  eth, err := money.LookupCurrency("ETH")
+  if err != nil {
+    t.Fatal(err)
+  }
+  fixture := &escrowFixture{
+    backend:    backend,
+    interactor: interactor,
+    client:     common.HexToAddress("0x00000000000000000000000000000000000c1e47"),
+    amount:     money.New(big.NewInt(params.Ether), eth),
+  }
+
+  deadline := big.NewInt(time.Now().AddDate(0, 0, 30).Unix())
+  milestones := [][32]byte{crypto.Keccak256Hash([]byte("design")), crypto.Keccak256Hash([]byte("build"))}
+  txHash, err := interactor.Deploy(ctx, 0, artifact, fixture.client, fixture.amount.Units, deadline, milestones)
+  if err != nil {
+    t.Fatal(err)
+  }
+  deployment, err := interactor.WaitDeployed(ctx, txHash)
+  if err != nil {
+    t.Fatal(err)
+  }
+  fixture.address = deployment.Address
+
+  fixture.escrow, err = contract.NewEscrowService(common.HexToAddress(deployment.Address), backend.Client())
+  if err != nil {
+    t.Fatal(err)
+  }
+  if count, err := fixture.escrow.MilestoneCount(nil); err != nil || count.Int64() != 2 {
+    t.Fatalf("deployed with %v milestones (%v), want 2", count, err)
+  }
+  if err := interactor.ExecuteContract(ctx, fixture.address); err != nil {
+    t.Fatal(err)
+  }
+  return fixture
+}
+
+// Asserts the flags returned by getTransactionDetails along with the escrowed balance
+func (f *escrowFixture) expectState(t *testing.T, balance *big.Int, initiated, received, disputed, refunded bool) {
+  t.Helper()
+  _, _, held, gotInitiated, gotReceived, gotDisputed, gotRefunded, err := f.escrow.GetTransactionDetails(nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if held.Cmp(balance) != 0 {
+    t.Errorf("escrow holds %s wei, want %s", held, balance)
+  }
+  if gotInitiated != initiated || gotReceived != received || gotDisputed != disputed || gotRefunded != refunded {
+    t.Errorf("initiated, received, disputed, refunded = %t, %t, %t, %t, want %t, %t, %t, %t",
+      gotInitiated, gotReceived, gotDisputed, gotRefunded, initiated, received, disputed, refunded)
+  }
+}
+
+func (f *escrowFixture) balanceOf(t *testing.T, address common.Address) *big.Int {
+  t.Helper()
+  balance, err := f.backend.Client().BalanceAt(context.Background(), address, nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  return balance
+}
+
+func TestExecuteContractIsIdempotent(t *testing.T) {
+  f := newEscrowFixture(t)
+  ctx := context.Background()
+
+  if err := f.interactor.ExecuteContract(ctx, f.address); err != nil {
+    t.Fatalf("executing twice failed: %v", err)
+  }
+  if executed, err := f.escrow.IsExecuted(nil); err != nil || !executed {
+    t.Fatalf("contract executed = %t (%v), want true", executed, err)
+  }
+}
+
+func TestDepositConfirmAndRelease(t *testing.T) {
+  f := newEscrowFixture(t)
+  ctx := context.Background()
+
+  if err := f.interactor.InitiateEscrow(ctx, f.address, f.amount); err != nil {
+    t.Fatal(err)
+  }
+  f.expectState(t, f.amount.Units, true, false, false, false)
+
+  // Marking the requirements complete also confirms them
+  if err := f.interactor.MarkRequirementsComplete(ctx, f.address); err != nil {
+    t.Fatal(err)
+  }
+  f.expectState(t, f.amount.Units, true, true, false, false)
+
+  if err := f.interactor.UpdateContractProgress(ctx, f.address, 4); err != nil {
+    t.Fatal(err)
+  }
+  if progress, err := f.escrow.Progress(nil); err != nil || progress != 4 {
+    t.Errorf("progress = %d (%v), want 4", progress, err)
+  }
+
+  freelancer := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  txHash, err := f.interactor.Release(ctx, f.address, freelancer.Hex(), f.amount, "payout-1")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if txHash == "" {
+    t.Error("release returned no transaction hash")
+  }
+  if got := f.balanceOf(t, freelancer); got.Cmp(f.amount.Units) != 0 {
+    t.Errorf("freelancer received %s wei, want %s", got, f.amount.Units)
+  }
+
+  // Retrying the payout, e.g. after a restart, finds the release already sent
+  again, err := f.interactor.Release(ctx, f.address, freelancer.Hex(), f.amount, "payout-1")
+  if err != nil || again != txHash {
+    t.Errorf("retried release = %s (%v), want %s", again, err, txHash)
+  }
+  f.expectState(t, new(big.Int), true, true, false, false)
+
+  // The confirmation is visible to anyone following the contract's events
+  events, err := f.escrow.FilterReceiptConfirmed(&bind.FilterOpts{Start: 0})
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer events.Close()
+  if !events.Next() {
+    t.Fatal("no ReceiptConfirmed event was emitted")
+  }
+  if events.Event.Amount.Cmp(f.amount.Units) != 0 {
+    t.Errorf("ReceiptConfirmed amount = %s, want %s", events.Event.Amount, f.amount.Units)
+  }
+}
+
+func TestDisputeAndResolve(t *testing.T) {
+  f := newEscrowFixture(t)
+  ctx := context.Background()
+
+  if err := f.interactor.InitiateEscrow(ctx, f.address, f.amount); err != nil {
+    t.Fatal(err)
+  }
+  if err := f.interactor.InitiateDispute(ctx, f.address); err != nil {
+    t.Fatal(err)
+  }
+  f.expectState(t, f.amount.Units, true, false, true, false)
+
+  // Nothing moves while the dispute is open
+  if err := f.interactor.MarkRequirementsComplete(ctx, f.address); err == nil {
+    t.Error("requirements were marked complete during a dispute")
+  }
+  if _, err := f.interactor.Release(ctx, f.address, f.client.Hex(), f.amount, "payout-1"); err == nil {
+    t.Error("funds were released during a dispute")
+  }
+
+  if err := f.interactor.ResolveDispute(ctx, f.address, "deliver the remaining pages"); err != nil {
+    t.Fatal(err)
+  }
+  f.expectState(t, f.amount.Units, true, false, false, false)
+  if resolution, err := f.escrow.Resolution(nil); err != nil || resolution != "deliver the remaining pages" {
+    t.Errorf("resolution = %q (%v)", resolution, err)
+  }
+
+  // Once resolved the contract can be completed as usual
+  if err := f.interactor.MarkRequirementsComplete(ctx, f.address); err != nil {
+    t.Fatal(err)
+  }
+  freelancer := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  if _, err := f.interactor.Release(ctx, f.address, freelancer.Hex(), f.amount, "payout-1"); err != nil {
+    t.Fatal(err)
+  }
+  f.expectState(t, new(big.Int), true, true, false, false)
+}
+
+func TestRefund(t *testing.T) {
+  f := newEscrowFixture(t)
+  ctx := context.Background()
+
+  if err := f.interactor.InitiateEscrow(ctx, f.address, f.amount); err != nil {
+    t.Fatal(err)
+  }
+  if err := f.interactor.Refund(ctx, f.address); err != nil {
+    t.Fatal(err)
+  }
+  if got := f.balanceOf(t, f.client); got.Cmp(f.amount.Units) != 0 {
+    t.Errorf("client was refunded %s wei, want %s", got, f.amount.Units)
+  }
+  f.expectState(t, new(big.Int), true, false, false, true)
+}
+
+func TestRejectsInvalidActions(t *testing.T) {
+  f := newEscrowFixture(t)
+  ctx := context.Background()
+
+  // Funds can't be released before the client confirmed receipt
+  if _, err := f.interactor.Release(ctx, f.address, f.client.Hex(), f.amount, "payout-1"); err == nil {
+    t.Error("funds were released before the escrow was funded")
+  }
+
+  short := money.New(new(big.Int).Sub(f.amount.Units, big.NewInt(1)), f.amount.Currency)
+  if err := f.interactor.InitiateEscrow(ctx, f.address, short); err == nil {
+    t.Error("the escrow accepted less than the payment amount")
+  }
+  f.expectState(t, new(big.Int), false, false, false, false)
+
+  if err := f.interactor.InitiateEscrow(ctx, f.address, f.amount); err != nil {
+    t.Fatal(err)
+  }
+  if err := f.interactor.ConfirmReqs(ctx, f.address); err == nil {
+    t.Error("receipt was confirmed before the requirements were complete")
+  }
+  if err := f.interactor.InitiateEscrow(ctx, f.address, f.amount); err == nil {
+    t.Error("the escrow was funded twice")
+  }
+  f.expectState(t, f.amount.Units, true, false, false, false)
+}
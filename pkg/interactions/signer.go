@@ -0,0 +1,188 @@
+package interactions
+
+import (
+  "context"
+  "crypto/ecdsa"
+  "fmt"
+  "math/big"
+  "os"
+  "strings"
+
+  "github.com/ethereum/go-ethereum/accounts/keystore"
+  "github.com/ethereum/go-ethereum/common"
+  "github.com/ethereum/go-ethereum/common/hexutil"
+  "github.com/ethereum/go-ethereum/core/types"
+  "github.com/ethereum/go-ethereum/crypto"
+  "github.com/ethereum/go-ethereum/rpc"
+)
+
+// Signs transactions for the account the Interactor sends from, so that no Interactor method handles key material
+type Signer interface {
+  // The account transactions are sent from
+  Address() common.Address
+  // Signs a transaction for the given chain
+  SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// Returns a remote signer when SIGNER_URL is set, a keystore signer when SIGNER_KEYSTORE is set,
+// otherwise a signer using the raw key in ADMIN_PRIVATE_KEY, which is only meant for development
+func NewSignerFromEnv(ctx context.Context) (Signer, error) {
+  if url := os.Getenv("SIGNER_URL"); url != "" {
+    var account common.Address
+    if address := os.Getenv("SIGNER_ADDRESS"); address != "" {
+      if !common.IsHexAddress(address) {
+        return nil, fmt.Errorf("invalid SIGNER_ADDRESS %q", address)
+      }
+      account = common.HexToAddress(address)
+    }
+    return NewRemoteSigner(ctx, url, account)
+  }
+
+  if path := os.Getenv("SIGNER_KEYSTORE"); path != "" {
+    passphrase := os.Getenv("SIGNER_PASSWORD")
+    if file := os.Getenv("SIGNER_PASSWORD_FILE"); file != "" {
+      data, err := os.ReadFile(file)
+      if err != nil {
+        return nil, fmt.Errorf("failed to read keystore password: %v", err)
+      }
+      passphrase = strings.TrimRight(string(data), "\r\n")
+    }
+    return NewKeystoreSigner(path, passphrase)
+  }
+
+  return NewKeySignerFromEnv("ADMIN_PRIVATE_KEY")
+}
+
+// Signs locally with a private key held in memory
+type KeySigner struct {
+  key     *ecdsa.PrivateKey
+  address common.Address
+}
+
+// Decrypts an encrypted JSON keystore file, as written by geth or clef
+func NewKeystoreSigner(path, passphrase string) (*KeySigner, error) {
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return nil, fmt.Errorf("failed to read keystore: %v", err)
+  }
+  key, err := keystore.DecryptKey(data, passphrase)
+  if err != nil {
+    return nil, fmt.Errorf("failed to decrypt keystore: %v", err)
+  }
+  return &KeySigner{key: key.PrivateKey, address: key.Address}, nil
+}
+
+// Reads a hex encoded private key from an environment variable, for local development only
+func NewKeySignerFromEnv(name string) (*KeySigner, error) {
+  value := os.Getenv(name)
+  if value == "" {
+    return nil, fmt.Errorf("%s is not set", name)
+  }
+  key, err := crypto.HexToECDSA(strings.TrimPrefix(value, "0x"))
+  if err != nil {
+    return nil, fmt.Errorf("invalid %s: %v", name, err)
+  }
+  return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}, nil
+}
+
+// Returns the key's address
+func (s *KeySigner) Address() common.Address {
+  return s.address
+}
+
+// Signs the transaction with the London rules for the chain, covering both legacy and EIP-1559 transactions
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+  return types.SignTx(tx, types.NewLondonSigner(chainID), s.key)
+}
+
+// Asks a clef-compatible signer to sign over JSON-RPC, keeping the key outside this process
+type RemoteSigner struct {
+  client  *rpc.Client
+  account common.Address
+}
+
+// Connects to a remote signer; when account is the zero address, the first account it manages is used
+func NewRemoteSigner(ctx context.Context, url string, account common.Address) (*RemoteSigner, error) {
+  client, err := rpc.DialContext(ctx, url)
+  if err != nil {
+    return nil, fmt.Errorf("failed to connect to remote signer: %v", err)
+  }
+
+  if account == (common.Address{}) {
+    var accounts []common.Address
+    if err := client.CallContext(ctx, &accounts, "account_list"); err != nil {
+      client.Close()
+      return nil, fmt.Errorf("failed to list remote signer accounts: %v", err)
+    }
+    if len(accounts) == 0 {
+      client.Close()
+      return nil, fmt.Errorf("remote signer has no accounts")
+    }
+    account = accounts[0]
+  }
+  return &RemoteSigner{client: client, account: account}, nil
+}
+
+// Returns the remote account's address
+func (s *RemoteSigner) Address() common.Address {
+  return s.account
+}
+
+// Arguments of clef's account_signTransaction
+type remoteTxArgs struct {
+  From                 common.MixedcaseAddress  `json:"from"`
+  To                   *common.MixedcaseAddress `json:"to"`
+  Gas                  hexutil.Uint64           `json:"gas"`
+  GasPrice             *hexutil.Big             `json:"gasPrice,omitempty"`
+  MaxFeePerGas         *hexutil.Big             `json:"maxFeePerGas,omitempty"`
+  MaxPriorityFeePerGas *hexutil.Big             `json:"maxPriorityFeePerGas,omitempty"`
+  Value                hexutil.Big              `json:"value"`
+  Nonce                hexutil.Uint64           `json:"nonce"`
+  Input                hexutil.Bytes            `json:"input"`
+  ChainID              *hexutil.Big             `json:"chainId"`
+}
+
+// Sends the transaction to the remote signer, which may ask an operator to approve it
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+  args := remoteTxArgs{
+    From:    common.NewMixedcaseAddress(s.account),
+    Gas:     hexutil.Uint64(tx.Gas()),
+    Value:   hexutil.Big(*tx.Value()),
+    Nonce:   hexutil.Uint64(tx.Nonce()),
+    Input:   tx.Data(),
+    ChainID: (*hexutil.Big)(chainID),
+  }
+  if to := tx.To(); to != nil {
+    address := common.NewMixedcaseAddress(*to)
+    args.To = &address
+  }
+  if tx.Type() == types.DynamicFeeTxType {
+    args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+    args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+  } else {
+    args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+  }
+
+  var result struct {
+    Raw hexutil.Bytes `json:"raw"`
+  }
+  if err := s.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+    return nil, fmt.Errorf("remote signer refused to sign: %v", err)
+  }
+
+  signed := new(types.Transaction)
+  if err := signed.UnmarshalBinary(result.Raw); err != nil {
+    return nil, fmt.Errorf("failed to decode signed transaction: %v", err)
+  }
+  // Make sure the signer didn't alter any part of the transaction, fees and data included, or sign it with another account.
+  // The signing hash covers the type, chain and every field that is signed
+  signer := types.NewLondonSigner(chainID)
+  if signed.Type() != tx.Type() || signer.Hash(signed) != signer.Hash(tx) {
+    return nil, fmt.Errorf("remote signer returned a different transaction")
+  }
+  from, err := types.Sender(signer, signed)
+  if err != nil || from != s.account {
+    return nil, fmt.Errorf("remote signer signed with the wrong account")
+  }
+  return signed, nil
+}
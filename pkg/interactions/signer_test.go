@@ -0,0 +1,168 @@
+package interactions
+
+import (
+  "context"
+  "crypto/ecdsa"
+  "math/big"
+  "net/http/httptest"
+  "testing"
+
+  "github.com/ethereum/go-ethereum/common"
+  "github.com/ethereum/go-ethereum/common/hexutil"
+  "github.com/ethereum/go-ethereum/core/types"
+  "github.com/ethereum/go-ethereum/crypto"
+  "github.com/ethereum/go-ethereum/rpc"
+)
+
+// Serves clef's account namespace, signing whatever it is asked to after an optional change
+type stubClef struct {
+  key      *ecdsa.PrivateKey         // The account it manages
+  signWith *ecdsa.PrivateKey         // Signs with another key when set
+  tamper   func(*types.DynamicFeeTx) // Changes a dynamic fee transaction before signing it
+  calls    int
+}
+
+func (c *stubClef) List() []common.Address {
+  return []common.Address{crypto.PubkeyToAddress(c.key.PublicKey)}
+}
+
+func (c *stubClef) SignTransaction(args remoteTxArgs) (map[string]hexutil.Bytes, error) {
+  c.calls++
+  chainID := args.ChainID.ToInt()
+  var tx *types.Transaction
+  if args.MaxFeePerGas != nil {
+    to := args.To.Address()
+    inner := &types.DynamicFeeTx{
+      ChainID:   chainID,
+      Nonce:     uint64(args.Nonce),
+      GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+      GasFeeCap: args.MaxFeePerGas.ToInt(),
+      Gas:       uint64(args.Gas),
+      To:        &to,
+      Value:     args.Value.ToInt(),
+      Data:      args.Input,
+    }
+    if c.tamper != nil {
+      c.tamper(inner)
+    }
+    tx = types.NewTx(inner)
+  } else {
+    tx = types.NewContractCreation(uint64(args.Nonce), args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), args.Input)
+  }
+  key := c.key
+  if c.signWith != nil {
+    key = c.signWith
+  }
+  signed, err := types.SignTx(tx, types.NewLondonSigner(chainID), key)
+  if err != nil {
+    return nil, err
+  }
+  raw, err := signed.MarshalBinary()
+  if err != nil {
+    return nil, err
+  }
+  return map[string]hexutil.Bytes{"raw": raw}, nil
+}
+
+// Starts a stub clef and connects a RemoteSigner to it
+func remoteSigner(t *testing.T, clef *stubClef) *RemoteSigner {
+  t.Helper()
+  server := rpc.NewServer()
+  if err := server.RegisterName("account", clef); err != nil {
+    t.Fatal(err)
+  }
+  httpServer := httptest.NewServer(server)
+  t.Cleanup(func() {
+    httpServer.Close()
+    server.Stop()
+  })
+
+  signer, err := NewRemoteSigner(context.Background(), httpServer.URL, common.Address{})
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { signer.client.Close() })
+  return signer
+}
+
+func TestRemoteSignerChecksSignedTransaction(t *testing.T) {
+  key, err := crypto.GenerateKey()
+  if err != nil {
+    t.Fatal(err)
+  }
+  other, err := crypto.GenerateKey()
+  if err != nil {
+    t.Fatal(err)
+  }
+  chainID := big.NewInt(1337)
+  escrow := common.HexToAddress("0x00000000000000000000000000000000000E5C40")
+  call := types.NewTx(&types.DynamicFeeTx{
+    ChainID:   chainID,
+    Nonce:     7,
+    GasTipCap: big.NewInt(1e9),
+    GasFeeCap: big.NewInt(30e9),
+    Gas:       90000,
+    To:        &escrow,
+    Value:     big.NewInt(1e18),
+    Data:      common.FromHex("0x5c7c1e83"),
+  })
+
+  tests := []struct {
+    name     string
+    signWith *ecdsa.PrivateKey
+    tamper   func(*types.DynamicFeeTx)
+    valid    bool
+  }{
+    {"signed as asked", nil, nil, true},
+    {"fee cap raised", nil, func(tx *types.DynamicFeeTx) { tx.GasFeeCap = big.NewInt(300e9) }, false},
+    {"tip raised", nil, func(tx *types.DynamicFeeTx) { tx.GasTipCap = big.NewInt(10e9) }, false},
+    {"gas raised", nil, func(tx *types.DynamicFeeTx) { tx.Gas = 900000 }, false},
+    {"data changed", nil, func(tx *types.DynamicFeeTx) { tx.Data = common.FromHex("0xdeadbeef") }, false},
+    {"recipient changed", nil, func(tx *types.DynamicFeeTx) { tx.To = &common.Address{1} }, false},
+    {"value changed", nil, func(tx *types.DynamicFeeTx) { tx.Value = big.NewInt(2e18) }, false},
+    {"access list added", nil, func(tx *types.DynamicFeeTx) { tx.AccessList = types.AccessList{{Address: escrow}} }, false},
+    {"other account", other, nil, false},
+  }
+  for _, test := range tests {
+    t.Run(test.name, func(t *testing.T) {
+      clef := &stubClef{key: key, signWith: test.signWith, tamper: test.tamper}
+      signed, err := remoteSigner(t, clef).SignTx(context.Background(), call, chainID)
+      if clef.calls != 1 {
+        t.Fatalf("remote signer called %d times", clef.calls)
+      }
+      if test.valid {
+        if err != nil {
+          t.Fatalf("rejected: %v", err)
+        }
+        if signed.Hash() == call.Hash() {
+          t.Error("returned the unsigned transaction")
+        }
+        return
+      }
+      if err == nil {
+        t.Error("accepted")
+      }
+    })
+  }
+}
+
+func TestRemoteSignerSignsLegacyTransactions(t *testing.T) {
+  key, err := crypto.GenerateKey()
+  if err != nil {
+    t.Fatal(err)
+  }
+  signer := remoteSigner(t, &stubClef{key: key})
+  if signer.Address() != crypto.PubkeyToAddress(key.PublicKey) {
+    t.Fatalf("using account %s, want the first listed", signer.Address())
+  }
+
+  chainID := big.NewInt(1337)
+  deploy := types.NewContractCreation(0, big.NewInt(0), 2000000, big.NewInt(20e9), common.FromHex("0x6080604052"))
+  signed, err := signer.SignTx(context.Background(), deploy, chainID)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if from, err := types.Sender(types.NewLondonSigner(chainID), signed); err != nil || from != signer.Address() {
+    t.Errorf("signed by %s (%v)", from, err)
+  }
+}
@@ -0,0 +1,446 @@
+package interactions
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "log"
+  "math/big"
+  "strings"
+  "sync"
+  "time"
+
+  "github.com/ethereum/go-ethereum"
+  "github.com/ethereum/go-ethereum/accounts/abi/bind"
+  "github.com/ethereum/go-ethereum/common"
+  "github.com/ethereum/go-ethereum/common/hexutil"
+  "github.com/ethereum/go-ethereum/core/types"
+  "github.com/ethereum/go-ethereum/rpc"
+  "smart_contract/pkg/chain"
+  "smart_contract/pkg/db"
+)
+
+const (
+  defaultStuckAfter      = 2 * time.Minute // How long a transaction may stay unmined before it is replaced
+  defaultPollInterval    = time.Second
+  defaultMaxReplacements = 5
+)
+
+// Sends the transactions of one signer one at a time, assigning nonces locally so concurrent calls never collide,
+// and follows each to a final status, replacing it with a higher fee when it gets stuck
+type TxManager struct {
+  backend Backend
+  network chain.Network
+  signer  Signer
+  gas     *gasPolicy
+
+  stuckAfter      time.Duration
+  pollInterval    time.Duration
+  maxReplacements int
+
+  mu        sync.Mutex // Serializes submissions and replacements
+  nonce     uint64     // Next nonce to assign
+  nonceSync bool       // Whether nonce was loaded from the node and the DB
+}
+
+type txManagerKey struct {
+  chainID int64
+  sender  common.Address
+}
+
+var (
+  txManagersMu sync.Mutex
+  txManagers   = map[txManagerKey]*TxManager{}
+)
+
+// Returns the transaction manager for the signer's account on the network, shared by every Interactor using it
+func txManagerFor(backend Backend, network chain.Network, signer Signer) *TxManager {
+  txManagersMu.Lock()
+  defer txManagersMu.Unlock()
+
+  key := txManagerKey{network.ChainID, signer.Address()}
+  if m, ok := txManagers[key]; ok {
+    return m
+  }
+  m := &TxManager{
+    backend:         backend,
+    network:         network,
+    signer:          signer,
+    gas:             &gasPolicy{backend: backend, network: network},
+    stuckAfter:      defaultStuckAfter,
+    pollInterval:    defaultPollInterval,
+    maxReplacements: defaultMaxReplacements,
+  }
+  txManagers[key] = m
+  return m
+}
+
+// Returns the next nonce to assign, starting after both the node's pending nonce and the last one we recorded
+func (m *TxManager) nextNonce(ctx context.Context) (uint64, error) {
+  if m.nonceSync {
+    return m.nonce, nil
+  }
+  pending, err := m.backend.PendingNonceAt(ctx, m.signer.Address())
+  if err != nil {
+    return 0, fmt.Errorf("failed to get pending nonce: %v", err)
+  }
+  recorded, err := db.GetNextChainNonce(m.signer.Address().Hex())
+  if err != nil {
+    return 0, err
+  }
+  m.nonce, m.nonceSync = max(pending, recorded), true
+  return m.nonce, nil
+}
+
+// Returns the options for a transaction with the given nonce and fees, signed for the signer's account but not sent.
+// Its gas limit is given the network's headroom before signing, and it is refused if it would overrun its contract's budget
+func (m *TxManager) transactOpts(ctx context.Context, nonce uint64, fees *gasFees, contractID int) *bind.TransactOpts {
+  from := m.signer.Address()
+  return &bind.TransactOpts{
+    From:      from,
+    Nonce:     new(big.Int).SetUint64(nonce),
+    GasTipCap: fees.Tip,
+    GasFeeCap: fees.FeeCap,
+    Context:   ctx,
+    NoSend:    true,
+    Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+      if address != from {
+        return nil, bind.ErrNotAuthorized
+      }
+      tx, err := m.gas.prepare(tx)
+      if err != nil {
+        return nil, err
+      }
+      if err := m.gas.checkBudget(contractID, tx, fees.BaseFee); err != nil {
+        return nil, err
+      }
+      return m.signer.SignTx(ctx, tx, m.network.ChainIDBig())
+    },
+  }
+}
+
+// Builds a transaction with the next nonce, records it and sends it, returning once the node has it.
+// build receives the options to pass to a bound contract method, which must sign the transaction without sending it.
+// The gas is paid from the network fee budget of the contract with contractID, if it is not 0
+func (m *TxManager) Submit(ctx context.Context, contractID int, description string, build func(*bind.TransactOpts) (*types.Transaction, error)) (*db.ChainTransaction, error) {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+
+  fees, err := m.gas.fees(ctx)
+  if err != nil {
+    return nil, err
+  }
+  for attempt := 0; ; attempt++ {
+    nonce, err := m.nextNonce(ctx)
+    if err != nil {
+      return nil, err
+    }
+    // A transaction that fails to build, e.g. because its call would revert or is over budget, leaves the nonce unused
+    tx, err := build(m.transactOpts(ctx, nonce, fees, contractID))
+    if err != nil {
+      return nil, err
+    }
+    raw, err := tx.MarshalBinary()
+    if err != nil {
+      return nil, fmt.Errorf("failed to encode transaction: %v", err)
+    }
+
+    // Recorded before it is sent, so a restart can't lose track of a transaction the node may have
+    record := &db.ChainTransaction{
+      Sender:      m.signer.Address().Hex(),
+      Nonce:       nonce,
+      ContractID:  contractID,
+      Description: description,
+      Hash:        tx.Hash().Hex(),
+      Raw:         hexutil.Encode(raw),
+      Hashes:      []string{tx.Hash().Hex()},
+      Status:      db.ChainTxPending,
+      SentAt:      time.Now(),
+    }
+    if record.ID, err = db.CreateChainTransaction(record); err != nil {
+      return nil, err
+    }
+
+    err = m.backend.SendTransaction(ctx, tx)
+    if err == nil || isAlreadyKnown(err) || !isRejected(err) {
+      // If the node never got it, waiting for it will send it again once it is considered stuck
+      if err != nil && !isAlreadyKnown(err) {
+        log.Printf("Error sending %s, will retry: %v", description, err)
+      }
+      m.nonce = nonce + 1
+      log.Printf("Sent %s with nonce %d. Transaction hash: %s", description, nonce, record.Hash)
+      return record, nil
+    }
+
+    if err := db.DeleteChainTransaction(record.ID); err != nil {
+      return nil, err
+    }
+    // Another client sent from our account, so start again from the node's nonce
+    if strings.Contains(err.Error(), "nonce too low") && attempt == 0 {
+      m.nonceSync = false
+      continue
+    }
+    return nil, fmt.Errorf("failed to send %s: %v", description, err)
+  }
+}
+
+// Waits until a transaction sent by Submit, or any higher-fee version of it, is mined and buried under the network's
+// confirmations. Transactions not sent through the manager are waited for without being replaced
+func (m *TxManager) Wait(ctx context.Context, hash string) (*types.Receipt, error) {
+  record, err := db.GetChainTransactionByHash(hash)
+  if err != nil {
+    record = &db.ChainTransaction{Hash: hash, Hashes: []string{hash}, Status: db.ChainTxPending}
+  }
+
+  ticker := time.NewTicker(m.pollInterval)
+  defer ticker.Stop()
+  for {
+    // Another caller waiting for the same transaction may have replaced or finished it
+    if record.ID != 0 {
+      if record, err = db.GetChainTransaction(record.ID); err != nil {
+        return nil, err
+      }
+    }
+
+    receipt, done, err := m.check(ctx, record)
+    if done {
+      return receipt, err
+    }
+    if err != nil {
+      log.Printf("Error checking transaction %s: %v", record.Hash, err)
+    }
+
+    select {
+    case <-ctx.Done():
+      return nil, ctx.Err()
+    case <-ticker.C:
+    }
+  }
+}
+
+// Checks whether any version of a transaction is final, replacing it if it has been pending too long
+func (m *TxManager) check(ctx context.Context, record *db.ChainTransaction) (*types.Receipt, bool, error) {
+  if record.Status == db.ChainTxFailed {
+    return nil, true, fmt.Errorf("transaction %s failed: %s", record.Hash, record.LastError)
+  }
+
+  head, err := m.backend.BlockNumber(ctx)
+  if err != nil {
+    return nil, false, err
+  }
+  receipt, err := m.findReceipt(ctx, record)
+  if err != nil {
+    return nil, false, err
+  }
+
+  if receipt != nil {
+    if head+1 < receipt.BlockNumber.Uint64()+m.network.Confirmations {
+      return nil, false, nil
+    }
+    if receipt.Status != types.ReceiptStatusSuccessful {
+      failure := fmt.Errorf("transaction %s reverted", receipt.TxHash.Hex())
+      return receipt, true, m.finish(record, db.ChainTxReverted, receipt, failure)
+    }
+    return receipt, true, m.finish(record, db.ChainTxConfirmed, receipt, nil)
+  }
+
+  // A transaction we saw mined was reorged out, so it is pending again
+  if record.Status != db.ChainTxPending && record.ID != 0 {
+    log.Printf("Transaction %s was removed from the chain, waiting for it again", record.Hash)
+    if err := db.ReopenChainTransaction(record.ID); err != nil {
+      return nil, false, err
+    }
+  }
+  if record.ID == 0 {
+    return nil, false, nil
+  }
+
+  // The nonce was used without any of our versions being mined, e.g. by a transaction sent elsewhere from our account
+  mined, err := m.backend.NonceAt(ctx, m.signer.Address(), nil)
+  if err != nil {
+    return nil, false, err
+  }
+  if mined > record.Nonce {
+    // It may have been mined between looking for receipts and reading the nonce
+    if receipt, err := m.findReceipt(ctx, record); err != nil || receipt != nil {
+      return nil, false, err
+    }
+    failure := fmt.Errorf("nonce %d was used by another transaction", record.Nonce)
+    return nil, true, m.finish(record, db.ChainTxFailed, nil, failure)
+  }
+
+  if time.Since(record.SentAt) >= m.stuckAfter && record.Replacements < m.maxReplacements {
+    if err := m.replace(ctx, record); err != nil {
+      return nil, false, fmt.Errorf("failed to replace stuck transaction: %v", err)
+    }
+  }
+  return nil, false, nil
+}
+
+// Returns the receipt of whichever version of a transaction was mined, or nil if none was
+func (m *TxManager) findReceipt(ctx context.Context, record *db.ChainTransaction) (*types.Receipt, error) {
+  for _, hash := range record.Hashes {
+    receipt, err := m.backend.TransactionReceipt(ctx, common.HexToHash(hash))
+    if errors.Is(err, ethereum.NotFound) {
+      continue
+    }
+    if err != nil {
+      return nil, err
+    }
+    return receipt, nil
+  }
+  return nil, nil
+}
+
+// Records a transaction's final status and, if it was mined, the gas it paid for, returning failure so callers see
+// why it did not go through
+func (m *TxManager) finish(record *db.ChainTransaction, status string, receipt *types.Receipt, failure error) error {
+  if record.ID == 0 {
+    return failure
+  }
+  var lastError string
+  if failure != nil {
+    lastError = failure.Error()
+  }
+  hash, block, gasUsed, fee := record.Hash, uint64(0), uint64(0), (*big.Int)(nil)
+  if receipt != nil {
+    hash, block, gasUsed, fee = receipt.TxHash.Hex(), receipt.BlockNumber.Uint64(), receipt.GasUsed, receiptFee(receipt)
+  }
+  if err := db.FinishChainTransaction(record.ID, status, hash, block, gasUsed, fee, m.network.Currency, lastError); err != nil {
+    return err
+  }
+  if failure != nil {
+    log.Printf("Transaction %d (%s): %v", record.ID, record.Description, failure)
+  }
+  return failure
+}
+
+// Signs a stuck transaction again with the same nonce and fees raised enough for nodes to accept it as a replacement
+func (m *TxManager) replace(ctx context.Context, record *db.ChainTransaction) error {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+
+  // Someone else waiting for it may have replaced it already
+  current, err := db.GetChainTransaction(record.ID)
+  if err != nil {
+    return err
+  }
+  if current.Replacements != record.Replacements || current.Status != db.ChainTxPending {
+    *record = *current
+    return nil
+  }
+
+  var stuck types.Transaction
+  if err := stuck.UnmarshalBinary(common.FromHex(record.Raw)); err != nil {
+    return fmt.Errorf("failed to decode transaction: %v", err)
+  }
+  unsigned, baseFee, err := m.bumpFees(ctx, &stuck)
+  if err != nil {
+    return err
+  }
+  if err := m.gas.checkBudget(record.ContractID, unsigned, baseFee); err != nil {
+    return err
+  }
+  tx, err := m.signer.SignTx(ctx, unsigned, m.network.ChainIDBig())
+  if err != nil {
+    return fmt.Errorf("failed to sign replacement: %v", err)
+  }
+  raw, err := tx.MarshalBinary()
+  if err != nil {
+    return fmt.Errorf("failed to encode transaction: %v", err)
+  }
+
+  sentAt := time.Now()
+  if err := db.ReplaceChainTransaction(record.ID, tx.Hash().Hex(), hexutil.Encode(raw), sentAt); err != nil {
+    return err
+  }
+  if err := m.backend.SendTransaction(ctx, tx); err != nil && !isAlreadyKnown(err) {
+    return err
+  }
+
+  log.Printf("Replaced stuck %s (nonce %d) %s with %s", record.Description, record.Nonce, record.Hash, tx.Hash().Hex())
+  record.Hash, record.Raw, record.SentAt = tx.Hash().Hex(), hexutil.Encode(raw), sentAt
+  record.Hashes = append(record.Hashes, record.Hash)
+  record.Replacements++
+  return nil
+}
+
+// Returns a copy of tx with its fees raised by an eighth, which nodes require of a replacement, or to what the
+// network currently asks if that is higher, along with the base fee they were picked against
+func (m *TxManager) bumpFees(ctx context.Context, tx *types.Transaction) (*types.Transaction, *big.Int, error) {
+  fees, err := m.gas.fees(ctx)
+  if err != nil {
+    return nil, nil, err
+  }
+
+  if fees.BaseFee == nil {
+    price, err := m.backend.SuggestGasPrice(ctx)
+    if err != nil {
+      return nil, nil, fmt.Errorf("failed to suggest gas price: %v", err)
+    }
+    price = bigMax(bump(tx.GasPrice()), price)
+    if err := m.gas.checkFeeCap(price); err != nil {
+      return nil, nil, err
+    }
+    return types.NewTx(&types.LegacyTx{
+      Nonce: tx.Nonce(), GasPrice: price, Gas: tx.Gas(), To: tx.To(), Value: tx.Value(), Data: tx.Data(),
+    }), nil, nil
+  }
+
+  tip := bigMax(bump(tx.GasTipCap()), fees.Tip)
+  feeCap := bigMax(bump(tx.GasFeeCap()), fees.FeeCap)
+  if err := m.gas.checkFeeCap(feeCap); err != nil {
+    return nil, nil, err
+  }
+  return types.NewTx(&types.DynamicFeeTx{
+    ChainID: m.network.ChainIDBig(), Nonce: tx.Nonce(), GasTipCap: tip, GasFeeCap: feeCap, Gas: tx.Gas(),
+    To: tx.To(), Value: tx.Value(), Data: tx.Data(), AccessList: tx.AccessList(),
+  }), fees.BaseFee, nil
+}
+
+// Follows the signer's transactions left pending by a previous run to a final status
+func (m *TxManager) Resume(ctx context.Context) error {
+  ids, err := db.GetPendingChainTransactionIDs(m.signer.Address().Hex())
+  if err != nil {
+    return err
+  }
+  for _, id := range ids {
+    record, err := db.GetChainTransaction(id)
+    if err != nil {
+      return err
+    }
+    go func() {
+      if _, err := m.Wait(ctx, record.Hash); err != nil {
+        log.Printf("Error waiting for %s: %v", record.Description, err)
+      }
+    }()
+  }
+  if len(ids) > 0 {
+    log.Printf("Resumed %d pending transactions from %s", len(ids), m.signer.Address().Hex())
+  }
+  return nil
+}
+
+// Returns value raised by an eighth, rounded up
+func bump(value *big.Int) *big.Int {
+  raised := new(big.Int).Div(value, big.NewInt(8))
+  return raised.Add(raised, value).Add(raised, big.NewInt(1))
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+  if a.Cmp(b) >= 0 {
+    return a
+  }
+  return b
+}
+
+// Whether the node already has the transaction in its pool
+func isAlreadyKnown(err error) bool {
+  return strings.Contains(err.Error(), "already known")
+}
+
+// Whether the node answered and refused the transaction, rather than the request failing on the way
+func isRejected(err error) bool {
+  var rpcErr rpc.Error
+  return errors.As(err, &rpcErr)
+}
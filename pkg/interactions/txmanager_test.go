@@ -0,0 +1,149 @@
+//go:build integration
+
+package interactions
+
+import (
+  "context"
+  "math/big"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+
+  "github.com/ethereum/go-ethereum/accounts/abi/bind"
+  "github.com/ethereum/go-ethereum/common"
+  "github.com/ethereum/go-ethereum/core/types"
+  "github.com/ethereum/go-ethereum/ethclient/simulated"
+  "github.com/ethereum/go-ethereum/params"
+
+  "smart_contract/pkg/db"
+)
+
+// Builds a plain transfer paying the given tip, so fees can be controlled without a contract
+func transfer(to common.Address, value, tip *big.Int) func(*bind.TransactOpts) (*types.Transaction, error) {
+  return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+    tx := types.NewTx(&types.DynamicFeeTx{
+      ChainID:   params.AllDevChainProtocolChanges.ChainID,
+      Nonce:     opts.Nonce.Uint64(),
+      GasTipCap: tip,
+      GasFeeCap: new(big.Int).Mul(tip, big.NewInt(50)),
+      Gas:       21000,
+      To:        &to,
+      Value:     value,
+    })
+    return opts.Signer(opts.From, tx)
+  }
+}
+
+func TestConcurrentSubmissionsGetDistinctNonces(t *testing.T) {
+  useTestDB(t)
+  backend, signer := newSimulatedChain(t)
+  mineInBackground(t, backend)
+  m := txManagerFor(backend.Client(), simulatedNetwork(), signer)
+  m.pollInterval = 10 * time.Millisecond
+  ctx := context.Background()
+
+  recipient := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  var wg sync.WaitGroup
+  errs := make(chan error, 10)
+  for n := 0; n < 10; n++ {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      record, err := m.Submit(ctx, 0, "transfer", transfer(recipient, big.NewInt(1000), big.NewInt(params.GWei)))
+      if err == nil {
+        _, err = m.Wait(ctx, record.Hash)
+      }
+      errs <- err
+    }()
+  }
+  wg.Wait()
+  close(errs)
+  for err := range errs {
+    if err != nil {
+      t.Fatal(err)
+    }
+  }
+
+  balance, err := backend.Client().BalanceAt(ctx, recipient, nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if balance.Int64() != 10000 {
+    t.Errorf("recipient received %s wei, want 10000", balance)
+  }
+  next, err := db.GetNextChainNonce(signer.Address().Hex())
+  if err != nil || next != 10 {
+    t.Errorf("next recorded nonce = %d (%v), want 10", next, err)
+  }
+  if pending, err := db.GetPendingChainTransactionIDs(signer.Address().Hex()); err != nil || len(pending) != 0 {
+    t.Errorf("%d transactions still pending (%v)", len(pending), err)
+  }
+}
+
+func TestReplacesStuckTransaction(t *testing.T) {
+  useTestDB(t)
+  // Transactions tipping less than the miner's minimum sit in the pool until replaced
+  backend, signer := newSimulatedChain(t, simulated.WithMinerMinTip(big.NewInt(params.GWei+params.GWei/10)))
+  mineInBackground(t, backend)
+  m := txManagerFor(backend.Client(), simulatedNetwork(), signer)
+  m.pollInterval, m.stuckAfter = 10*time.Millisecond, 100*time.Millisecond
+  ctx := context.Background()
+
+  recipient := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  record, err := m.Submit(ctx, 0, "transfer", transfer(recipient, big.NewInt(1000), big.NewInt(params.GWei)))
+  if err != nil {
+    t.Fatal(err)
+  }
+  receipt, err := m.Wait(ctx, record.Hash)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if receipt.TxHash.Hex() == record.Hash {
+    t.Error("the underpriced transaction was mined without being replaced")
+  }
+
+  final, err := db.GetChainTransaction(record.ID)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if final.Status != db.ChainTxConfirmed || final.Replacements == 0 || final.Hash != receipt.TxHash.Hex() {
+    t.Errorf("status %s after %d replacements with hash %s, want confirmed as %s", final.Status, final.Replacements, final.Hash, receipt.TxHash.Hex())
+  }
+  if len(final.Hashes) != final.Replacements+1 {
+    t.Errorf("recorded %d hashes for %d replacements", len(final.Hashes), final.Replacements)
+  }
+}
+
+func TestFailsWhenNonceIsUsedElsewhere(t *testing.T) {
+  useTestDB(t)
+  backend, signer := newSimulatedChain(t, simulated.WithMinerMinTip(big.NewInt(2*params.GWei)))
+  mineInBackground(t, backend)
+  m := txManagerFor(backend.Client(), simulatedNetwork(), signer)
+  m.pollInterval, m.stuckAfter = 10*time.Millisecond, time.Hour
+  ctx := context.Background()
+
+  recipient := common.HexToAddress("0x00000000000000000000000000000000000f4ee1")
+  record, err := m.Submit(ctx, 0, "transfer", transfer(recipient, big.NewInt(1000), big.NewInt(params.GWei)))
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  // Another client holding the same key sends a different transaction with the same nonce
+  opts := m.transactOpts(ctx, record.Nonce, &gasFees{}, 0)
+  other, err := transfer(recipient, big.NewInt(1), big.NewInt(5*params.GWei))(opts)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := backend.Client().SendTransaction(ctx, other); err != nil {
+    t.Fatal(err)
+  }
+
+  if _, err := m.Wait(ctx, record.Hash); err == nil || !strings.Contains(err.Error(), "used by another transaction") {
+    t.Fatalf("waiting returned %v, want the nonce reported as used", err)
+  }
+  final, err := db.GetChainTransaction(record.ID)
+  if err != nil || final.Status != db.ChainTxFailed {
+    t.Errorf("status = %s (%v), want failed", final.Status, err)
+  }
+}
@@ -0,0 +1,154 @@
+package invoice
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/fees"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/money"
+)
+
+// Kinds of documents issued for a contract
+const (
+	KindInvoice = "invoice"
+	KindReceipt = "receipt"
+)
+
+var (
+	htmlTemplatePath = "templates/invoice/document.html"
+	textTemplatePath = "templates/invoice/document.txt"
+)
+
+// The data rendered into invoice and receipt templates
+type Document struct {
+	Kind            string
+	Title           string
+	Number          string
+	IssuedAt        time.Time
+	ContractID      int
+	Description     string
+	ClientName      string
+	ClientEmail     string
+	FreelancerName  string
+	FreelancerEmail string
+	Fees            fees.Breakdown
+	Paid            string // Total received into escrow according to the ledger
+	PayoutTxID      string
+}
+
+// Issues the invoice for a paid contract, returning the existing one if it was already issued
+func IssueInvoice(contractID int) (*db.Invoice, error) {
+	return issue(contractID, KindInvoice)
+}
+
+// Issues the receipt for a released contract, returning the existing one if it was already issued
+func IssueReceipt(contractID int) (*db.Invoice, error) {
+	return issue(contractID, KindReceipt)
+}
+
+// Renders, numbers and stores a document for a contract
+func issue(contractID int, kind string) (*db.Invoice, error) {
+	if existing, err := db.GetInvoiceByContract(contractID, kind); err == nil {
+		return existing, nil
+	}
+
+	doc, err := buildDocument(contractID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "INV"
+	if kind == KindReceipt {
+		prefix = "RCT"
+	}
+	invoice := &db.Invoice{
+		Kind:       kind,
+		ContractID: contractID,
+		CreatedAt:  doc.IssuedAt,
+	}
+	invoice.ID, err = db.CreateInvoice(invoice, prefix, func(number string) (string, []byte, error) {
+		doc.Number = number
+		return Render(doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// Gathers the contract, parties, fee breakdown and ledger totals for a document
+func buildDocument(contractID int, kind string) (*Document, error) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := db.GetClientByID(db.DB, contract.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := db.GetUserByID(client.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	deposits, err := ledger.Balance(contractID, ledger.ClientDeposits, contract.Fees.Gross.Currency)
+	if err != nil {
+		return nil, err
+	}
+	paid, _ := money.Zero(deposits.Currency).Sub(deposits)
+
+	// Invoices show the network fee estimate the client was quoted, receipts the network fee actually charged
+	title, breakdown := "Invoice", contract.Fees
+	if kind == KindReceipt {
+		title = "Receipt"
+		if breakdown, err = contract.SettledFees(); err != nil {
+			return nil, err
+		}
+	}
+
+	doc := &Document{
+		Kind:            kind,
+		Title:           title,
+		IssuedAt:        time.Now(),
+		ContractID:      contractID,
+		Description:     contract.Description,
+		ClientName:      client.Name,
+		ClientEmail:     client.Email,
+		FreelancerName:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+		FreelancerEmail: user.Email,
+		Fees:            breakdown,
+		PayoutTxID:      contract.PayoutTxID,
+	}
+	if paid.Sign() > 0 {
+		doc.Paid = paid.String()
+	}
+	return doc, nil
+}
+
+// Renders a document to HTML and PDF from the invoice templates
+func Render(doc *Document) (string, []byte, error) {
+	htmlTmpl, err := htmltemplate.ParseFiles(htmlTemplatePath)
+	if err != nil {
+		return "", nil, err
+	}
+	var html bytes.Buffer
+	if err := htmlTmpl.Execute(&html, doc); err != nil {
+		return "", nil, err
+	}
+
+	textTmpl, err := texttemplate.ParseFiles(textTemplatePath)
+	if err != nil {
+		return "", nil, err
+	}
+	var text bytes.Buffer
+	if err := textTmpl.Execute(&text, doc); err != nil {
+		return "", nil, err
+	}
+
+	return html.String(), renderPDF(text.String()), nil
+}
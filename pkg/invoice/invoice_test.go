@@ -0,0 +1,117 @@
+package invoice
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/fees"
+	"smart_contract/pkg/money"
+)
+
+func TestMain(m *testing.M) {
+	// Documents are rendered from templates relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// Creates contracts paid 1 ETH each for a single client
+func paidContracts(t *testing.T, n int) []int {
+	t.Helper()
+	if err := db.Initialize(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+
+	if err := db.CreateUser(db.User{FirstName: "Fran", Email: "fran@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := db.GetUserByEmail("fran@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &db.Client{UserID: user.ID, Name: "Cleo", Email: "cleo@example.com"}
+	if err := db.CreateClient(db.DB, client); err != nil {
+		t.Fatal(err)
+	}
+
+	gross := money.New(big.NewInt(1e18), money.ETH)
+	breakdown := fees.Breakdown{Gross: gross, PlatformFee: money.Zero(money.ETH), NetworkFee: money.Zero(money.ETH), Net: gross}
+	var ids []int
+	for i := 0; i < n; i++ {
+		id, err := db.CreateContract(&db.Contract{ClientID: client.ID, Description: fmt.Sprintf("logo design #%d", i+1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveContractFees(id, breakdown); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestIssueNumbersDocumentsSequentially(t *testing.T) {
+	ids := paidContracts(t, 4)
+	year := time.Now().Year()
+
+	// An invoice numbered before the counter existed
+	if _, err := db.DB.Exec("INSERT INTO invoices (number, kind, contract_id) VALUES (?, ?, ?)", fmt.Sprintf("INV-%d-000001", year), KindInvoice, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]string{
+		ids[1]: fmt.Sprintf("INV-%d-000002", year),
+		ids[2]: fmt.Sprintf("INV-%d-000003", year),
+		ids[3]: fmt.Sprintf("INV-%d-000004", year),
+	}
+	for _, id := range ids[1:] {
+		invoice, err := IssueInvoice(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if invoice.Number != want[id] {
+			t.Errorf("contract %d invoiced as %s, want %s", id, invoice.Number, want[id])
+		}
+	}
+
+	// Reissuing returns the stored document without using up a number
+	again, err := IssueInvoice(ids[1])
+	if err != nil || again.Number != want[ids[1]] {
+		t.Errorf("reissued %v (%v), want %s", again, err, want[ids[1]])
+	}
+	receipt, err := IssueReceipt(ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number := fmt.Sprintf("RCT-%d-000001", year); receipt.Number != number {
+		t.Errorf("receipt numbered %s, want %s", receipt.Number, number)
+	}
+}
+
+func TestFailedRenderReleasesNumber(t *testing.T) {
+	ids := paidContracts(t, 1)
+	year := time.Now().Year()
+
+	failing := &db.Invoice{Kind: KindInvoice, ContractID: ids[0], CreatedAt: time.Now()}
+	_, err := db.CreateInvoice(failing, "INV", func(number string) (string, []byte, error) {
+		return "", nil, fmt.Errorf("no fonts")
+	})
+	if err == nil {
+		t.Fatal("stored an invoice that failed to render")
+	}
+
+	invoice, err := IssueInvoice(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number := fmt.Sprintf("INV-%d-000001", year); invoice.Number != number {
+		t.Errorf("invoiced as %s, want %s", invoice.Number, number)
+	}
+}
@@ -0,0 +1,261 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/money"
+)
+
+// Identifies a ledger account; balances are tracked per contract
+type Account string
+
+const (
+	ClientDeposits    Account = "client_deposits"
+	EscrowHolding     Account = "escrow_holding"
+	PlatformFees      Account = "platform_fees"
+	FreelancerPayable Account = "freelancer_payable"
+	Refunds           Account = "refunds"
+)
+
+// One side of a journal entry
+type Line struct {
+	Account Account
+	Debit   money.Amount
+	Credit  money.Amount
+}
+
+// Creates a line debiting an account
+func Debit(account Account, amount money.Amount) Line {
+	return Line{Account: account, Debit: amount, Credit: money.Zero(amount.Currency)}
+}
+
+// Creates a line crediting an account
+func Credit(account Account, amount money.Amount) Line {
+	return Line{Account: account, Debit: money.Zero(amount.Currency), Credit: amount}
+}
+
+// A balanced set of lines recording one movement of money for a contract
+type Entry struct {
+	ContractID  int
+	Kind        string
+	Reference   string
+	Description string
+	Lines       []Line
+}
+
+// Checks that debits equal credits in every currency the entry touches
+func (e *Entry) Validate() error {
+	if len(e.Lines) < 2 {
+		return fmt.Errorf("journal entry needs at least two lines")
+	}
+
+	totals := make(map[money.Currency]*big.Int)
+	for _, line := range e.Lines {
+		if line.Debit.Sign() < 0 || line.Credit.Sign() < 0 {
+			return fmt.Errorf("journal entry lines must not be negative")
+		}
+		if line.Debit.Currency != line.Credit.Currency {
+			return fmt.Errorf("journal entry line mixes %s and %s", line.Debit.Currency.Code, line.Credit.Currency.Code)
+		}
+
+		total, ok := totals[line.Debit.Currency]
+		if !ok {
+			total = new(big.Int)
+			totals[line.Debit.Currency] = total
+		}
+		total.Add(total, line.Debit.Units)
+		total.Sub(total, line.Credit.Units)
+	}
+
+	for currency, total := range totals {
+		if total.Sign() != 0 {
+			return fmt.Errorf("journal entry is unbalanced by %s", money.New(total, currency))
+		}
+	}
+	return nil
+}
+
+// Validates an entry and returns the record stored for it, so it can be posted in the same transaction as other changes
+func (e *Entry) Record() (*db.LedgerEntry, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	record := &db.LedgerEntry{
+		ContractID:  e.ContractID,
+		Kind:        e.Kind,
+		Reference:   e.Reference,
+		Description: e.Description,
+	}
+	for _, line := range e.Lines {
+		record.Lines = append(record.Lines, db.LedgerLine{
+			Account: string(line.Account),
+			Debit:   line.Debit,
+			Credit:  line.Credit,
+		})
+	}
+	return record, nil
+}
+
+// Validates and stores an entry; posting the same reference twice is a no-op
+func Post(entry *Entry) error {
+	record, err := entry.Record()
+	if err != nil {
+		return err
+	}
+
+	posted, err := db.InsertLedgerEntry(record)
+	if err != nil {
+		return err
+	}
+	if !posted {
+		log.Printf("Ledger entry %s already posted", entry.Reference)
+	}
+	return nil
+}
+
+// Builds the entry for a client's payment moving into escrow
+func PaymentEntry(contractID int, amount money.Amount, reference string) *Entry {
+	return &Entry{
+		ContractID:  contractID,
+		Kind:        "payment",
+		Reference:   reference,
+		Description: fmt.Sprintf("Payment of %s received into escrow", amount),
+		Lines: []Line{
+			Debit(EscrowHolding, amount),
+			Credit(ClientDeposits, amount),
+		},
+	}
+}
+
+// Records a client's payment moving into escrow
+func RecordPayment(contractID int, amount money.Amount, reference string) error {
+	return Post(PaymentEntry(contractID, amount, reference))
+}
+
+// Builds the entry for our platform fee being taken out of escrow
+func FeeEntry(contractID int, fee money.Amount, reference string) *Entry {
+	return &Entry{
+		ContractID:  contractID,
+		Kind:        "fee",
+		Reference:   reference,
+		Description: fmt.Sprintf("Platform fee of %s", fee),
+		Lines: []Line{
+			Debit(PlatformFees, fee),
+			Credit(EscrowHolding, fee),
+		},
+	}
+}
+
+// Records our platform fee being taken out of escrow
+func RecordFee(contractID int, fee money.Amount, reference string) error {
+	return Post(FeeEntry(contractID, fee, reference))
+}
+
+// Builds the entry for the net amount being released from escrow to the freelancer
+func ReleaseEntry(contractID int, net money.Amount, reference string) *Entry {
+	return &Entry{
+		ContractID:  contractID,
+		Kind:        "release",
+		Reference:   reference,
+		Description: fmt.Sprintf("Release of %s to freelancer", net),
+		Lines: []Line{
+			Debit(FreelancerPayable, net),
+			Credit(EscrowHolding, net),
+		},
+	}
+}
+
+// Records the net amount being released from escrow to the freelancer
+func RecordRelease(contractID int, net money.Amount, reference string) error {
+	return Post(ReleaseEntry(contractID, net, reference))
+}
+
+// Builds the entry for funds being returned from escrow to the client
+func RefundEntry(contractID int, amount money.Amount, reference string) *Entry {
+	return &Entry{
+		ContractID:  contractID,
+		Kind:        "refund",
+		Reference:   reference,
+		Description: fmt.Sprintf("Refund of %s to client", amount),
+		Lines: []Line{
+			Debit(Refunds, amount),
+			Credit(EscrowHolding, amount),
+		},
+	}
+}
+
+// Records funds being returned from escrow to the client
+func RecordRefund(contractID int, amount money.Amount, reference string) error {
+	return Post(RefundEntry(contractID, amount, reference))
+}
+
+// Returns the debit-minus-credit balance of every account for a contract in the given currency
+func Balances(contractID int, currency money.Currency) (map[Account]money.Amount, error) {
+	lines, err := db.GetLedgerLines(contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[Account]money.Amount)
+	for _, line := range lines {
+		if line.Debit.Currency != currency {
+			continue
+		}
+
+		balance, ok := balances[Account(line.Account)]
+		if !ok {
+			balance = money.Zero(currency)
+		}
+		balance, _ = balance.Add(line.Debit)
+		balance, _ = balance.Sub(line.Credit)
+		balances[Account(line.Account)] = balance
+	}
+	return balances, nil
+}
+
+// Returns the balance of a single account for a contract
+func Balance(contractID int, account Account, currency money.Currency) (money.Amount, error) {
+	balances, err := Balances(contractID, currency)
+	if err != nil {
+		return money.Amount{}, err
+	}
+	if balance, ok := balances[account]; ok {
+		return balance, nil
+	}
+	return money.Zero(currency), nil
+}
+
+// Reads the balance held at an address on-chain, in base units
+type OnChainBalanceFunc func(ctx context.Context, address string) (*big.Int, error)
+
+// Verifies that the escrow holding recorded for a contract matches what its escrow contract holds on-chain
+func CheckEscrowInvariant(ctx context.Context, contractID int, currency money.Currency, onChain OnChainBalanceFunc) error {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return err
+	}
+	if contract.Address == "" {
+		return fmt.Errorf("contract %d has not been deployed yet", contractID)
+	}
+
+	recorded, err := Balance(contractID, EscrowHolding, currency)
+	if err != nil {
+		return err
+	}
+
+	units, err := onChain(ctx, contract.Address)
+	if err != nil {
+		return fmt.Errorf("failed to get on-chain balance: %v", err)
+	}
+	actual := money.New(units, currency)
+
+	if cmp, _ := recorded.Cmp(actual); cmp != 0 {
+		return fmt.Errorf("contract %d escrow holds %s on-chain but the ledger records %s", contractID, actual, recorded)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package ledger
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/fees"
+	"smart_contract/pkg/money"
+)
+
+func eth(t *testing.T, s string) money.Amount {
+	t.Helper()
+	amount, err := money.ParseIn(s, money.ETH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amount
+}
+
+func useTestDB(t *testing.T) {
+	t.Helper()
+	if err := db.Initialize(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+}
+
+func TestEntryValidate(t *testing.T) {
+	usd, _ := money.ParseIn("1", money.USD)
+	negative := eth(t, "-1")
+
+	tests := []struct {
+		name  string
+		lines []Line
+		valid bool
+	}{
+		{"balanced", []Line{Debit(EscrowHolding, eth(t, "1")), Credit(ClientDeposits, eth(t, "1"))}, true},
+		{"split credit", []Line{
+			Debit(EscrowHolding, eth(t, "1")),
+			Credit(ClientDeposits, eth(t, "0.4")),
+			Credit(ClientDeposits, eth(t, "0.6")),
+		}, true},
+		{"balanced in each currency", []Line{
+			Debit(EscrowHolding, eth(t, "1")), Credit(ClientDeposits, eth(t, "1")),
+			Debit(EscrowHolding, usd), Credit(ClientDeposits, usd),
+		}, true},
+		{"single line", []Line{Debit(EscrowHolding, eth(t, "1"))}, false},
+		{"unbalanced", []Line{Debit(EscrowHolding, eth(t, "1")), Credit(ClientDeposits, eth(t, "0.9"))}, false},
+		{"balanced only across currencies", []Line{Debit(EscrowHolding, eth(t, "1")), Credit(ClientDeposits, money.New(eth(t, "1").Units, money.USD))}, false},
+		{"negative", []Line{Debit(EscrowHolding, negative), Credit(ClientDeposits, negative)}, false},
+		{"line mixing currencies", []Line{{Account: EscrowHolding, Debit: eth(t, "1"), Credit: money.Zero(money.USD)}, Credit(ClientDeposits, eth(t, "1"))}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := &Entry{ContractID: 1, Kind: "test", Reference: test.name, Lines: test.lines}
+			err := entry.Validate()
+			if test.valid && err != nil {
+				t.Errorf("rejected: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("accepted")
+			}
+			if _, recordErr := entry.Record(); (recordErr == nil) != (err == nil) {
+				t.Errorf("Record returned %v where Validate returned %v", recordErr, err)
+			}
+		})
+	}
+}
+
+func TestBalances(t *testing.T) {
+	useTestDB(t)
+
+	posts := []*Entry{
+		PaymentEntry(1, eth(t, "2"), "payment:1"),
+		PaymentEntry(1, eth(t, "2"), "payment:1"), // Posting a reference twice is a no-op
+		PaymentEntry(2, eth(t, "5"), "payment:2"), // Other contracts don't count
+		FeeEntry(1, eth(t, "0.1"), "fee:1"),
+		ReleaseEntry(1, eth(t, "1.5"), "release:1"),
+	}
+	for _, entry := range posts {
+		if err := Post(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Post(&Entry{ContractID: 1, Reference: "bad", Lines: []Line{Debit(EscrowHolding, eth(t, "1"))}}); err == nil {
+		t.Error("posted an unbalanced entry")
+	}
+
+	balances, err := Balances(1, money.ETH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[Account]string{
+		EscrowHolding:     "0.4",
+		ClientDeposits:    "-2",
+		PlatformFees:      "0.1",
+		FreelancerPayable: "1.5",
+	}
+	if len(balances) != len(want) {
+		t.Errorf("balances = %v", balances)
+	}
+	total := money.Zero(money.ETH)
+	for account, amount := range want {
+		if cmp, _ := balances[account].Cmp(eth(t, amount)); cmp != 0 {
+			t.Errorf("%s = %s, want %s ETH", account, balances[account], amount)
+		}
+		total, _ = total.Add(balances[account])
+	}
+	if !total.IsZero() {
+		t.Errorf("balances add up to %s, not zero", total)
+	}
+
+	if refunds, err := Balance(1, Refunds, money.ETH); err != nil || !refunds.IsZero() {
+		t.Errorf("refunds = %s (%v), want zero", refunds, err)
+	}
+	if usd, err := Balances(1, money.USD); err != nil || len(usd) != 0 {
+		t.Errorf("USD balances = %v (%v), want none", usd, err)
+	}
+}
+
+func TestCheckEscrowInvariant(t *testing.T) {
+	useTestDB(t)
+	var ids []int
+	for i := 0; i < 2; i++ {
+		id, err := db.CreateContract(&db.Contract{Description: "logo design"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gross := eth(t, "2")
+		breakdown := fees.Breakdown{Gross: gross, PlatformFee: money.Zero(money.ETH), NetworkFee: money.Zero(money.ETH), Net: gross}
+		if err := db.SaveContractFees(id, breakdown); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	deployed, undeployed := ids[0], ids[1]
+	if err := db.SetContractDeployment(deployed, "0x00000000000000000000000000000000000E5C40", "0x01", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordPayment(deployed, eth(t, "2"), "payment:1"); err != nil {
+		t.Fatal(err)
+	}
+
+	holding := func(units *big.Int) OnChainBalanceFunc {
+		return func(ctx context.Context, address string) (*big.Int, error) {
+			return units, nil
+		}
+	}
+	ctx := context.Background()
+	if err := CheckEscrowInvariant(ctx, deployed, money.ETH, holding(eth(t, "2").Units)); err != nil {
+		t.Errorf("matching balances: %v", err)
+	}
+	if err := CheckEscrowInvariant(ctx, deployed, money.ETH, holding(eth(t, "1.9").Units)); err == nil {
+		t.Error("accepted an escrow holding less than the ledger records")
+	}
+	if err := CheckEscrowInvariant(ctx, undeployed, money.ETH, holding(big.NewInt(0))); err == nil {
+		t.Error("accepted a contract that was never deployed")
+	}
+}
@@ -0,0 +1,224 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// A currency and the number of decimal places between its display unit and base unit
+type Currency struct {
+	Code     string
+	Decimals int
+}
+
+var (
+	ETH   = Currency{Code: "ETH", Decimals: 18}
+	MATIC = Currency{Code: "MATIC", Decimals: 18}
+	USDC  = Currency{Code: "USDC", Decimals: 6}
+	USD   = Currency{Code: "USD", Decimals: 2}
+	EUR   = Currency{Code: "EUR", Decimals: 2}
+)
+
+var currencies = map[string]Currency{
+	ETH.Code:   ETH,
+	MATIC.Code: MATIC,
+	USDC.Code:  USDC,
+	USD.Code:   USD,
+	EUR.Code:   EUR,
+}
+
+// Looks up a known currency by its code
+func LookupCurrency(code string) (Currency, error) {
+	currency, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("unknown currency %q", code)
+	}
+	return currency, nil
+}
+
+// An exact amount of money held as an integer number of base units (wei, cents, ...)
+type Amount struct {
+	Units    *big.Int
+	Currency Currency
+}
+
+// Creates an amount from a count of base units
+func New(units *big.Int, currency Currency) Amount {
+	return Amount{Units: new(big.Int).Set(units), Currency: currency}
+}
+
+// Creates a zero amount in the given currency
+func Zero(currency Currency) Amount {
+	return Amount{Units: new(big.Int), Currency: currency}
+}
+
+// Creates an amount from a decimal string of base units, as stored in the database
+func FromUnits(units string, currency Currency) (Amount, error) {
+	value, ok := new(big.Int).SetString(units, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid base unit amount %q", units)
+	}
+	return Amount{Units: value, Currency: currency}, nil
+}
+
+// Parses a user supplied amount such as "1.5 ETH" or "20 USD"
+func Parse(s string) (Amount, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Amount{}, fmt.Errorf("amount %q must be a number followed by a currency", s)
+	}
+
+	currency, err := LookupCurrency(fields[1])
+	if err != nil {
+		return Amount{}, err
+	}
+	return ParseIn(fields[0], currency)
+}
+
+// Parses a decimal number such as "1.5" as an amount of the given currency
+func ParseIn(s string, currency Currency) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(fraction) > currency.Decimals {
+		return Amount{}, fmt.Errorf("amount %q has more than %d decimal places", s, currency.Decimals)
+	}
+
+	digits := whole + fraction + strings.Repeat("0", currency.Decimals-len(fraction))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Amount{}, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	units, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		units.Neg(units)
+	}
+	return Amount{Units: units, Currency: currency}, nil
+}
+
+// Formats the amount in display units without trailing zeros, e.g. "1.5"
+func (a Amount) Decimal() string {
+	units := a.units()
+	abs := new(big.Int).Abs(units).String()
+
+	if len(abs) <= a.Currency.Decimals {
+		abs = strings.Repeat("0", a.Currency.Decimals-len(abs)+1) + abs
+	}
+	whole := abs[:len(abs)-a.Currency.Decimals]
+	fraction := strings.TrimRight(abs[len(abs)-a.Currency.Decimals:], "0")
+
+	result := whole
+	if fraction != "" {
+		result += "." + fraction
+	}
+	if units.Sign() < 0 {
+		result = "-" + result
+	}
+	return result
+}
+
+// Formats the amount with its currency code, e.g. "1.5 ETH"
+func (a Amount) String() string {
+	return a.Decimal() + " " + a.Currency.Code
+}
+
+// Reports whether the amount is zero
+func (a Amount) IsZero() bool {
+	return a.units().Sign() == 0
+}
+
+// Returns -1, 0 or 1 depending on the sign of the amount
+func (a Amount) Sign() int {
+	return a.units().Sign()
+}
+
+// Adds two amounts of the same currency
+func (a Amount) Add(b Amount) (Amount, error) {
+	if err := a.sameCurrency(b); err != nil {
+		return Amount{}, err
+	}
+	return Amount{Units: new(big.Int).Add(a.units(), b.units()), Currency: a.Currency}, nil
+}
+
+// Subtracts an amount of the same currency
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if err := a.sameCurrency(b); err != nil {
+		return Amount{}, err
+	}
+	return Amount{Units: new(big.Int).Sub(a.units(), b.units()), Currency: a.Currency}, nil
+}
+
+// Compares two amounts of the same currency, returning -1, 0 or 1
+func (a Amount) Cmp(b Amount) (int, error) {
+	if err := a.sameCurrency(b); err != nil {
+		return 0, err
+	}
+	return a.units().Cmp(b.units()), nil
+}
+
+// Multiplies the amount by a decimal rate such as 0.05, rounding down to whole base units
+func (a Amount) MulRate(rate float64) Amount {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	product := new(big.Rat).Mul(new(big.Rat).SetInt(a.units()), r)
+	return Amount{Units: new(big.Int).Quo(product.Num(), product.Denom()), Currency: a.Currency}
+}
+
+// Converts the amount at a rate of target display units per source display unit, rounding down to whole base units
+func (a Amount) Convert(rate *big.Rat, to Currency) Amount {
+	value := new(big.Rat).Mul(new(big.Rat).SetInt(a.units()), rate)
+	value.Mul(value, new(big.Rat).SetFrac(pow10(to.Decimals), pow10(a.Currency.Decimals)))
+	return Amount{Units: new(big.Int).Quo(value.Num(), value.Denom()), Currency: to}
+}
+
+// Encodes the amount as a string such as "1.5 ETH"
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// Decodes an amount from a string such as "1.5 ETH"
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Stores the amount as its decimal count of base units
+func (a Amount) Value() (driver.Value, error) {
+	return a.units().String(), nil
+}
+
+func (a Amount) units() *big.Int {
+	if a.Units == nil {
+		return new(big.Int)
+	}
+	return a.Units
+}
+
+func (a Amount) sameCurrency(b Amount) error {
+	if a.Currency != b.Currency {
+		return fmt.Errorf("currency mismatch: %s and %s", a.Currency.Code, b.Currency.Code)
+	}
+	return nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
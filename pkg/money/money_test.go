@@ -0,0 +1,220 @@
+package money
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestParseIn(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency Currency
+		units    string
+	}{
+		{"1.5", ETH, "1500000000000000000"},
+		{"1", ETH, "1000000000000000000"},
+		{"0.000000000000000001", ETH, "1"},
+		{".5", ETH, "500000000000000000"},
+		{"5.", ETH, "5000000000000000000"},
+		{"-2.25", USD, "-225"},
+		{" 20 ", USD, "2000"},
+		{"0.1", USDC, "100000"},
+		{"123456789012345678901234567890", ETH, "123456789012345678901234567890000000000000000000"},
+	}
+	for _, test := range tests {
+		amount, err := ParseIn(test.input, test.currency)
+		if err != nil {
+			t.Errorf("ParseIn(%q): %v", test.input, err)
+			continue
+		}
+		if amount.Units.String() != test.units || amount.Currency != test.currency {
+			t.Errorf("ParseIn(%q) = %s %s, want %s %s", test.input, amount.Units, amount.Currency.Code, test.units, test.currency.Code)
+		}
+	}
+}
+
+func TestParseInRejects(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency Currency
+	}{
+		{"", ETH},
+		{".", ETH},
+		{"-", ETH},
+		{"1.005", USD},
+		{"1,5", ETH},
+		{"1e18", ETH},
+		{"--1", ETH},
+		{"+1", ETH},
+		{"1.2.3", ETH},
+		{"0x10", ETH},
+	}
+	for _, test := range tests {
+		if amount, err := ParseIn(test.input, test.currency); err == nil {
+			t.Errorf("ParseIn(%q, %s) = %s, want an error", test.input, test.currency.Code, amount)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	amount, err := Parse("1.5 eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount.String() != "1.5 ETH" {
+		t.Errorf("Parse = %s, want 1.5 ETH", amount)
+	}
+
+	for _, input := range []string{"1.5", "ETH", "1.5 DOGE", "1.5 ETH extra"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) succeeded", input)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		units    int64
+		currency Currency
+		want     string
+	}{
+		{0, USD, "0"},
+		{5, USD, "0.05"},
+		{150, USD, "1.5"},
+		{-150, USD, "-1.5"},
+		{100, USD, "1"},
+		{1, ETH, "0.000000000000000001"},
+		{1_500_000, USDC, "1.5"},
+	}
+	for _, test := range tests {
+		if got := New(big.NewInt(test.units), test.currency).Decimal(); got != test.want {
+			t.Errorf("Decimal(%d %s) = %q, want %q", test.units, test.currency.Code, got, test.want)
+		}
+	}
+
+	// A zero value amount behaves as zero instead of panicking
+	if got := (Amount{Currency: USD}).String(); got != "0 USD" {
+		t.Errorf("zero value = %q, want 0 USD", got)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, _ := Parse("1.5 ETH")
+	b, _ := Parse("0.25 ETH")
+	usd, _ := Parse("1 USD")
+
+	sum, err := a.Add(b)
+	if err != nil || sum.String() != "1.75 ETH" {
+		t.Errorf("1.5 + 0.25 = %s (%v)", sum, err)
+	}
+	diff, err := b.Sub(a)
+	if err != nil || diff.String() != "-1.25 ETH" || diff.Sign() != -1 {
+		t.Errorf("0.25 - 1.5 = %s (%v)", diff, err)
+	}
+	if cmp, err := a.Cmp(b); err != nil || cmp != 1 {
+		t.Errorf("1.5 cmp 0.25 = %d (%v)", cmp, err)
+	}
+
+	// Operations never modify their operands
+	if a.String() != "1.5 ETH" || b.String() != "0.25 ETH" {
+		t.Errorf("operands changed to %s and %s", a, b)
+	}
+
+	if _, err := a.Add(usd); err == nil {
+		t.Error("adding USD to ETH succeeded")
+	}
+	if _, err := a.Sub(usd); err == nil {
+		t.Error("subtracting USD from ETH succeeded")
+	}
+	if _, err := a.Cmp(usd); err == nil {
+		t.Error("comparing USD with ETH succeeded")
+	}
+
+	if !Zero(ETH).IsZero() || a.IsZero() {
+		t.Error("IsZero is wrong")
+	}
+}
+
+func TestNewCopiesUnits(t *testing.T) {
+	units := big.NewInt(100)
+	amount := New(units, USD)
+	units.SetInt64(5)
+	if amount.Units.Int64() != 100 {
+		t.Errorf("amount changed to %s with its source", amount)
+	}
+}
+
+func TestMulRate(t *testing.T) {
+	tests := []struct {
+		amount string
+		rate   float64
+		want   string
+	}{
+		{"100 USD", 0.05, "5 USD"},
+		{"0.19 USD", 0.05, "0 USD"}, // 0.95 cents rounds down
+		{"1 ETH", 0.025, "0.025 ETH"},
+		{"0.000000000000000099 ETH", 0.1, "0.000000000000000009 ETH"},
+		{"10 USD", 0, "0 USD"},
+	}
+	for _, test := range tests {
+		amount, _ := Parse(test.amount)
+		if got := amount.MulRate(test.rate).String(); got != test.want {
+			t.Errorf("%s * %v = %s, want %s", test.amount, test.rate, got, test.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	usd, _ := Parse("1500 USD")
+	// 2000 USD per ETH
+	eth := usd.Convert(big.NewRat(1, 2000), ETH)
+	if eth.String() != "0.75 ETH" {
+		t.Errorf("1500 USD = %s, want 0.75 ETH", eth)
+	}
+
+	back := eth.Convert(big.NewRat(2000, 1), USD)
+	if back.String() != "1500 USD" {
+		t.Errorf("0.75 ETH = %s, want 1500 USD", back)
+	}
+
+	// Converting to fewer decimals rounds down
+	wei, _ := Parse("0.000000000000000001 ETH")
+	if got := wei.Convert(big.NewRat(2000, 1), USD); !got.IsZero() {
+		t.Errorf("1 wei = %s, want 0 USD", got)
+	}
+}
+
+func TestJSONAndValue(t *testing.T) {
+	amount, _ := Parse("1.5 ETH")
+
+	data, err := json.Marshal(amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"1.5 ETH"` {
+		t.Errorf("JSON = %s", data)
+	}
+	var decoded Amount
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if cmp, err := decoded.Cmp(amount); err != nil || cmp != 0 {
+		t.Errorf("decoded %s, want %s", decoded, amount)
+	}
+	if err := json.Unmarshal([]byte(`"1.5"`), &decoded); err == nil {
+		t.Error("decoded an amount without a currency")
+	}
+
+	value, err := amount.Value()
+	if err != nil || value != "1500000000000000000" {
+		t.Errorf("stored as %v (%v), want base units", value, err)
+	}
+	stored, err := FromUnits(value.(string), ETH)
+	if err != nil || stored.String() != "1.5 ETH" {
+		t.Errorf("loaded %s (%v)", stored, err)
+	}
+	if _, err := FromUnits("1.5", ETH); err == nil {
+		t.Error("loaded a decimal as base units")
+	}
+}
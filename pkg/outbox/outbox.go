@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+)
+
+// Delivers queued emails, retrying failures with exponential backoff
+type Dispatcher struct {
+	Sender      email.Sender
+	MaxAttempts int
+	Backoff     time.Duration
+	BatchSize   int
+	OnBounce    func(email.Bounce) // Called when the server rejects a recipient outright
+}
+
+// Sends every email that is due, then repeats every interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil {
+			log.Printf("Error dispatching emails: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Attempts delivery of the emails that are due and returns how many were sent
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	batch := d.BatchSize
+	if batch <= 0 {
+		batch = 50
+	}
+
+	emails, err := db.GetDueEmails(time.Now(), batch)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, e := range emails {
+		if err := d.deliver(ctx, e); err != nil {
+			log.Printf("Error sending email %d (%s) to %s: %v", e.ID, e.Event, e.Recipient, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// Sends one email and records the outcome, giving up after MaxAttempts or on a permanent error
+func (d *Dispatcher) deliver(ctx context.Context, e db.OutboxEmail) error {
+	attempts := e.Attempts + 1
+	err := d.Sender.Send(ctx, &email.Message{
+		To:          []string{e.Recipient},
+		ReplyTo:     e.ReplyTo,
+		Unsubscribe: e.Unsubscribe,
+		Subject:     e.Subject,
+		Text:        e.Text,
+		HTML:        e.HTML,
+		Attachments: e.Attachments,
+	})
+	if err == nil {
+		return db.MarkEmailSent(e.ID, attempts)
+	}
+
+	var permanent *email.PermanentError
+	if errors.As(err, &permanent) && permanent.Recipient != "" && d.OnBounce != nil {
+		d.OnBounce(email.Bounce{Recipient: permanent.Recipient, Kind: email.HardBounce, Reason: permanent.Error()})
+	}
+
+	status, next := db.OutboxPending, time.Now().Add(d.Backoff*time.Duration(1<<(attempts-1)))
+	if email.IsPermanent(err) || attempts >= d.MaxAttempts {
+		status = db.OutboxFailed
+	}
+	if updateErr := db.MarkEmailAttempt(e.ID, status, attempts, err.Error(), next); updateErr != nil {
+		return updateErr
+	}
+	return fmt.Errorf("attempt %d failed: %v", attempts, err)
+}
@@ -0,0 +1,196 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+)
+
+func useTestDB(t *testing.T) {
+	t.Helper()
+	if err := db.Initialize(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+}
+
+// Fails each send with the next scripted error, succeeding once the script runs out
+type scriptedSender struct {
+	errs []error
+	sent int
+}
+
+func (s *scriptedSender) Send(ctx context.Context, msg *email.Message) error {
+	s.sent++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func queuedEmail(t *testing.T) db.OutboxEmail {
+	t.Helper()
+	err := db.EnqueueEmails([]db.OutboxEmail{{ContractID: 1, Event: "status:payment_made", Recipient: "cleo@example.com", Subject: "Payment received", Text: "Thanks"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e db.OutboxEmail
+	err = db.DB.QueryRow("SELECT id, status, attempts, next_attempt_at FROM email_outbox").Scan(&e.ID, &e.Status, &e.Attempts, &e.NextAttemptAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	temporary := errors.New("421 try again later")
+	permanent := &email.PermanentError{Err: errors.New("550 no such user"), Recipient: "cleo@example.com"}
+	backoff := time.Minute
+
+	// After each dispatch, the email's status and how far its next attempt was pushed back
+	type attempt struct {
+		status string
+		delay  time.Duration
+	}
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts []attempt
+		bounced  bool
+	}{
+		{"sent first time", nil, []attempt{{db.OutboxSent, 0}}, false},
+		{"sent on retry", []error{temporary, temporary}, []attempt{
+			{db.OutboxPending, backoff},
+			{db.OutboxPending, 2 * backoff},
+			{db.OutboxSent, 0},
+		}, false},
+		{"gives up after max attempts", []error{temporary, temporary, temporary}, []attempt{
+			{db.OutboxPending, backoff},
+			{db.OutboxPending, 2 * backoff},
+			{db.OutboxFailed, 0},
+		}, false},
+		{"permanent failure", []error{permanent}, []attempt{{db.OutboxFailed, 0}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestDB(t)
+			queued := queuedEmail(t)
+			sender := &scriptedSender{errs: test.errs}
+			var bounces []email.Bounce
+			dispatcher := &Dispatcher{Sender: sender, MaxAttempts: 3, Backoff: backoff, OnBounce: func(b email.Bounce) { bounces = append(bounces, b) }}
+
+			for i, want := range test.attempts {
+				before := time.Now()
+				sent, err := dispatcher.Dispatch(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if wantSent := want.status == db.OutboxSent; (sent == 1) != wantSent {
+					t.Errorf("attempt %d: dispatch reported %d sent", i+1, sent)
+				}
+
+				var e db.OutboxEmail
+				err = db.DB.QueryRow("SELECT status, attempts, next_attempt_at FROM email_outbox WHERE id = ?", queued.ID).Scan(&e.Status, &e.Attempts, &e.NextAttemptAt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if e.Status != want.status || e.Attempts != i+1 {
+					t.Fatalf("attempt %d: email is %s after %d attempts, want %s", i+1, e.Status, e.Attempts, want.status)
+				}
+				if want.status == db.OutboxPending {
+					if e.NextAttemptAt.Before(before.Add(want.delay)) || e.NextAttemptAt.After(time.Now().Add(want.delay)) {
+						t.Errorf("attempt %d: retry at %s, want %s from now", i+1, e.NextAttemptAt, want.delay)
+					}
+					// Nothing is sent before the retry is due
+					if sent, _ := dispatcher.Dispatch(context.Background()); sent != 0 || sender.sent != i+1 {
+						t.Fatalf("attempt %d: sent again before the retry was due", i+1)
+					}
+					if _, err := db.DB.Exec("UPDATE email_outbox SET next_attempt_at = ? WHERE id = ?", time.Now().UTC().Add(-time.Second), queued.ID); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			if (len(bounces) == 1) != test.bounced {
+				t.Errorf("bounces = %v", bounces)
+			}
+			// Delivered and abandoned emails are never sent again
+			if sent, _ := dispatcher.Dispatch(context.Background()); sent != 0 || sender.sent != len(test.attempts) {
+				t.Errorf("sent %d times over %d attempts", sender.sent, len(test.attempts))
+			}
+		})
+	}
+}
+
+func TestStatusChangeRequiresCurrentStatus(t *testing.T) {
+	useTestDB(t)
+	id, err := db.CreateContract(&db.Contract{Description: "logo design", Status: "payment_made"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	announce := []db.OutboxEmail{{ContractID: id, Event: "status:reqs_completed", Recipient: "cleo@example.com", Subject: "Work delivered", Text: "Done"}}
+
+	// A second writer that read the same status loses, and its emails aren't queued
+	if err := db.UpdateContractStatusWithEmails(id, "payment_made", "reqs_completed", announce); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateContractStatusWithEmails(id, "payment_made", "disputed", []db.OutboxEmail{{ContractID: id, Event: "status:disputed", Recipient: "cleo@example.com", Subject: "Disputed", Text: "Disputed"}}); err == nil {
+		t.Fatal("moved a contract from a status it had already left")
+	}
+
+	contract, err := db.GetContractByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contract.Status != "reqs_completed" {
+		t.Errorf("contract is %s, want reqs_completed", contract.Status)
+	}
+	var queued int
+	if err := db.DB.QueryRow("SELECT COUNT(*) FROM email_outbox WHERE contract_id = ?", id).Scan(&queued); err != nil {
+		t.Fatal(err)
+	}
+	if queued != 1 {
+		t.Errorf("queued %d emails, want only the winning change's", queued)
+	}
+}
+
+func TestOutboxStatsAreScopedToContract(t *testing.T) {
+	useTestDB(t)
+	err := db.EnqueueEmails([]db.OutboxEmail{
+		{ContractID: 1, Event: "status:payment_made:1", Recipient: "cleo@example.com", Subject: "Payment received", Text: "Thanks"},
+		{ContractID: 2, Event: "status:payment_made:1", Recipient: "someone@example.com", Subject: "Payment received", Text: "Thanks"},
+		{ContractID: 2, Event: "status:refunded:1", Recipient: "else@example.com", Subject: "Refund issued", Text: "Sorry"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DB.Exec("UPDATE email_outbox SET status = ? WHERE contract_id = 2", db.OutboxFailed); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := db.GetOutboxStats(1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Counts[db.OutboxPending] != 1 || stats.Counts[db.OutboxFailed] != 0 || stats.OldestPending == nil {
+		t.Errorf("contract 1 counts = %v", stats.Counts)
+	}
+	// Another contract's recipients never show up
+	if len(stats.Failures) != 0 {
+		t.Errorf("contract 1 failures = %+v", stats.Failures)
+	}
+
+	if stats, err = db.GetOutboxStats(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Counts[db.OutboxFailed] != 2 || stats.OldestPending != nil || len(stats.Failures) != 1 || stats.Failures[0].Recipient != "else@example.com" {
+		t.Errorf("contract 2 stats = %+v", stats)
+	}
+}
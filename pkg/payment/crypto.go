@@ -0,0 +1,286 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/money"
+)
+
+// A transfer of the chain's native coin observed on-chain
+type Transfer struct {
+	TxHash      string
+	LogIndex    uint
+	BlockNumber uint64
+	From        string
+	To          string
+	Amount      money.Amount
+}
+
+// Uniquely identifies a transfer so the same deposit is never counted twice
+func (t Transfer) Key() string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(t.TxHash), t.LogIndex)
+}
+
+// Reads transfers from a chain, implemented by an RPC node or a simulated chain
+type ChainSource interface {
+	// Returns the number of the most recent block
+	LatestBlock(ctx context.Context) (uint64, error)
+	// Returns transfers to any of the addresses within the inclusive block range
+	Transfers(ctx context.Context, fromBlock, toBlock uint64, addresses []string) ([]Transfer, error)
+}
+
+// Resolves where a contract's deposit should be sent, e.g. the deployed escrow address
+type DepositAddressFunc func(ctx context.Context, contractID int) (string, error)
+
+// A crypto deposit being watched
+type deposit struct {
+	intent  *Intent
+	address string
+}
+
+// Accepts payment by watching the chain for deposits to a per-contract address. Deposits, what they have received
+// and how far the chain has been scanned are kept in the database, so a restart picks up where it left off
+type CryptoProvider struct {
+	mu            sync.Mutex
+	source        ChainSource
+	addressFor    DepositAddressFunc
+	currency      money.Currency
+	confirmations uint64
+}
+
+// Creates a crypto provider paying in the chain's native currency. Escrows only hold the native coin, so tokens aren't
+// accepted
+func NewCryptoProvider(source ChainSource, addressFor DepositAddressFunc, currency money.Currency, confirmations uint64) *CryptoProvider {
+	return &CryptoProvider{
+		source:        source,
+		addressFor:    addressFor,
+		currency:      currency,
+		confirmations: confirmations,
+	}
+}
+
+func (p *CryptoProvider) Name() string {
+	return "crypto"
+}
+
+func (p *CryptoProvider) CreateIntent(ctx context.Context, contractID int, amount money.Amount) (*Intent, error) {
+	if amount.Currency != p.currency {
+		return nil, fmt.Errorf("crypto deposits are accepted in %s, not %s", p.currency.Code, amount.Currency.Code)
+	}
+
+	address, err := p.addressFor(ctx, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve deposit address: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Start watching from the current head so older transfers are not attributed to this intent
+	if _, started, err := db.GetPaymentCursor(p.Name()); err != nil {
+		return nil, err
+	} else if !started {
+		latest, err := p.source.LatestBlock(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest block: %v", err)
+		}
+		if err := db.SetPaymentCursor(p.Name(), latest+1); err != nil {
+			return nil, err
+		}
+	}
+
+	intent := &Intent{
+		ID:          fmt.Sprintf("crypto_%d_%s", contractID, strings.ToLower(address)),
+		Provider:    p.Name(),
+		ContractID:  contractID,
+		Amount:      amount,
+		Status:      IntentPending,
+		PaymentLink: depositLink(address, amount),
+		CreatedAt:   time.Now(),
+	}
+
+	// Asking again for the same deposit returns it as it stands, with whatever has been received towards it
+	if stored, err := db.GetPaymentIntentByID(intent.ID); err == nil {
+		intent.Amount = stored.Amount
+		intent.Status = IntentStatus(stored.Status)
+	}
+	return intent, nil
+}
+
+func (p *CryptoProvider) FetchStatus(ctx context.Context, intentID string) (IntentStatus, error) {
+	intent, err := db.GetPaymentIntentByID(intentID)
+	if err != nil || intent.Provider != p.Name() {
+		return "", fmt.Errorf("payment intent %s not found", intentID)
+	}
+	return IntentStatus(intent.Status), nil
+}
+
+func (p *CryptoProvider) Refund(ctx context.Context, intentID string, amount money.Amount) error {
+	return fmt.Errorf("crypto deposits are refunded on-chain from the escrow contract")
+}
+
+func (p *CryptoProvider) HandleWebhook(ctx context.Context, headers http.Header, payload []byte) (*Event, error) {
+	return nil, fmt.Errorf("crypto provider does not accept webhooks")
+}
+
+// Scans confirmed blocks since the last poll and passes the payment events they produce to handle. The scan is only
+// marked done once every event was handled, so a failure or restart rescans the blocks; transfers whose events were
+// already stored are skipped
+func (p *CryptoProvider) Poll(ctx context.Context, handle EventHandler) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cursor, started, err := db.GetPaymentCursor(p.Name())
+	if err != nil || !started {
+		return err
+	}
+	latest, err := p.source.LatestBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %v", err)
+	}
+	if latest < p.confirmations {
+		return nil
+	}
+	confirmed := latest - p.confirmations
+	if cursor > confirmed {
+		return nil
+	}
+
+	deposits, err := p.deposits(ctx)
+	if err != nil {
+		return err
+	}
+	if len(deposits) > 0 {
+		var addresses []string
+		for _, deposit := range deposits {
+			addresses = append(addresses, deposit.address)
+		}
+
+		transfers, err := p.source.Transfers(ctx, cursor, confirmed, addresses)
+		if err != nil {
+			return fmt.Errorf("failed to get transfers: %v", err)
+		}
+
+		var failed error
+		for _, transfer := range transfers {
+			event, err := p.apply(deposits, transfer)
+			if err != nil {
+				return err
+			}
+			if event == nil {
+				continue
+			}
+			if err := handle(ctx, event); err != nil {
+				log.Printf("Error handling crypto deposit %s: %v", event.ID, err)
+				failed = err
+			}
+		}
+		if failed != nil {
+			return fmt.Errorf("failed to handle every deposit up to block %d: %v", confirmed, failed)
+		}
+	}
+
+	return db.SetPaymentCursor(p.Name(), confirmed+1)
+}
+
+// Polls the chain every interval and passes events to handle until ctx is cancelled
+func (p *CryptoProvider) Watch(ctx context.Context, interval time.Duration, handle EventHandler) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Poll(ctx, handle); err != nil {
+				log.Printf("Error polling for crypto deposits: %v", err)
+			}
+		}
+	}
+}
+
+// Loads the deposits still waiting for funds
+func (p *CryptoProvider) deposits(ctx context.Context) ([]*deposit, error) {
+	intents, err := db.ListPaymentIntents(p.Name(), string(IntentPending))
+	if err != nil {
+		return nil, err
+	}
+
+	var deposits []*deposit
+	for _, stored := range intents {
+		address, err := p.addressFor(ctx, stored.ContractID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve deposit address: %v", err)
+		}
+		deposits = append(deposits, &deposit{
+			intent: &Intent{
+				ID:         stored.ID,
+				Provider:   stored.Provider,
+				ContractID: stored.ContractID,
+				Amount:     stored.Amount,
+				Status:     IntentStatus(stored.Status),
+			},
+			address: strings.ToLower(address),
+		})
+	}
+	return deposits, nil
+}
+
+// Reports the deposit a transfer completes. The escrow reverts any call other than a single initiateEscrow paying
+// exactly its amount, so a transfer to it that went through is the whole deposit
+func (p *CryptoProvider) apply(deposits []*deposit, transfer Transfer) (*Event, error) {
+	if transfer.Amount.Currency != p.currency {
+		return nil, nil
+	}
+	id := "crypto_" + transfer.Key()
+	if seen, err := db.PaymentEventReceived(p.Name(), id); err != nil || seen {
+		return nil, err
+	}
+
+	deposit := match(deposits, transfer)
+	if deposit == nil {
+		log.Printf("Unmatched deposit of %s to %s in %s", transfer.Amount, transfer.To, transfer.TxHash)
+		return nil, nil
+	}
+
+	if cmp, _ := transfer.Amount.Cmp(deposit.intent.Amount); cmp != 0 {
+		log.Printf("Deposit of %s to %s in %s doesn't match the %s its escrow accepts", transfer.Amount, transfer.To, transfer.TxHash, deposit.intent.Amount)
+		return nil, nil
+	}
+	deposit.intent.Status = IntentSucceeded
+
+	// The escrow contract's own record of the deposit is posted under the same reference, so it is only counted once
+	return &Event{
+		ID:         id,
+		Type:       EventSucceeded,
+		Provider:   p.Name(),
+		IntentID:   deposit.intent.ID,
+		ContractID: deposit.intent.ContractID,
+		Status:     IntentSucceeded,
+		Amount:     transfer.Amount,
+		Reference:  "chain:" + transfer.Key(),
+	}, nil
+}
+
+// Finds the pending deposit to the address a transfer was sent to
+func match(deposits []*deposit, transfer Transfer) *deposit {
+	for _, deposit := range deposits {
+		if deposit.address == strings.ToLower(transfer.To) && deposit.intent.Status == IntentPending {
+			return deposit
+		}
+	}
+	return nil
+}
+
+// Builds an EIP-681 payment URI for wallets, calling initiateEscrow since the escrow contract rejects plain transfers
+func depositLink(address string, amount money.Amount) string {
+	return fmt.Sprintf("ethereum:%s/initiateEscrow?value=%s", address, amount.Units)
+}
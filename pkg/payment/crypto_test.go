@@ -0,0 +1,184 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/money"
+)
+
+const escrowAddress = "0x00000000000000000000000000000000000E5C40"
+
+func useTestDB(t *testing.T) {
+	t.Helper()
+	if err := db.Initialize(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+}
+
+func eth(t *testing.T, s string) money.Amount {
+	t.Helper()
+	amount, err := money.ParseIn(s, money.ETH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amount
+}
+
+func newTestCryptoProvider(chain *SimulatedChain) *CryptoProvider {
+	addressFor := func(ctx context.Context, contractID int) (string, error) {
+		return escrowAddress, nil
+	}
+	return NewCryptoProvider(chain, addressFor, money.ETH, 2)
+}
+
+// Stores and credits events the way the contract's payment handler does, collecting them for the test
+type recordingHandler struct {
+	events []*Event
+}
+
+func (h *recordingHandler) handle(ctx context.Context, event *Event) error {
+	if _, err := db.InsertPaymentEvent(&db.PaymentEvent{
+		Provider:  event.Provider,
+		EventID:   event.ID,
+		Type:      event.Type,
+		IntentID:  event.IntentID,
+		Status:    string(event.Status),
+		Amount:    event.Amount,
+		Reference: event.Reference,
+	}); err != nil {
+		return err
+	}
+
+	intent, err := db.GetPaymentIntentByID(event.IntentID)
+	if err != nil {
+		return err
+	}
+	received, err := intent.Received.Add(event.Amount)
+	if err != nil {
+		return err
+	}
+	entry := &db.LedgerEntry{ContractID: intent.ContractID, Kind: "payment", Reference: event.Reference, Lines: []db.LedgerLine{
+		{Account: "escrow_holding", Debit: event.Amount, Credit: money.Zero(event.Amount.Currency)},
+		{Account: "client_deposits", Debit: money.Zero(event.Amount.Currency), Credit: event.Amount},
+	}}
+	if _, err := db.CreditPaymentIntent(intent.ID, string(event.Status), received, entry); err != nil {
+		return err
+	}
+
+	h.events = append(h.events, event)
+	return nil
+}
+
+func requestDeposit(t *testing.T, provider *CryptoProvider, amount money.Amount) *Intent {
+	t.Helper()
+	intent, err := provider.CreateIntent(context.Background(), 1, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.CreatePaymentIntent(&db.PaymentIntent{
+		ID:          intent.ID,
+		Provider:    intent.Provider,
+		ContractID:  intent.ContractID,
+		Amount:      intent.Amount,
+		Status:      string(intent.Status),
+		PaymentLink: intent.PaymentLink,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return intent
+}
+
+func TestCryptoDepositLinkCallsInitiateEscrow(t *testing.T) {
+	useTestDB(t)
+	intent := requestDeposit(t, newTestCryptoProvider(NewSimulatedChain()), eth(t, "1"))
+
+	want := fmt.Sprintf("ethereum:%s/initiateEscrow?value=1000000000000000000", escrowAddress)
+	if intent.PaymentLink != want {
+		t.Errorf("payment link = %s, want %s", intent.PaymentLink, want)
+	}
+}
+
+func TestCryptoDepositsSurviveRestart(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+	chain := NewSimulatedChain()
+
+	// Sent before the deposit was requested, so it must not count towards it
+	chain.Send("0xclient", escrowAddress, eth(t, "1"))
+
+	provider := newTestCryptoProvider(chain)
+	intent := requestDeposit(t, provider, eth(t, "1"))
+	handler := &recordingHandler{}
+
+	// Not yet confirmed
+	deposit := chain.Send("0xclient", escrowAddress, eth(t, "1"))
+	chain.Mine(1)
+	if err := provider.Poll(ctx, handler.handle); err != nil {
+		t.Fatal(err)
+	}
+	if len(handler.events) != 0 {
+		t.Fatalf("events = %+v before the deposit was confirmed", handler.events)
+	}
+
+	// A new provider and a repeated request start from what was stored
+	provider = newTestCryptoProvider(chain)
+	if again := requestDeposit(t, provider, eth(t, "1")); again.ID != intent.ID {
+		t.Fatalf("requested %s again as %s", intent.ID, again.ID)
+	}
+
+	chain.Mine(1)
+	if err := provider.Poll(ctx, handler.handle); err != nil {
+		t.Fatal(err)
+	}
+	if len(handler.events) != 1 || handler.events[0].Type != EventSucceeded {
+		t.Fatalf("events = %+v, want the deposit", handler.events)
+	}
+	if want := "chain:" + deposit.Key(); handler.events[0].Reference != want {
+		t.Errorf("reference = %s, want %s", handler.events[0].Reference, want)
+	}
+	if status, err := provider.FetchStatus(ctx, intent.ID); err != nil || status != IntentSucceeded {
+		t.Errorf("status = %s (%v), want succeeded", status, err)
+	}
+
+	// Scanned blocks are not scanned again, and a replayed deposit isn't counted twice
+	chain.Replay(deposit)
+	chain.Mine(2)
+	if err := provider.Poll(ctx, handler.handle); err != nil {
+		t.Fatal(err)
+	}
+	if len(handler.events) != 1 {
+		t.Errorf("events = %+v after polling again", handler.events)
+	}
+}
+
+func TestCryptoPollRescansAfterFailure(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+	chain := NewSimulatedChain()
+	provider := newTestCryptoProvider(chain)
+	requestDeposit(t, provider, eth(t, "1"))
+
+	chain.Send("0xclient", escrowAddress, eth(t, "1"))
+	chain.Mine(2)
+	failing := func(ctx context.Context, event *Event) error {
+		return fmt.Errorf("database is locked")
+	}
+	if err := provider.Poll(ctx, failing); err == nil || !strings.Contains(err.Error(), "database is locked") {
+		t.Fatalf("poll error = %v, want the handler's error", err)
+	}
+
+	handler := &recordingHandler{}
+	if err := provider.Poll(ctx, handler.handle); err != nil {
+		t.Fatal(err)
+	}
+	if len(handler.events) != 1 || handler.events[0].Type != EventSucceeded {
+		t.Fatalf("events = %+v, want the deposit found again", handler.events)
+	}
+}
@@ -0,0 +1,87 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"smart_contract/pkg/money"
+)
+
+// Reads native coin transfers from an Ethereum-compatible RPC node
+type EthChainSource struct {
+	client  *ethclient.Client
+	native  money.Currency
+	chainID *big.Int
+}
+
+// Creates a chain source that reports native transfers in native
+func NewEthChainSource(ctx context.Context, client *ethclient.Client, native money.Currency) (*EthChainSource, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+
+	return &EthChainSource{client: client, native: native, chainID: chainID}, nil
+}
+
+func (s *EthChainSource) LatestBlock(ctx context.Context) (uint64, error) {
+	return s.client.BlockNumber(ctx)
+}
+
+func (s *EthChainSource) Transfers(ctx context.Context, fromBlock, toBlock uint64, addresses []string) ([]Transfer, error) {
+	watched := make(map[common.Address]bool)
+	for _, address := range addresses {
+		watched[common.HexToAddress(address)] = true
+	}
+	signer := types.LatestSignerForChainID(s.chainID)
+
+	var transfers []Transfer
+	for number := fromBlock; number <= toBlock; number++ {
+		block, err := s.client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %d: %v", number, err)
+		}
+
+		for _, tx := range block.Transactions() {
+			if tx.To() == nil || !watched[*tx.To()] || tx.Value().Sign() == 0 {
+				continue
+			}
+
+			// Reverted transactions move no value
+			receipt, err := s.client.TransactionReceipt(ctx, tx.Hash())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get receipt for %s: %v", tx.Hash().Hex(), err)
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				continue
+			}
+
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to recover sender of %s: %v", tx.Hash().Hex(), err)
+			}
+
+			// Key the deposit by the escrow's own log for it, which is how the escrow's event indexer records it too
+			transfer := Transfer{
+				TxHash:      tx.Hash().Hex(),
+				BlockNumber: number,
+				From:        from.Hex(),
+				To:          tx.To().Hex(),
+				Amount:      money.New(tx.Value(), s.native),
+			}
+			for _, entry := range receipt.Logs {
+				if entry.Address == *tx.To() {
+					transfer.LogIndex = entry.Index
+					break
+				}
+			}
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers, nil
+}
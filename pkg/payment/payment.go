@@ -0,0 +1,149 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"smart_contract/pkg/money"
+)
+
+// Represents the lifecycle of a payment intent
+type IntentStatus string
+
+const (
+	IntentPending   IntentStatus = "pending"
+	IntentSucceeded IntentStatus = "succeeded"
+	IntentFailed    IntentStatus = "failed"
+	IntentRefunded  IntentStatus = "refunded"
+)
+
+// A request for a client to pay a contract through a provider
+type Intent struct {
+	ID          string
+	Provider    string
+	ContractID  int
+	Amount      money.Amount
+	Status      IntentStatus
+	PaymentLink string
+	CreatedAt   time.Time
+}
+
+// Types of payment events reported by providers
+const (
+	EventSucceeded = "payment.succeeded"
+	EventFailed    = "payment.failed"
+)
+
+// A status change reported by a provider, usually through a webhook
+type Event struct {
+	ID         string // Provider's event ID, used to deduplicate retries
+	Provider   string
+	Type       string
+	IntentID   string
+	ContractID int
+	Status     IntentStatus
+	Amount     money.Amount
+	Reference  string // Ledger reference of the funds, when other records of the same transfer must share it
+}
+
+// Implemented by every way a client can pay into escrow (card processor, crypto deposit, ...)
+type Provider interface {
+	// Name under which the provider is registered
+	Name() string
+	// Creates a payment intent for a contract and returns where the client should pay
+	CreateIntent(ctx context.Context, contractID int, amount money.Amount) (*Intent, error)
+	// Fetches the current status of a payment intent
+	FetchStatus(ctx context.Context, intentID string) (IntentStatus, error)
+	// Refunds all or part of a succeeded payment intent
+	Refund(ctx context.Context, intentID string, amount money.Amount) error
+	// Parses and authenticates a webhook request from the provider
+	HandleWebhook(ctx context.Context, headers http.Header, payload []byte) (*Event, error)
+}
+
+// Called for every event so the contract state machine can react to it
+type EventHandler func(ctx context.Context, event *Event) error
+
+// Holds the configured payment providers and dispatches their events
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	fallback  string
+	handler   EventHandler
+}
+
+// Creates an empty registry that passes events to handler
+func NewRegistry(handler EventHandler) *Registry {
+	return &Registry{
+		providers: make(map[string]Provider),
+		handler:   handler,
+	}
+}
+
+// Adds a provider; the first registered provider becomes the default
+func (r *Registry) Register(provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[provider.Name()] = provider
+	if r.fallback == "" {
+		r.fallback = provider.Name()
+	}
+}
+
+// Adds a provider that is only used when asked for by name, never as the default
+func (r *Registry) RegisterNamed(provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[provider.Name()] = provider
+}
+
+// Looks up a provider by name, or the default provider when name is empty
+func (r *Registry) Get(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		if r.fallback == "" {
+			return nil, fmt.Errorf("no default payment provider is configured")
+		}
+		name = r.fallback
+	}
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("payment provider %q not registered", name)
+	}
+	return provider, nil
+}
+
+// Passes a provider event on to the registry's handler
+func (r *Registry) Dispatch(ctx context.Context, event *Event) error {
+	if r.handler == nil {
+		return nil
+	}
+	if err := r.handler(ctx, event); err != nil {
+		return fmt.Errorf("failed to handle %s event %s: %v", event.Provider, event.ID, err)
+	}
+	return nil
+}
+
+// Bank details collected from a freelancer; only the provider keeps the account number
+type BankAccount struct {
+	HolderName    string `json:"holder_name"`
+	RoutingNumber string `json:"routing_number"`
+	AccountNumber string `json:"account_number"`
+	Country       string `json:"country"`
+}
+
+// Implemented by providers that can send money out to bank accounts
+type PayoutProvider interface {
+	Provider
+	// Stores bank details with the provider and returns a reference to pay out to
+	CreateBankDestination(ctx context.Context, userID int, account BankAccount) (string, error)
+	// Sends money to a destination and returns the provider's transfer ID. reference is an idempotency key: paying out
+	// again with the same reference returns the first transfer instead of sending the money twice
+	Payout(ctx context.Context, destination string, amount money.Amount, reference string) (string, error)
+}
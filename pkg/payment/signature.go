@@ -0,0 +1,67 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header carrying the webhook signature, formatted as "t=<unix timestamp>,v1=<hex hmac>"
+const SignatureHeader = "X-Tronch-Signature"
+
+// Signs a webhook payload with a shared secret, binding it to a timestamp to prevent replays
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, computeSignature(secret, ts, payload))
+}
+
+// Checks a webhook signature header against the payload and rejects stale timestamps
+func VerifySignature(secret, header string, payload []byte, tolerance time.Duration) error {
+	// Anyone could sign with an empty secret
+	if secret == "" {
+		return fmt.Errorf("webhook secret is not configured")
+	}
+	if header == "" {
+		return fmt.Errorf("missing webhook signature")
+	}
+
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid webhook signature timestamp")
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("webhook signature timestamp outside tolerance")
+	}
+
+	expected := computeSignature(secret, ts, payload)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("webhook signature does not match")
+}
+
+func computeSignature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
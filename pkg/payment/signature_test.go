@@ -0,0 +1,46 @@
+package payment
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"event_id":"evt_1"}`)
+	now := time.Now()
+	valid := Sign("secret", now, payload)
+	_, mac, _ := strings.Cut(valid, ",v1=")
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		payload []byte
+		valid   bool
+	}{
+		{"valid", "secret", valid, payload, true},
+		{"one of several signatures matches", "secret", valid + ",v1=deadbeef", payload, true},
+		{"within tolerance", "secret", Sign("secret", now.Add(-4*time.Minute), payload), payload, true},
+		{"empty secret", "", Sign("", now, payload), payload, false},
+		{"missing header", "secret", "", payload, false},
+		{"wrong secret", "other", valid, payload, false},
+		{"tampered payload", "secret", valid, []byte(`{"event_id":"evt_2"}`), false},
+		{"stale", "secret", Sign("secret", now.Add(-6*time.Minute), payload), payload, false},
+		{"from the future", "secret", Sign("secret", now.Add(6*time.Minute), payload), payload, false},
+		{"missing timestamp", "secret", "v1=" + mac, payload, false},
+		{"malformed timestamp", "secret", "t=yesterday,v1=" + mac, payload, false},
+		{"missing signature", "secret", strings.Split(valid, ",")[0], payload, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifySignature(test.secret, test.header, test.payload, 5*time.Minute)
+			if test.valid && err != nil {
+				t.Errorf("rejected: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"smart_contract/pkg/money"
+)
+
+// A local provider that keeps intents in memory, for tests and development
+type SimulatedProvider struct {
+	mu       sync.Mutex
+	baseURL  string
+	secret   string
+	intents  map[string]*Intent
+	payouts  map[string]string // Transfer IDs by reference
+	sequence int
+}
+
+// The webhook payload produced by the simulated provider
+type simulatedWebhook struct {
+	EventID    string       `json:"event_id"`
+	Type       string       `json:"type"`
+	IntentID   string       `json:"intent_id"`
+	ContractID int          `json:"contract_id"`
+	Status     IntentStatus `json:"status"`
+	Amount     money.Amount `json:"amount"`
+}
+
+// Creates a simulated provider whose payment links point at baseURL and whose webhooks are signed with secret
+func NewSimulatedProvider(baseURL, secret string) *SimulatedProvider {
+	return &SimulatedProvider{
+		baseURL: baseURL,
+		secret:  secret,
+		intents: make(map[string]*Intent),
+		payouts: make(map[string]string),
+	}
+}
+
+func (p *SimulatedProvider) Name() string {
+	return "simulated"
+}
+
+func (p *SimulatedProvider) CreateIntent(ctx context.Context, contractID int, amount money.Amount) (*Intent, error) {
+	if amount.Sign() <= 0 {
+		return nil, fmt.Errorf("payment amount must be positive")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sequence++
+	id := fmt.Sprintf("sim_pi_%d", p.sequence)
+	intent := &Intent{
+		ID:          id,
+		Provider:    p.Name(),
+		ContractID:  contractID,
+		Amount:      amount,
+		Status:      IntentPending,
+		PaymentLink: fmt.Sprintf("%s/pay/%s", p.baseURL, id),
+		CreatedAt:   time.Now(),
+	}
+	p.intents[id] = intent
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *SimulatedProvider) FetchStatus(ctx context.Context, intentID string) (IntentStatus, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return "", fmt.Errorf("payment intent %s not found", intentID)
+	}
+	return intent.Status, nil
+}
+
+func (p *SimulatedProvider) Refund(ctx context.Context, intentID string, amount money.Amount) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return fmt.Errorf("payment intent %s not found", intentID)
+	}
+	if intent.Status != IntentSucceeded {
+		return fmt.Errorf("payment intent %s is %s and cannot be refunded", intentID, intent.Status)
+	}
+	if cmp, err := amount.Cmp(intent.Amount); err != nil || cmp > 0 {
+		return fmt.Errorf("refund of %s exceeds payment of %s", amount, intent.Amount)
+	}
+
+	intent.Status = IntentRefunded
+	return nil
+}
+
+func (p *SimulatedProvider) HandleWebhook(ctx context.Context, headers http.Header, payload []byte) (*Event, error) {
+	if err := VerifySignature(p.secret, headers.Get(SignatureHeader), payload, 5*time.Minute); err != nil {
+		return nil, err
+	}
+
+	var webhook simulatedWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return nil, fmt.Errorf("failed to decode simulated webhook: %v", err)
+	}
+
+	return &Event{
+		ID:         webhook.EventID,
+		Provider:   p.Name(),
+		Type:       webhook.Type,
+		IntentID:   webhook.IntentID,
+		ContractID: webhook.ContractID,
+		Status:     webhook.Status,
+		Amount:     webhook.Amount,
+	}, nil
+}
+
+// Settles an intent as if the client had paid and returns the signed webhook the provider would send
+func (p *SimulatedProvider) Complete(intentID string, succeeded bool) (http.Header, []byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, nil, fmt.Errorf("payment intent %s not found", intentID)
+	}
+
+	intent.Status = IntentFailed
+	eventType := EventFailed
+	if succeeded {
+		intent.Status = IntentSucceeded
+		eventType = EventSucceeded
+	}
+
+	p.sequence++
+	payload, err := json.Marshal(simulatedWebhook{
+		EventID:    fmt.Sprintf("sim_evt_%d", p.sequence),
+		Type:       eventType,
+		IntentID:   intent.ID,
+		ContractID: intent.ContractID,
+		Status:     intent.Status,
+		Amount:     intent.Amount,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers := http.Header{}
+	headers.Set(SignatureHeader, Sign(p.secret, time.Now(), payload))
+	return headers, payload, nil
+}
+
+func (p *SimulatedProvider) CreateBankDestination(ctx context.Context, userID int, account BankAccount) (string, error) {
+	if account.AccountNumber == "" || account.HolderName == "" {
+		return "", fmt.Errorf("bank account holder and number are required")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sequence++
+	return fmt.Sprintf("sim_ba_%d", p.sequence), nil
+}
+
+func (p *SimulatedProvider) Payout(ctx context.Context, destination string, amount money.Amount, reference string) (string, error) {
+	if amount.Sign() <= 0 {
+		return "", fmt.Errorf("payout amount must be positive")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.payouts[reference]; ok {
+		return id, nil
+	}
+	p.sequence++
+	p.payouts[reference] = fmt.Sprintf("sim_po_%d", p.sequence)
+	return p.payouts[reference], nil
+}
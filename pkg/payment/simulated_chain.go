@@ -0,0 +1,82 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"smart_contract/pkg/money"
+)
+
+// An in-memory chain that mines one block per transfer, for testing deposit detection
+type SimulatedChain struct {
+	mu        sync.Mutex
+	head      uint64
+	transfers []Transfer
+}
+
+// Creates an empty simulated chain
+func NewSimulatedChain() *SimulatedChain {
+	return &SimulatedChain{}
+}
+
+// Mines a block containing a single transfer and returns it
+func (c *SimulatedChain) Send(from, to string, amount money.Amount) Transfer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.head++
+	transfer := Transfer{
+		TxHash:      fmt.Sprintf("0x%064x", c.head),
+		BlockNumber: c.head,
+		From:        from,
+		To:          to,
+		Amount:      amount,
+	}
+	c.transfers = append(c.transfers, transfer)
+	return transfer
+}
+
+// Mines empty blocks, e.g. to reach the required number of confirmations
+func (c *SimulatedChain) Mine(blocks uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.head += blocks
+}
+
+// Includes an already mined transfer again, as a node would after a reorg or repeated query
+func (c *SimulatedChain) Replay(transfer Transfer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.head++
+	transfer.BlockNumber = c.head
+	c.transfers = append(c.transfers, transfer)
+}
+
+func (c *SimulatedChain) LatestBlock(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.head, nil
+}
+
+func (c *SimulatedChain) Transfers(ctx context.Context, fromBlock, toBlock uint64, addresses []string) ([]Transfer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	watched := make(map[string]bool)
+	for _, address := range addresses {
+		watched[strings.ToLower(address)] = true
+	}
+
+	var transfers []Transfer
+	for _, transfer := range c.transfers {
+		if transfer.BlockNumber >= fromBlock && transfer.BlockNumber <= toBlock && watched[strings.ToLower(transfer.To)] {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers, nil
+}
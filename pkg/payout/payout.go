@@ -0,0 +1,304 @@
+package payout
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/payment"
+	"smart_contract/pkg/smart-contract"
+)
+
+// Statuses of a payout
+const (
+	Pending   = "pending"
+	Sending   = "sending" // Handed to the provider or chain, which may or may not have sent it
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+// Releases a contract's escrow to the freelancer's wallet on-chain, implemented by the Interactor
+type WalletReleaser interface {
+	// Releasing again with the same idempotency key must not send the funds twice
+	Release(ctx context.Context, contractAddress, to string, amount money.Amount, idempotencyKey string) (string, error)
+	// Returns what the escrow contract holds, checked against the ledger before anything leaves it
+	EscrowBalance(ctx context.Context, contractAddress string) (*big.Int, error)
+}
+
+// Checks that a wallet address is well formed and, if mixed case, carries a valid EIP-55 checksum
+func ValidateWalletAddress(address string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("%q is not a valid wallet address", address)
+	}
+
+	hex := strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X")
+	if hex == strings.ToLower(hex) || hex == strings.ToUpper(hex) {
+		return nil
+	}
+	if common.HexToAddress(address).Hex() != "0x"+hex {
+		return fmt.Errorf("wallet address %q has an invalid checksum", address)
+	}
+	return nil
+}
+
+// Registers a wallet as a payout destination, replacing the user's default only when makeDefault is set
+func RegisterWallet(userID int, address string, makeDefault bool) (*db.PayoutAccount, error) {
+	if err := ValidateWalletAddress(address); err != nil {
+		return nil, err
+	}
+
+	account := &db.PayoutAccount{
+		UserID:        userID,
+		Kind:          "wallet",
+		WalletAddress: common.HexToAddress(address).Hex(),
+		IsDefault:     makeDefault,
+	}
+	id, err := db.CreatePayoutAccount(account)
+	if err != nil {
+		return nil, err
+	}
+	account.ID = id
+	return account, nil
+}
+
+// Registers a bank account with a payment provider as a payout destination, replacing the user's default only
+// when makeDefault is set
+func RegisterBankAccount(ctx context.Context, provider payment.PayoutProvider, userID int, bank payment.BankAccount, makeDefault bool) (*db.PayoutAccount, error) {
+	destination, err := provider.CreateBankDestination(ctx, userID, bank)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register bank account: %v", err)
+	}
+
+	account := &db.PayoutAccount{
+		UserID:      userID,
+		Kind:        "bank",
+		Provider:    provider.Name(),
+		Destination: destination,
+		IsDefault:   makeDefault,
+	}
+	id, err := db.CreatePayoutAccount(account)
+	if err != nil {
+		return nil, err
+	}
+	account.ID = id
+	return account, nil
+}
+
+// Disburses executed contracts to freelancers, retrying failures with exponential backoff
+type Service struct {
+	Payments    *payment.Registry
+	Wallet      WalletReleaser
+	FeeWallet   string // Where the platform and network fees are withdrawn from a wallet payout's escrow
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// Pays out every executed contract that is due, then repeats every interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ids, err := db.GetContractIDsByStatus(string(smart_contract.ContractExecuted))
+		if err != nil {
+			log.Printf("Error loading executed contracts: %v", err)
+		}
+		for _, id := range ids {
+			if err := s.Disburse(ctx, id); err != nil {
+				log.Printf("Error disbursing contract %d: %v", id, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Pays the freelancer the contract's net amount and releases the contract once the payout succeeds
+func (s *Service) Disburse(ctx context.Context, contractID int) error {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return err
+	}
+	if smart_contract.ContractStatus(contract.Status) != smart_contract.ContractExecuted {
+		return fmt.Errorf("contract %d is %s, not %s", contractID, contract.Status, smart_contract.ContractExecuted)
+	}
+
+	payout, err := s.payoutFor(contract)
+	if err != nil {
+		return err
+	}
+	// A payout that went out before the release was recorded only needs the fees withdrawn and the release
+	if payout.Status == Succeeded {
+		return s.release(ctx, contractID, payout)
+	}
+	// A payout left sending by a restart may have gone out, so it is sent again under the same idempotency key,
+	// which returns the transfer already made instead of paying twice
+	switch {
+	case payout.Status == Sending:
+		log.Printf("Reconciling payout %s for contract %d", payout.IdempotencyKey, contractID)
+	case payout.Status != Pending || time.Now().Before(payout.NextAttemptAt):
+		return nil
+	default:
+		payout.Attempts++
+		payout.Status = Sending
+		if payout.IdempotencyKey == "" {
+			payout.IdempotencyKey = fmt.Sprintf("payout-%d", payout.ID)
+		}
+		if err := db.UpdatePayout(payout); err != nil {
+			return err
+		}
+	}
+
+	account, err := db.GetPayoutAccountByID(payout.PayoutAccountID)
+	if err != nil {
+		return err
+	}
+
+	txID, err := s.send(ctx, contract, account, payout.Amount, payout.IdempotencyKey)
+	if err != nil {
+		payout.Status = Pending
+		payout.LastError = err.Error()
+		if payout.Attempts >= s.MaxAttempts {
+			payout.Status = Failed
+		} else {
+			payout.NextAttemptAt = time.Now().Add(s.Backoff * time.Duration(1<<(payout.Attempts-1)))
+		}
+		if updateErr := db.UpdatePayout(payout); updateErr != nil {
+			return updateErr
+		}
+		return fmt.Errorf("payout attempt %d failed: %v", payout.Attempts, err)
+	}
+
+	payout.Status = Succeeded
+	payout.TxID = txID
+	payout.LastError = ""
+	if err := db.UpdatePayout(payout); err != nil {
+		return err
+	}
+	if err := db.SetContractPayoutTx(contractID, txID); err != nil {
+		return err
+	}
+
+	log.Printf("Paid out %s for contract %d in %s", payout.Amount, contractID, txID)
+	return s.release(ctx, contractID, payout)
+}
+
+// Withdraws the fees left in a wallet payout's escrow to the platform, so the escrow is empty when the ledger
+// records the release, then releases the contract
+func (s *Service) release(ctx context.Context, contractID int, payout *db.Payout) error {
+	account, err := db.GetPayoutAccountByID(payout.PayoutAccountID)
+	if err != nil {
+		return err
+	}
+	if account.Kind != "wallet" {
+		return smart_contract.ReleaseFunds(contractID)
+	}
+
+	// Read after the payout was created, which settles the network fee
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return err
+	}
+	breakdown, err := contract.SettledFees()
+	if err != nil {
+		return err
+	}
+	fee, err := breakdown.PlatformFee.Add(breakdown.NetworkFee)
+	if err != nil {
+		return err
+	}
+	if !fee.IsZero() {
+		if s.Wallet == nil || s.FeeWallet == "" {
+			return fmt.Errorf("no platform fee wallet configured")
+		}
+		txID, err := s.Wallet.Release(ctx, contract.Address, s.FeeWallet, fee, payout.IdempotencyKey+"-fee")
+		if err != nil {
+			return fmt.Errorf("failed to withdraw fees: %v", err)
+		}
+		log.Printf("Withdrew %s in fees for contract %d in %s", fee, contractID, txID)
+	}
+	return smart_contract.ReleaseFunds(contractID)
+}
+
+// Loads the contract's payout, creating it against the freelancer's default account on first use
+func (s *Service) payoutFor(contract *db.Contract) (*db.Payout, error) {
+	if payout, err := db.GetPayoutByContract(contract.ID); err == nil {
+		return payout, nil
+	}
+
+	client, err := db.GetClientByID(db.DB, contract.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := db.GetDefaultPayoutAccount(client.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The freelancer is paid what is left after the network fees actually spent, when they were paid in the
+	// contract's currency, rather than after the estimate
+	if spent := contract.NetworkFeeSpent; spent.Units != nil && spent.Currency == contract.Fees.Gross.Currency {
+		settled, err := contract.Fees.WithNetworkFee(spent)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.SettleContractNetworkFee(contract.ID, settled.NetworkFee); err != nil {
+			return nil, err
+		}
+		if contract, err = db.GetContractByID(contract.ID); err != nil {
+			return nil, err
+		}
+	}
+	breakdown, err := contract.SettledFees()
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.CreatePayout(&db.Payout{
+		ContractID:      contract.ID,
+		PayoutAccountID: account.ID,
+		Amount:          breakdown.Net,
+		Status:          Pending,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.GetPayoutByContract(contract.ID)
+}
+
+// Sends the money to a wallet through the escrow contract or to a bank through the provider
+func (s *Service) send(ctx context.Context, contract *db.Contract, account *db.PayoutAccount, amount money.Amount, idempotencyKey string) (string, error) {
+	switch account.Kind {
+	case "wallet":
+		if s.Wallet == nil {
+			return "", fmt.Errorf("no wallet releaser configured")
+		}
+		if err := ledger.CheckEscrowInvariant(ctx, contract.ID, contract.Fees.Gross.Currency, s.Wallet.EscrowBalance); err != nil {
+			return "", err
+		}
+		return s.Wallet.Release(ctx, contract.Address, account.WalletAddress, amount, idempotencyKey)
+	case "bank":
+		provider, err := s.Payments.Get(account.Provider)
+		if err != nil {
+			return "", err
+		}
+		payouts, ok := provider.(payment.PayoutProvider)
+		if !ok {
+			return "", fmt.Errorf("payment provider %s cannot pay out to banks", account.Provider)
+		}
+		return payouts.Payout(ctx, account.Destination, amount, idempotencyKey)
+	}
+	return "", fmt.Errorf("unknown payout account kind %q", account.Kind)
+}
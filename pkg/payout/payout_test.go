@@ -0,0 +1,379 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/fees"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/smart-contract"
+	"smart_contract/pkg/smart-contract/contract"
+)
+
+func TestMain(m *testing.M) {
+	// Emails and receipts are rendered from templates relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// Releases escrow in memory, checking what the payout looks like when the funds are handed over
+type fakeWallet struct {
+	held    *big.Int
+	calls   []string
+	sending bool // Whether the payout was marked sending at the last call
+}
+
+func (w *fakeWallet) Release(ctx context.Context, contractAddress, to string, amount money.Amount, idempotencyKey string) (string, error) {
+	contractID, err := db.GetContractIDByAddress(contractAddress)
+	if err != nil {
+		return "", err
+	}
+	payout, err := db.GetPayoutByContract(contractID)
+	if err != nil {
+		return "", err
+	}
+	if idempotencyKey == payout.IdempotencyKey {
+		w.sending = payout.Status == Sending
+	}
+	w.calls = append(w.calls, idempotencyKey)
+	w.held.Sub(w.held, amount.Units)
+	return "0xrelease-" + idempotencyKey, nil
+}
+
+func (w *fakeWallet) EscrowBalance(ctx context.Context, contractAddress string) (*big.Int, error) {
+	return w.held, nil
+}
+
+// Creates an executed contract holding 1 ETH in escrow for a freelancer paid to a wallet
+func executedContract(t *testing.T) (int, *fakeWallet) {
+	t.Helper()
+	contractID, wallet := deployedContract(t, smart_contract.ContractExecuted)
+	if err := ledger.RecordPayment(contractID, money.New(wallet.held, money.ETH), "test:payment"); err != nil {
+		t.Fatal(err)
+	}
+	return contractID, wallet
+}
+
+// Creates a contract for 1 ETH with its escrow deployed in block 1 for a freelancer paid to a wallet
+func deployedContract(t *testing.T, status smart_contract.ContractStatus) (int, *fakeWallet) {
+	t.Helper()
+	if err := db.Initialize(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+
+	if err := db.CreateUser(db.User{FirstName: "Fran", Email: "fran@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := db.GetUserByEmail("fran@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &db.Client{UserID: user.ID, Name: "Cleo", Email: "cleo@example.com"}
+	if err := db.CreateClient(db.DB, client); err != nil {
+		t.Fatal(err)
+	}
+	contractID, err := db.CreateContract(&db.Contract{ClientID: client.ID, Description: "logo design", Status: string(status)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gross := money.New(big.NewInt(1e18), money.ETH)
+	breakdown := fees.Breakdown{Gross: gross, PlatformFee: money.Zero(money.ETH), NetworkFee: money.Zero(money.ETH), Net: gross}
+	if err := db.SaveContractFees(contractID, breakdown); err != nil {
+		t.Fatal(err)
+	}
+	address := fmt.Sprintf("0x%040x", 0xe5c40+contractID)
+	if err := db.SetContractDeployment(contractID, address, "0x01", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RegisterWallet(user.ID, "0x00000000000000000000000000000000000f4ee1", false); err != nil {
+		t.Fatal(err)
+	}
+	return contractID, &fakeWallet{held: new(big.Int).Set(gross.Units)}
+}
+
+// Serves escrow logs from memory, one block per log after the deployment in block 1
+type fakeChain struct {
+	logs []types.Log
+}
+
+// Adds an escrow event to a new block
+func (c *fakeChain) emit(t *testing.T, address string, name string, args ...interface{}) {
+	t.Helper()
+	escrowABI, err := contract.EscrowServiceMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := escrowABI.Events[name]
+	data, err := event.Inputs.NonIndexed().Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := uint64(len(c.logs) + 2)
+	c.logs = append(c.logs, types.Log{
+		Address:     common.HexToAddress(address),
+		Topics:      []common.Hash{event.ID},
+		Data:        data,
+		BlockNumber: block,
+		TxHash:      common.BigToHash(new(big.Int).SetUint64(block)),
+		BlockHash:   c.header(block).Hash(),
+	})
+}
+
+func (c *fakeChain) header(number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(0)}
+}
+
+func (c *fakeChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return uint64(len(c.logs) + 1), nil
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number.Uint64() > uint64(len(c.logs)+1) {
+		return nil, ethereum.NotFound
+	}
+	return c.header(number.Uint64()), nil
+}
+
+func (c *fakeChain) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, entry := range c.logs {
+		if entry.BlockNumber >= query.FromBlock.Uint64() && entry.BlockNumber <= query.ToBlock.Uint64() {
+			logs = append(logs, entry)
+		}
+	}
+	return logs, nil
+}
+
+func (c *fakeChain) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestIndexedContractPaysOut(t *testing.T) {
+	contractID, wallet := deployedContract(t, smart_contract.ContractConfirmed)
+	deployed, err := db.GetContractByID(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := &fakeChain{}
+	indexer := &smart_contract.EventIndexer{Reader: chain, Confirmations: 1}
+	client := common.HexToAddress("0x00000000000000000000000000000000000c1e0")
+	ctx := context.Background()
+
+	// The client deposits into the escrow from their wallet
+	chain.emit(t, deployed.Address, "EscrowInitiated", client, client, wallet.held, big.NewInt(1))
+	if err := indexer.Index(ctx, contractID); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := smart_contract.GetCurrentContractStatus(contractID); status != smart_contract.PaymentMade {
+		t.Fatalf("contract is %s after the deposit, want %s", status, smart_contract.PaymentMade)
+	}
+
+	// Then confirms receipt of the work, which is all the escrow needs to be released
+	chain.emit(t, deployed.Address, "ReceiptConfirmed", client, wallet.held, big.NewInt(2))
+	if err := indexer.Index(ctx, contractID); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := smart_contract.GetCurrentContractStatus(contractID); status != smart_contract.ContractExecuted {
+		t.Fatalf("contract is %s after receipt was confirmed, want %s", status, smart_contract.ContractExecuted)
+	}
+
+	// A cancelled context runs the payout service once
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	(&Service{Wallet: wallet, MaxAttempts: 3, Backoff: time.Minute}).Run(cancelled, time.Hour)
+	if len(wallet.calls) != 1 || wallet.held.Sign() != 0 {
+		t.Fatalf("released with keys %v, leaving %s in escrow", wallet.calls, wallet.held)
+	}
+	if status, _ := smart_contract.GetCurrentContractStatus(contractID); status != smart_contract.PaymentReleased {
+		t.Errorf("contract is %s, want %s", status, smart_contract.PaymentReleased)
+	}
+	if holding, _ := ledger.Balance(contractID, ledger.EscrowHolding, money.ETH); holding.Sign() != 0 {
+		t.Errorf("ledger holds %s in escrow after the payout", holding)
+	}
+}
+
+func TestDisburseMarksPayoutSendingFirst(t *testing.T) {
+	contractID, wallet := executedContract(t)
+	service := &Service{Wallet: wallet, MaxAttempts: 3, Backoff: time.Minute}
+
+	if err := service.Disburse(context.Background(), contractID); err != nil {
+		t.Fatal(err)
+	}
+	if len(wallet.calls) != 1 || !wallet.sending {
+		t.Fatalf("released %v; marked sending beforehand: %v", wallet.calls, wallet.sending)
+	}
+
+	payout, err := db.GetPayoutByContract(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payout.Status != Succeeded || payout.TxID != "0xrelease-"+payout.IdempotencyKey || payout.Attempts != 1 {
+		t.Errorf("payout = %+v", payout)
+	}
+	if status, _ := smart_contract.GetCurrentContractStatus(contractID); status != smart_contract.PaymentReleased {
+		t.Errorf("contract is %s, want %s", status, smart_contract.PaymentReleased)
+	}
+}
+
+func TestDisburseReconcilesSendingPayout(t *testing.T) {
+	contractID, wallet := executedContract(t)
+	service := &Service{Wallet: wallet, MaxAttempts: 3, Backoff: time.Minute}
+
+	// A previous run handed the payout over and stopped before hearing back
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payout, err := service.payoutFor(contract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payout.Status, payout.Attempts, payout.IdempotencyKey = Sending, 1, "payout-earlier"
+	payout.NextAttemptAt = time.Now().Add(time.Hour)
+	if err := db.UpdatePayout(payout); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.Disburse(context.Background(), contractID); err != nil {
+		t.Fatal(err)
+	}
+	if len(wallet.calls) != 1 || wallet.calls[0] != "payout-earlier" {
+		t.Fatalf("released with keys %v, want the earlier attempt's", wallet.calls)
+	}
+	if payout, _ = db.GetPayoutByContract(contractID); payout.Status != Succeeded || payout.Attempts != 1 {
+		t.Errorf("payout = %+v, want the first attempt to have succeeded", payout)
+	}
+}
+
+func TestDisburseChecksEscrowBeforeReleasing(t *testing.T) {
+	contractID, wallet := executedContract(t)
+	wallet.held = big.NewInt(1)
+	service := &Service{Wallet: wallet, MaxAttempts: 3, Backoff: time.Minute}
+
+	if err := service.Disburse(context.Background(), contractID); err == nil {
+		t.Fatal("disbursed an escrow holding less than the ledger records")
+	}
+	if len(wallet.calls) != 0 {
+		t.Errorf("released %v", wallet.calls)
+	}
+
+	payout, err := db.GetPayoutByContract(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payout.Status != Pending || payout.LastError == "" || !payout.NextAttemptAt.After(time.Now()) {
+		t.Errorf("payout = %+v, want a pending retry", payout)
+	}
+}
+
+func TestDisbursePaysAfterNetworkFeesSpent(t *testing.T) {
+	contractID, wallet := executedContract(t)
+	service := &Service{Wallet: wallet, FeeWallet: "0x00000000000000000000000000000000000fee01", MaxAttempts: 3, Backoff: time.Minute}
+
+	// Quoted with a 0.01 ETH network fee estimate, of which the contract's transactions spent 0.004 ETH
+	gross := money.New(big.NewInt(1e18), money.ETH)
+	quoted := fees.Breakdown{Gross: gross, PlatformFee: money.Zero(money.ETH), NetworkFee: money.New(big.NewInt(1e16), money.ETH), Net: money.New(big.NewInt(99e16), money.ETH)}
+	if err := db.SaveContractFees(contractID, quoted); err != nil {
+		t.Fatal(err)
+	}
+	spend := func(nonce uint64, wei int64) {
+		t.Helper()
+		id, err := db.CreateChainTransaction(&db.ChainTransaction{Sender: "0x01", Nonce: nonce, ContractID: contractID, Hash: fmt.Sprintf("0x%x", nonce), SentAt: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.FinishChainTransaction(id, db.ChainTxConfirmed, fmt.Sprintf("0x%x", nonce), 1, 21000, big.NewInt(wei), "ETH", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spend(1, 4e15)
+
+	if err := service.Disburse(context.Background(), contractID); err != nil {
+		t.Fatal(err)
+	}
+	want := money.New(big.NewInt(996e15), money.ETH)
+	payout, err := db.GetPayoutByContract(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp, _ := payout.Amount.Cmp(want); cmp != 0 {
+		t.Errorf("paid out %s, want %s", payout.Amount, want)
+	}
+	if payable, _ := ledger.Balance(contractID, ledger.FreelancerPayable, money.ETH); payable.Units.Cmp(want.Units) != 0 {
+		t.Errorf("released %s in the ledger, want %s", payable, want)
+	}
+	// The fees are withdrawn from the escrow too, leaving it as empty as the ledger says
+	if len(wallet.calls) != 2 || wallet.calls[1] != payout.IdempotencyKey+"-fee" {
+		t.Errorf("released with keys %v, want the payout and its fees", wallet.calls)
+	}
+	if holding, _ := ledger.Balance(contractID, ledger.EscrowHolding, money.ETH); wallet.held.Sign() != 0 || holding.Sign() != 0 {
+		t.Errorf("escrow holds %s on-chain and %s in the ledger after the release", wallet.held, holding)
+	}
+
+	// Gas spent after the payout was created doesn't change what the freelancer was paid
+	spend(2, 1e15)
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settled, err := contract.SettledFees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp, _ := settled.Net.Cmp(want); cmp != 0 || contract.NetworkFeeSpent.Units.Cmp(big.NewInt(5e15)) != 0 {
+		t.Errorf("settled net %s after spending %s, want %s", settled.Net, contract.NetworkFeeSpent, want)
+	}
+}
+
+func TestRegisterWalletKeepsExistingDefault(t *testing.T) {
+	contractID, _ := executedContract(t)
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := db.GetClientByID(db.DB, contract.ClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := db.GetDefaultPayoutAccount(client.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another wallet is only added alongside the freelancer's first one
+	added, err := RegisterWallet(client.UserID, "0x00000000000000000000000000000000000f4ee2", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.IsDefault {
+		t.Error("a second wallet became the default without asking")
+	}
+	if current, err := db.GetDefaultPayoutAccount(client.UserID); err != nil || current.ID != first.ID {
+		t.Errorf("default is %+v (%v), want the first wallet", current, err)
+	}
+
+	// Until the freelancer asks for it to replace the default
+	replaced, err := RegisterWallet(client.UserID, "0x00000000000000000000000000000000000f4ee3", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current, err := db.GetDefaultPayoutAccount(client.UserID); err != nil || current.ID != replaced.ID || !replaced.IsDefault {
+		t.Errorf("default is %+v (%v), want the replacement", current, err)
+	}
+}
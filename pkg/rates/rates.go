@@ -0,0 +1,79 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"smart_contract/pkg/money"
+)
+
+// Quotes exchange rates between currencies, e.g. from a price feed or a static table
+type Source interface {
+	// Name recorded alongside every rate the source provides
+	Name() string
+	// Returns how many display units of to one display unit of from is worth
+	Rate(ctx context.Context, from, to money.Currency) (*big.Rat, error)
+}
+
+// A fixed table of rates keyed by "FROM/TO", for tests and development
+type StaticSource struct {
+	name  string
+	rates map[string]*big.Rat
+}
+
+// Creates a static source from decimal rate strings keyed by "FROM/TO", e.g. {"USD/MATIC": "1.42"}
+func NewStaticSource(name string, rates map[string]string) (*StaticSource, error) {
+	source := &StaticSource{name: name, rates: make(map[string]*big.Rat)}
+	for pair, value := range rates {
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q for %s", value, pair)
+		}
+		source.rates[strings.ToUpper(pair)] = rate
+	}
+	return source, nil
+}
+
+// The layout of a rates file
+type rateFile struct {
+	Source string            `json:"source"`
+	Rates  map[string]string `json:"rates"`
+}
+
+// Loads a static source from a JSON file such as rates.json
+func LoadFile(path string) (*StaticSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %v", path, err)
+	}
+	if file.Source == "" {
+		file.Source = path
+	}
+	return NewStaticSource(file.Source, file.Rates)
+}
+
+func (s *StaticSource) Name() string {
+	return s.name
+}
+
+func (s *StaticSource) Rate(ctx context.Context, from, to money.Currency) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	if rate, ok := s.rates[from.Code+"/"+to.Code]; ok {
+		return new(big.Rat).Set(rate), nil
+	}
+	if rate, ok := s.rates[to.Code+"/"+from.Code]; ok {
+		return new(big.Rat).Inv(rate), nil
+	}
+	return nil, fmt.Errorf("no %s/%s rate available from %s", from.Code, to.Code, s.name)
+}
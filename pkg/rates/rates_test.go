@@ -0,0 +1,98 @@
+package rates
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"smart_contract/pkg/money"
+)
+
+func TestStaticSourceRate(t *testing.T) {
+	source, err := NewStaticSource("test", map[string]string{"usd/matic": "1.45", "EUR/USD": "1.08"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		from, to money.Currency
+		want     string
+	}{
+		{money.USD, money.MATIC, "1.45"},
+		{money.MATIC, money.USD, "20/29"}, // The inverse of 1.45
+		{money.USD, money.USD, "1"},
+	}
+	for _, test := range tests {
+		rate, err := source.Rate(ctx, test.from, test.to)
+		if err != nil {
+			t.Errorf("%s/%s: %v", test.from.Code, test.to.Code, err)
+			continue
+		}
+		want, _ := new(big.Rat).SetString(test.want)
+		if rate.Cmp(want) != 0 {
+			t.Errorf("%s/%s = %s, want %s", test.from.Code, test.to.Code, rate.RatString(), want.RatString())
+		}
+	}
+
+	// Rates aren't chained through other currencies
+	if _, err := source.Rate(ctx, money.EUR, money.MATIC); err == nil {
+		t.Error("quoted EUR/MATIC without a rate for it")
+	}
+
+	// Callers can't change the table through a rate they were given
+	rate, _ := source.Rate(ctx, money.USD, money.MATIC)
+	rate.SetInt64(100)
+	if again, _ := source.Rate(ctx, money.USD, money.MATIC); again.Cmp(big.NewRat(145, 100)) != 0 {
+		t.Errorf("rate changed to %s", again.RatString())
+	}
+}
+
+func TestNewStaticSourceRejectsInvalidRates(t *testing.T) {
+	for _, value := range []string{"", "abc", "0", "-1.2"} {
+		if _, err := NewStaticSource("test", map[string]string{"USD/MATIC": value}); err == nil {
+			t.Errorf("accepted rate %q", value)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	source, err := LoadFile(write("rates.json", `{"source": "feed", "rates": {"USD/ETH": "0.00032"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Name() != "feed" {
+		t.Errorf("name = %q, want feed", source.Name())
+	}
+	if rate, err := source.Rate(context.Background(), money.USD, money.ETH); err != nil || rate.Cmp(big.NewRat(32, 100000)) != 0 {
+		t.Errorf("USD/ETH = %v (%v), want 0.00032", rate, err)
+	}
+
+	// Unnamed sources are named after their file
+	unnamed := write("unnamed.json", `{"rates": {}}`)
+	if source, err := LoadFile(unnamed); err != nil || source.Name() != unnamed {
+		t.Errorf("loaded %v (%v), want a source named %s", source, err, unnamed)
+	}
+
+	for name, path := range map[string]string{
+		"missing":      filepath.Join(dir, "missing.json"),
+		"invalid JSON": write("invalid.json", `{"rates": `),
+		"invalid rate": write("zero.json", `{"rates": {"USD/ETH": "0"}}`),
+	} {
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("loaded a %s rates file", name)
+		}
+	}
+}
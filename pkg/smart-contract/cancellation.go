@@ -0,0 +1,238 @@
+package smart_contract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/payment"
+)
+
+// Identifies who is acting on a contract
+type Party string
+
+const (
+	ClientParty     Party = "client"
+	FreelancerParty Party = "freelancer"
+	AdminParty      Party = "admin"
+)
+
+// Statuses of a cancellation request
+const (
+	CancellationPending  = "pending"
+	CancellationApproved = "approved"
+	CancellationRejected = "rejected"
+)
+
+// Refunds escrowed funds on-chain, implemented by the Interactor
+type OnChainRefunder interface {
+	Refund(ctx context.Context, contractAddress string) error
+	// Returns what the escrow contract holds, checked against the ledger before anything leaves it
+	EscrowBalance(ctx context.Context, contractAddress string) (*big.Int, error)
+}
+
+// Returns escrowed funds through whichever route the client paid with
+type Refunder struct {
+	Payments *payment.Registry
+	OnChain  OnChainRefunder
+}
+
+// Identifies the contract and party a signed party token was issued for
+func AuthenticateParty(token string) (int, Party, error) {
+	if PartyLinks == nil {
+		return 0, "", fmt.Errorf("party links are not configured")
+	}
+	contractID, party, ok := PartyLinks.Parse(token)
+	if !ok {
+		return 0, "", fmt.Errorf("invalid party token")
+	}
+	switch Party(party) {
+	case ClientParty, FreelancerParty, AdminParty:
+		return contractID, Party(party), nil
+	}
+	return 0, "", fmt.Errorf("invalid party token")
+}
+
+// Returns who must approve a cancellation requested by party while the contract is in status.
+// An empty list means the cancellation takes effect immediately.
+func cancellationApprovers(status ContractStatus, party Party) ([]Party, error) {
+	switch status {
+	case AwaitingConfirmation, ContractConfirmed:
+		// Nothing has been paid yet, so either side may walk away
+		return nil, nil
+	case PaymentMade:
+		// The freelancer may give up the work; the client needs the freelancer to agree
+		switch party {
+		case FreelancerParty, AdminParty:
+			return nil, nil
+		case ClientParty:
+			return []Party{FreelancerParty, AdminParty}, nil
+		}
+	case Disputed:
+		// Refunds after a dispute are decided by us
+		if party == AdminParty {
+			return nil, nil
+		}
+		return []Party{AdminParty}, nil
+	}
+	return nil, fmt.Errorf("a contract that is %s cannot be cancelled by the %s", status, party)
+}
+
+// Requests cancellation of a contract, cancelling or refunding immediately when no approval is needed
+func RequestCancellation(ctx context.Context, refunder *Refunder, contractID int, party Party, reason string) (*db.CancellationRequest, error) {
+	status, err := GetCurrentContractStatus(contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	approvers, err := cancellationApprovers(status, party)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &db.CancellationRequest{
+		ContractID:  contractID,
+		RequestedBy: string(party),
+		Reason:      reason,
+		Status:      CancellationPending,
+	}
+	if request.ID, err = db.CreateCancellationRequest(request); err != nil {
+		return nil, err
+	}
+
+	if len(approvers) > 0 {
+		event := fmt.Sprintf("cancellation_requested:%d", request.ID)
+		notifyParties(contractID, event, withTemplate(email.CancellationRequested, func(r email.Recipient) email.CancellationRequestedData {
+			return email.CancellationRequestedData{Recipient: r, RequestedBy: string(party), Reason: reason}
+		}))
+		return request, nil
+	}
+
+	if err := executeCancellation(ctx, refunder, contractID, status); err != nil {
+		return nil, err
+	}
+	request.Status = CancellationApproved
+	request.ResolvedBy = string(party)
+	return request, db.ResolveCancellationRequest(request.ID, request.Status, request.ResolvedBy)
+}
+
+// Approves or rejects a pending cancellation request on behalf of a party of contractID
+func ResolveCancellation(ctx context.Context, refunder *Refunder, requestID, contractID int, party Party, approve bool) error {
+	request, err := db.GetCancellationRequestByID(requestID)
+	if err != nil {
+		return err
+	}
+	if request.ContractID != contractID {
+		return fmt.Errorf("cancellation request %d is not for contract %d", requestID, contractID)
+	}
+	if request.Status != CancellationPending {
+		return fmt.Errorf("cancellation request %d is already %s", requestID, request.Status)
+	}
+
+	status, err := GetCurrentContractStatus(request.ContractID)
+	if err != nil {
+		return err
+	}
+
+	approvers, err := cancellationApprovers(status, Party(request.RequestedBy))
+	if err != nil {
+		return err
+	}
+	if !containsParty(approvers, party) {
+		return fmt.Errorf("the %s cannot resolve this cancellation request", party)
+	}
+
+	if !approve {
+		return db.ResolveCancellationRequest(requestID, CancellationRejected, string(party))
+	}
+
+	if err := executeCancellation(ctx, refunder, request.ContractID, status); err != nil {
+		return err
+	}
+	return db.ResolveCancellationRequest(requestID, CancellationApproved, string(party))
+}
+
+// Cancels an unpaid contract or refunds a paid one, then tells both parties
+func executeCancellation(ctx context.Context, refunder *Refunder, contractID int, status ContractStatus) error {
+	if status == AwaitingConfirmation || status == ContractConfirmed {
+		return UpdateContractStatus(contractID, Cancelled)
+	}
+
+	// The refund is posted with the status change, so the ledger and the contract can't disagree after a crash
+	refund, err := refunder.Refund(ctx, contractID)
+	if err != nil {
+		return err
+	}
+	return updateContractStatus(contractID, Refunded, refund)
+}
+
+// Refunds everything held in escrow for a contract, returning the ledger entry that records it
+func (r *Refunder) Refund(ctx context.Context, contractID int) (*ledger.Entry, error) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := ledger.Balance(contractID, ledger.EscrowHolding, contract.Fees.Gross.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if held.Sign() <= 0 {
+		return nil, fmt.Errorf("contract %d holds no funds to refund", contractID)
+	}
+
+	intents, err := db.GetPaymentIntentsByContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	onChain := false
+	for _, intent := range intents {
+		if intent.Status != string(payment.IntentSucceeded) {
+			continue
+		}
+
+		// Deposits sit in the escrow contract, so they are returned by the contract itself
+		if intent.Provider == "crypto" {
+			onChain = true
+			continue
+		}
+
+		provider, err := r.Payments.Get(intent.Provider)
+		if err != nil {
+			return nil, err
+		}
+		if err := provider.Refund(ctx, intent.ID, intent.Amount); err != nil {
+			return nil, fmt.Errorf("failed to refund payment %s: %v", intent.ID, err)
+		}
+		if err := db.UpdatePaymentIntentStatus(intent.ID, string(payment.IntentRefunded)); err != nil {
+			return nil, err
+		}
+	}
+
+	if onChain {
+		if r.OnChain == nil {
+			return nil, fmt.Errorf("no on-chain refunder configured for contract %d", contractID)
+		}
+		if err := ledger.CheckEscrowInvariant(ctx, contractID, held.Currency, r.OnChain.EscrowBalance); err != nil {
+			return nil, err
+		}
+		if err := r.OnChain.Refund(ctx, contract.Address); err != nil {
+			return nil, fmt.Errorf("failed to refund escrow on-chain: %v", err)
+		}
+	}
+
+	return ledger.RefundEntry(contractID, held, fmt.Sprintf("contract:%d:refund", contractID)), nil
+}
+
+func containsParty(parties []Party, party Party) bool {
+	for _, p := range parties {
+		if p == party {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package smart_contract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/payment"
+)
+
+func TestCancellationApprovers(t *testing.T) {
+	tests := []struct {
+		status    ContractStatus
+		party     Party
+		approvers []Party
+		allowed   bool
+	}{
+		{AwaitingConfirmation, ClientParty, nil, true},
+		{AwaitingConfirmation, FreelancerParty, nil, true},
+		{ContractConfirmed, ClientParty, nil, true},
+		{PaymentMade, FreelancerParty, nil, true},
+		{PaymentMade, AdminParty, nil, true},
+		{PaymentMade, ClientParty, []Party{FreelancerParty, AdminParty}, true},
+		{Disputed, AdminParty, nil, true},
+		{Disputed, ClientParty, []Party{AdminParty}, true},
+		{Disputed, FreelancerParty, []Party{AdminParty}, true},
+		{PaymentMade, Party("stranger"), nil, false},
+		{ReqsCompleted, ClientParty, nil, false},
+		{ContractExecuted, AdminParty, nil, false},
+		{PaymentReleased, FreelancerParty, nil, false},
+		{Refunded, ClientParty, nil, false},
+		{Cancelled, ClientParty, nil, false},
+	}
+	for _, test := range tests {
+		approvers, err := cancellationApprovers(test.status, test.party)
+		if !test.allowed {
+			if err == nil {
+				t.Errorf("%s cancelling a %s contract was allowed", test.party, test.status)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s cancelling a %s contract: %v", test.party, test.status, err)
+			continue
+		}
+		if len(approvers) != len(test.approvers) {
+			t.Errorf("%s cancelling a %s contract needs %v, want %v", test.party, test.status, approvers, test.approvers)
+			continue
+		}
+		for i := range approvers {
+			if approvers[i] != test.approvers[i] {
+				t.Errorf("%s cancelling a %s contract needs %v, want %v", test.party, test.status, approvers, test.approvers)
+				break
+			}
+		}
+	}
+}
+
+func TestAuthenticateParty(t *testing.T) {
+	PartyLinks = nil
+	if _, _, err := AuthenticateParty("1.client.abc"); err == nil {
+		t.Error("authenticated without party links configured")
+	}
+
+	PartyLinks = &email.PartyLinks{BaseURL: "https://tronch.test", Secret: []byte("secret")}
+	t.Cleanup(func() { PartyLinks = nil })
+	other := &email.PartyLinks{Secret: []byte("other")}
+	valid := PartyLinks.Token(42, "freelancer")
+
+	contractID, party, err := AuthenticateParty(valid)
+	if err != nil || contractID != 42 || party != FreelancerParty {
+		t.Errorf("authenticated %d %s (%v), want the freelancer of contract 42", contractID, party, err)
+	}
+
+	for name, token := range map[string]string{
+		"empty":             "",
+		"other secret":      other.Token(42, "freelancer"),
+		"party swapped":     "42.client" + valid[len("42.freelancer"):],
+		"contract swapped":  "43" + valid[len("42"):],
+		"unknown party":     PartyLinks.Token(42, "stranger"),
+		"missing signature": "42.freelancer",
+	} {
+		if _, _, err := AuthenticateParty(token); err == nil {
+			t.Errorf("%s token was accepted", name)
+		}
+	}
+}
+
+// Refunds escrow in memory, optionally running something else while the refund is in flight
+type fakeEscrow struct {
+	held     *big.Int
+	refunded int
+	during   func()
+}
+
+func (e *fakeEscrow) Refund(ctx context.Context, contractAddress string) error {
+	e.refunded++
+	e.held = big.NewInt(0)
+	if e.during != nil {
+		e.during()
+	}
+	return nil
+}
+
+func (e *fakeEscrow) EscrowBalance(ctx context.Context, contractAddress string) (*big.Int, error) {
+	return e.held, nil
+}
+
+// Creates a contract paid with a 1 ETH deposit into its escrow
+func paidContract(t *testing.T) (int, *fakeEscrow) {
+	t.Helper()
+	contractID := newContract(t, PaymentMade)
+	if err := db.SetContractDeployment(contractID, "0x00000000000000000000000000000000000e5c40", "0x01", 1); err != nil {
+		t.Fatal(err)
+	}
+	deposit := money.New(big.NewInt(1e18), money.ETH)
+	intent := &db.PaymentIntent{ID: "deposit", Provider: "crypto", ContractID: contractID, Amount: deposit, Status: string(payment.IntentSucceeded)}
+	if err := db.CreatePaymentIntent(intent); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.RecordPayment(contractID, deposit, "test:payment"); err != nil {
+		t.Fatal(err)
+	}
+	return contractID, &fakeEscrow{held: new(big.Int).Set(deposit.Units)}
+}
+
+func TestCancellationPostsRefundWithStatus(t *testing.T) {
+	contractID, escrow := paidContract(t)
+	refunder := &Refunder{OnChain: escrow}
+
+	request, err := RequestCancellation(context.Background(), refunder, contractID, FreelancerParty, "can't take it on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != CancellationApproved || escrow.refunded != 1 {
+		t.Fatalf("request %+v refunded %d times, want approved and refunded once", request, escrow.refunded)
+	}
+	if status, _ := GetCurrentContractStatus(contractID); status != Refunded {
+		t.Errorf("contract is %s, want %s", status, Refunded)
+	}
+	if refunded, _ := ledger.Balance(contractID, ledger.Refunds, money.ETH); refunded.Units.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("refunded %s in the ledger, want 1 ETH", refunded)
+	}
+}
+
+func TestCancellationRefundNotPostedWhenStatusMoves(t *testing.T) {
+	contractID, escrow := paidContract(t)
+	refunder := &Refunder{OnChain: escrow}
+
+	// The freelancer completes the work while the refund is being sent, so the contract can no longer be refunded
+	escrow.during = func() {
+		if err := UpdateContractStatus(contractID, ReqsCompleted); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := RequestCancellation(context.Background(), refunder, contractID, FreelancerParty, "can't take it on"); err == nil {
+		t.Fatal("refunded a contract that moved on")
+	}
+	if posted, err := db.LedgerEntryPosted(fmt.Sprintf("contract:%d:refund", contractID)); err != nil || posted {
+		t.Errorf("refund posted for a contract that wasn't refunded (%v)", err)
+	}
+	if status, _ := GetCurrentContractStatus(contractID); status != ReqsCompleted {
+		t.Errorf("contract is %s, want %s", status, ReqsCompleted)
+	}
+}
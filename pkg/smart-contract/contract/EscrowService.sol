@@ -0,0 +1,122 @@
+/* SPDX-License-Identifier: MIT */
+pragma solidity ^0.8.0;
+
+// Escrow template deployed for every contract. The platform's operator account deploys it and
+// sends every transaction on behalf of both parties, the client may also act on it directly.
+// Regenerate the Go bindings after changing this file: go generate ./pkg/smart-contract/contract
+contract EscrowService {
+    address public operator;
+    address payable public client;
+    uint256 public paymentAmount;
+    uint256 public deadline;
+    bytes32[] public milestones;
+    uint8 public progress;
+    string public resolution;
+
+    bool public isExecuted;
+    bool public isEscrowInitiated;
+    bool public isCompleted;
+    bool public isReceived;
+    bool public isDisputed;
+    bool public isRefunded;
+
+    event EscrowInitiated(address client, address depositor, uint256 amount, uint256 timestamp);
+    event ReceiptConfirmed(address confirmer, uint256 amount, uint256 timestamp);
+    event EscrowDisputed(address disputant, uint256 timestamp);
+    event DisputeResolved(address resolver, string resolution, uint256 timestamp);
+    event ProgressUpdated(uint8 progress, uint256 timestamp);
+    event Released(address to, uint256 amount, uint256 timestamp);
+    event Refunded(address client, uint256 amount, uint256 timestamp);
+
+    modifier onlyOperator() {
+        require(msg.sender == operator, "Only the operator can access this function");
+        _;
+    }
+
+    modifier onlyParty() {
+        require(msg.sender == operator || msg.sender == client, "Only the operator or client can access this function");
+        _;
+    }
+
+    modifier whileHeld() {
+        require(isEscrowInitiated, "Escrow has not been initiated");
+        require(!isRefunded, "Escrow has been refunded");
+        _;
+    }
+
+    constructor(address payable _client, uint256 _paymentAmount, uint256 _deadline, bytes32[] memory _milestones) {
+        require(_client != address(0), "Client address is required");
+        require(_paymentAmount > 0, "Payment amount is required");
+        operator = msg.sender;
+        client = _client;
+        paymentAmount = _paymentAmount;
+        deadline = _deadline;
+        milestones = _milestones;
+    }
+
+    function executeContract() external onlyOperator {
+        require(!isExecuted, "Contract has already been executed");
+        isExecuted = true;
+    }
+
+    function initiateEscrow() external payable onlyParty {
+        require(isExecuted, "Contract has not been executed");
+        require(!isEscrowInitiated, "Escrow has already been initiated");
+        require(msg.value == paymentAmount, "Incorrect payment amount");
+        isEscrowInitiated = true;
+        emit EscrowInitiated(client, msg.sender, msg.value, block.timestamp);
+    }
+
+    function markRequirementsComplete() external onlyOperator whileHeld {
+        require(!isDisputed, "Escrow is being disputed");
+        isCompleted = true;
+    }
+
+    function confirmReqs() external onlyParty whileHeld {
+        require(isCompleted, "Requirements have not been completed");
+        require(!isDisputed, "Escrow is being disputed");
+        isReceived = true;
+        emit ReceiptConfirmed(msg.sender, address(this).balance, block.timestamp);
+    }
+
+    function initiateDispute() external onlyParty whileHeld {
+        require(!isDisputed, "Escrow is already being disputed");
+        isDisputed = true;
+        emit EscrowDisputed(msg.sender, block.timestamp);
+    }
+
+    function resolveDispute(string calldata _resolution) external onlyOperator {
+        require(isDisputed, "No dispute to resolve");
+        isDisputed = false;
+        resolution = _resolution;
+        emit DisputeResolved(msg.sender, _resolution, block.timestamp);
+    }
+
+    function updateContractProgress(uint8 _progress) external onlyOperator {
+        progress = _progress;
+        emit ProgressUpdated(_progress, block.timestamp);
+    }
+
+    function release(address payable to, uint256 amount) external onlyOperator whileHeld {
+        require(isReceived, "Receipt has not been confirmed");
+        require(!isDisputed, "Escrow is being disputed");
+        require(amount <= address(this).balance, "Amount exceeds the escrowed balance");
+        to.transfer(amount);
+        emit Released(to, amount, block.timestamp);
+    }
+
+    function refund() external onlyOperator whileHeld {
+        uint256 amount = address(this).balance;
+        isRefunded = true;
+        client.transfer(amount);
+        emit Refunded(client, amount, block.timestamp);
+    }
+
+    function milestoneCount() external view returns (uint256) {
+        return milestones.length;
+    }
+
+    function getTransactionDetails() external view returns (address, uint256, uint256, bool, bool, bool, bool) {
+        return (client, paymentAmount, address(this).balance, isEscrowInitiated, isReceived, isDisputed, isRefunded);
+    }
+}
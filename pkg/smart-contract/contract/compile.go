@@ -0,0 +1,79 @@
+// Package contract holds the escrow template deployed for every contract and its generated Go bindings.
+package contract
+
+//go:generate go run ../../../cmd/bindgen -sol EscrowService.sol -contract EscrowService -pkg contract -out escrow_service.go
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/compiler"
+)
+
+// A contract's ABI and bytecode as produced by solc
+type Artifact struct {
+	Name   string
+	ABI    string
+	Bin    string            // Creation bytecode, hex encoded
+	Hashes map[string]string // Function signatures by selector
+}
+
+// Where an escrow contract was deployed, and the transaction and block that created it
+type Deployment struct {
+	Address     string
+	TxHash      string
+	BlockNumber uint64
+}
+
+// Compiles a Solidity source file with solc and returns the named contract from it
+func Compile(ctx context.Context, solc, path, name string) (*Artifact, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, solc, "--optimize", "--combined-json", "abi,bin,hashes", path)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("failed to compile %s: %v: %s", path, err, message)
+		}
+		return nil, fmt.Errorf("failed to compile %s: %v", path, err)
+	}
+
+	contracts, err := compiler.ParseCombinedJSON(output, "", "", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse solc output: %v", err)
+	}
+	for key, compiled := range contracts {
+		// solc names contracts "<path>:<name>"
+		if key != name && !strings.HasSuffix(key, ":"+name) {
+			continue
+		}
+		abi, err := json.Marshal(compiled.Info.AbiDefinition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s ABI: %v", name, err)
+		}
+		return &Artifact{Name: name, ABI: string(abi), Bin: compiled.Code, Hashes: compiled.Hashes}, nil
+	}
+	return nil, fmt.Errorf("contract %s not found in %s", name, path)
+}
+
+// Returns the hex encoded SHA-256 of a source file, recorded in the bindings to detect a stale build
+func SourceHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Returns the artifact the EscrowService bindings were generated from
+func EscrowServiceArtifact() Artifact {
+	return Artifact{Name: "EscrowService", ABI: EscrowServiceMetaData.ABI, Bin: EscrowServiceMetaData.Bin}
+}
@@ -0,0 +1,1822 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contract
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// EscrowServiceMetaData contains all meta data concerning the EscrowService contract.
+var EscrowServiceMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"addresspayable\",\"name\":\"_client\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_paymentAmount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_deadline\",\"type\":\"uint256\"},{\"internalType\":\"bytes32[]\",\"name\":\"_milestones\",\"type\":\"bytes32[]\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"resolver\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"resolution\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"DisputeResolved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"disputant\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"EscrowDisputed\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"client\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"depositor\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"EscrowInitiated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint8\",\"name\":\"progress\",\"type\":\"uint8\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"ProgressUpdated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"confirmer\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"ReceiptConfirmed\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"client\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"Refunded\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"Released\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"client\",\"outputs\":[{\"internalType\":\"addresspayable\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"confirmReqs\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"deadline\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"executeContract\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getTransactionDetails\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"initiateDispute\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"initiateEscrow\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"isCompleted\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"isDisputed\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"isEscrowInitiated\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"isExecuted\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"isReceived\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"isRefunded\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"markRequirementsComplete\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"milestoneCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"milestones\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"operator\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"paymentAmount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"progress\",\"outputs\":[{\"internalType\":\"uint8\",\"name\":\"\",\"type\":\"uint8\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"refund\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"addresspayable\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"release\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"resolution\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_resolution\",\"type\":\"string\"}],\"name\":\"resolveDispute\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint8\",\"name\":\"_progress\",\"type\":\"uint8\"}],\"name\":\"updateContractProgress\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Sigs: map[string]string{
+		"109e94cf": "client()",
+		"b48b6b42": "confirmReqs()",
+		"29dcb0cf": "deadline()",
+		"aedb1c8f": "executeContract()",
+		"d8a5cc03": "getTransactionDetails()",
+		"4acc296f": "initiateDispute()",
+		"52389b39": "initiateEscrow()",
+		"fa391c64": "isCompleted()",
+		"0335729e": "isDisputed()",
+		"a761d3dc": "isEscrowInitiated()",
+		"1c9feaa5": "isExecuted()",
+		"ad0123ee": "isReceived()",
+		"779cd083": "isRefunded()",
+		"e2e4827d": "markRequirementsComplete()",
+		"0681ca55": "milestoneCount()",
+		"e89e4ed6": "milestones(uint256)",
+		"570ca735": "operator()",
+		"c35905c6": "paymentAmount()",
+		"577bd336": "progress()",
+		"590e1ae3": "refund()",
+		"0357371d": "release(address,uint256)",
+		"71e21495": "resolution()",
+		"8f2238ba": "resolveDispute(string)",
+		"9a9fa1b2": "updateContractProgress(uint8)",
+	},
+}
+
+// EscrowServiceABI is the input ABI used to generate the binding from.
+// Deprecated: Use EscrowServiceMetaData.ABI instead.
+var EscrowServiceABI = EscrowServiceMetaData.ABI
+
+// Deprecated: Use EscrowServiceMetaData.Sigs instead.
+// EscrowServiceFuncSigs maps the 4-byte function signature to its string representation.
+var EscrowServiceFuncSigs = EscrowServiceMetaData.Sigs
+
+// EscrowService is an auto generated Go binding around an Ethereum contract.
+type EscrowService struct {
+	EscrowServiceCaller     // Read-only binding to the contract
+	EscrowServiceTransactor // Write-only binding to the contract
+	EscrowServiceFilterer   // Log filterer for contract events
+}
+
+// EscrowServiceCaller is an auto generated read-only Go binding around an Ethereum contract.
+type EscrowServiceCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EscrowServiceTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type EscrowServiceTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EscrowServiceFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type EscrowServiceFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EscrowServiceSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type EscrowServiceSession struct {
+	Contract     *EscrowService    // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// EscrowServiceCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type EscrowServiceCallerSession struct {
+	Contract *EscrowServiceCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts        // Call options to use throughout this session
+}
+
+// EscrowServiceTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type EscrowServiceTransactorSession struct {
+	Contract     *EscrowServiceTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts        // Transaction auth options to use throughout this session
+}
+
+// EscrowServiceRaw is an auto generated low-level Go binding around an Ethereum contract.
+type EscrowServiceRaw struct {
+	Contract *EscrowService // Generic contract binding to access the raw methods on
+}
+
+// EscrowServiceCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type EscrowServiceCallerRaw struct {
+	Contract *EscrowServiceCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// EscrowServiceTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type EscrowServiceTransactorRaw struct {
+	Contract *EscrowServiceTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewEscrowService creates a new instance of EscrowService, bound to a specific deployed contract.
+func NewEscrowService(address common.Address, backend bind.ContractBackend) (*EscrowService, error) {
+	contract, err := bindEscrowService(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowService{EscrowServiceCaller: EscrowServiceCaller{contract: contract}, EscrowServiceTransactor: EscrowServiceTransactor{contract: contract}, EscrowServiceFilterer: EscrowServiceFilterer{contract: contract}}, nil
+}
+
+// NewEscrowServiceCaller creates a new read-only instance of EscrowService, bound to a specific deployed contract.
+func NewEscrowServiceCaller(address common.Address, caller bind.ContractCaller) (*EscrowServiceCaller, error) {
+	contract, err := bindEscrowService(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceCaller{contract: contract}, nil
+}
+
+// NewEscrowServiceTransactor creates a new write-only instance of EscrowService, bound to a specific deployed contract.
+func NewEscrowServiceTransactor(address common.Address, transactor bind.ContractTransactor) (*EscrowServiceTransactor, error) {
+	contract, err := bindEscrowService(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceTransactor{contract: contract}, nil
+}
+
+// NewEscrowServiceFilterer creates a new log filterer instance of EscrowService, bound to a specific deployed contract.
+func NewEscrowServiceFilterer(address common.Address, filterer bind.ContractFilterer) (*EscrowServiceFilterer, error) {
+	contract, err := bindEscrowService(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceFilterer{contract: contract}, nil
+}
+
+// bindEscrowService binds a generic wrapper to an already deployed contract.
+func bindEscrowService(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := EscrowServiceMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_EscrowService *EscrowServiceRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _EscrowService.Contract.EscrowServiceCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_EscrowService *EscrowServiceRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EscrowService.Contract.EscrowServiceTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_EscrowService *EscrowServiceRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _EscrowService.Contract.EscrowServiceTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_EscrowService *EscrowServiceCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _EscrowService.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_EscrowService *EscrowServiceTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EscrowService.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_EscrowService *EscrowServiceTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _EscrowService.Contract.contract.Transact(opts, method, params...)
+}
+
+// Client is a free data retrieval call binding the contract method 0x109e94cf.
+//
+// Solidity: function client() view returns(address)
+func (_EscrowService *EscrowServiceCaller) Client(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "client")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Client is a free data retrieval call binding the contract method 0x109e94cf.
+//
+// Solidity: function client() view returns(address)
+func (_EscrowService *EscrowServiceSession) Client() (common.Address, error) {
+	return _EscrowService.Contract.Client(&_EscrowService.CallOpts)
+}
+
+// Client is a free data retrieval call binding the contract method 0x109e94cf.
+//
+// Solidity: function client() view returns(address)
+func (_EscrowService *EscrowServiceCallerSession) Client() (common.Address, error) {
+	return _EscrowService.Contract.Client(&_EscrowService.CallOpts)
+}
+
+// Deadline is a free data retrieval call binding the contract method 0x29dcb0cf.
+//
+// Solidity: function deadline() view returns(uint256)
+func (_EscrowService *EscrowServiceCaller) Deadline(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "deadline")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// Deadline is a free data retrieval call binding the contract method 0x29dcb0cf.
+//
+// Solidity: function deadline() view returns(uint256)
+func (_EscrowService *EscrowServiceSession) Deadline() (*big.Int, error) {
+	return _EscrowService.Contract.Deadline(&_EscrowService.CallOpts)
+}
+
+// Deadline is a free data retrieval call binding the contract method 0x29dcb0cf.
+//
+// Solidity: function deadline() view returns(uint256)
+func (_EscrowService *EscrowServiceCallerSession) Deadline() (*big.Int, error) {
+	return _EscrowService.Contract.Deadline(&_EscrowService.CallOpts)
+}
+
+// GetTransactionDetails is a free data retrieval call binding the contract method 0xd8a5cc03.
+//
+// Solidity: function getTransactionDetails() view returns(address, uint256, uint256, bool, bool, bool, bool)
+func (_EscrowService *EscrowServiceCaller) GetTransactionDetails(opts *bind.CallOpts) (common.Address, *big.Int, *big.Int, bool, bool, bool, bool, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "getTransactionDetails")
+
+	if err != nil {
+		return *new(common.Address), *new(*big.Int), *new(*big.Int), *new(bool), *new(bool), *new(bool), *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	out1 := *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
+	out2 := *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+	out3 := *abi.ConvertType(out[3], new(bool)).(*bool)
+	out4 := *abi.ConvertType(out[4], new(bool)).(*bool)
+	out5 := *abi.ConvertType(out[5], new(bool)).(*bool)
+	out6 := *abi.ConvertType(out[6], new(bool)).(*bool)
+
+	return out0, out1, out2, out3, out4, out5, out6, err
+
+}
+
+// GetTransactionDetails is a free data retrieval call binding the contract method 0xd8a5cc03.
+//
+// Solidity: function getTransactionDetails() view returns(address, uint256, uint256, bool, bool, bool, bool)
+func (_EscrowService *EscrowServiceSession) GetTransactionDetails() (common.Address, *big.Int, *big.Int, bool, bool, bool, bool, error) {
+	return _EscrowService.Contract.GetTransactionDetails(&_EscrowService.CallOpts)
+}
+
+// GetTransactionDetails is a free data retrieval call binding the contract method 0xd8a5cc03.
+//
+// Solidity: function getTransactionDetails() view returns(address, uint256, uint256, bool, bool, bool, bool)
+func (_EscrowService *EscrowServiceCallerSession) GetTransactionDetails() (common.Address, *big.Int, *big.Int, bool, bool, bool, bool, error) {
+	return _EscrowService.Contract.GetTransactionDetails(&_EscrowService.CallOpts)
+}
+
+// IsCompleted is a free data retrieval call binding the contract method 0xfa391c64.
+//
+// Solidity: function isCompleted() view returns(bool)
+func (_EscrowService *EscrowServiceCaller) IsCompleted(opts *bind.CallOpts) (bool, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "isCompleted")
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// IsCompleted is a free data retrieval call binding the contract method 0xfa391c64.
+//
+// Solidity: function isCompleted() view returns(bool)
+func (_EscrowService *EscrowServiceSession) IsCompleted() (bool, error) {
+	return _EscrowService.Contract.IsCompleted(&_EscrowService.CallOpts)
+}
+
+// IsCompleted is a free data retrieval call binding the contract method 0xfa391c64.
+//
+// Solidity: function isCompleted() view returns(bool)
+func (_EscrowService *EscrowServiceCallerSession) IsCompleted() (bool, error) {
+	return _EscrowService.Contract.IsCompleted(&_EscrowService.CallOpts)
+}
+
+// IsDisputed is a free data retrieval call binding the contract method 0x0335729e.
+//
+// Solidity: function isDisputed() view returns(bool)
+func (_EscrowService *EscrowServiceCaller) IsDisputed(opts *bind.CallOpts) (bool, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "isDisputed")
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// IsDisputed is a free data retrieval call binding the contract method 0x0335729e.
+//
+// Solidity: function isDisputed() view returns(bool)
+func (_EscrowService *EscrowServiceSession) IsDisputed() (bool, error) {
+	return _EscrowService.Contract.IsDisputed(&_EscrowService.CallOpts)
+}
+
+// IsDisputed is a free data retrieval call binding the contract method 0x0335729e.
+//
+// Solidity: function isDisputed() view returns(bool)
+func (_EscrowService *EscrowServiceCallerSession) IsDisputed() (bool, error) {
+	return _EscrowService.Contract.IsDisputed(&_EscrowService.CallOpts)
+}
+
+// IsEscrowInitiated is a free data retrieval call binding the contract method 0xa761d3dc.
+//
+// Solidity: function isEscrowInitiated() view returns(bool)
+func (_EscrowService *EscrowServiceCaller) IsEscrowInitiated(opts *bind.CallOpts) (bool, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "isEscrowInitiated")
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// IsEscrowInitiated is a free data retrieval call binding the contract method 0xa761d3dc.
+//
+// Solidity: function isEscrowInitiated() view returns(bool)
+func (_EscrowService *EscrowServiceSession) IsEscrowInitiated() (bool, error) {
+	return _EscrowService.Contract.IsEscrowInitiated(&_EscrowService.CallOpts)
+}
+
+// IsEscrowInitiated is a free data retrieval call binding the contract method 0xa761d3dc.
+//
+// Solidity: function isEscrowInitiated() view returns(bool)
+func (_EscrowService *EscrowServiceCallerSession) IsEscrowInitiated() (bool, error) {
+	return _EscrowService.Contract.IsEscrowInitiated(&_EscrowService.CallOpts)
+}
+
+// IsExecuted is a free data retrieval call binding the contract method 0x1c9feaa5.
+//
+// Solidity: function isExecuted() view returns(bool)
+func (_EscrowService *EscrowServiceCaller) IsExecuted(opts *bind.CallOpts) (bool, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "isExecuted")
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// IsExecuted is a free data retrieval call binding the contract method 0x1c9feaa5.
+//
+// Solidity: function isExecuted() view returns(bool)
+func (_EscrowService *EscrowServiceSession) IsExecuted() (bool, error) {
+	return _EscrowService.Contract.IsExecuted(&_EscrowService.CallOpts)
+}
+
+// IsExecuted is a free data retrieval call binding the contract method 0x1c9feaa5.
+//
+// Solidity: function isExecuted() view returns(bool)
+func (_EscrowService *EscrowServiceCallerSession) IsExecuted() (bool, error) {
+	return _EscrowService.Contract.IsExecuted(&_EscrowService.CallOpts)
+}
+
+// IsReceived is a free data retrieval call binding the contract method 0xad0123ee.
+//
+// Solidity: function isReceived() view returns(bool)
+func (_EscrowService *EscrowServiceCaller) IsReceived(opts *bind.CallOpts) (bool, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "isReceived")
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// IsReceived is a free data retrieval call binding the contract method 0xad0123ee.
+//
+// Solidity: function isReceived() view returns(bool)
+func (_EscrowService *EscrowServiceSession) IsReceived() (bool, error) {
+	return _EscrowService.Contract.IsReceived(&_EscrowService.CallOpts)
+}
+
+// IsReceived is a free data retrieval call binding the contract method 0xad0123ee.
+//
+// Solidity: function isReceived() view returns(bool)
+func (_EscrowService *EscrowServiceCallerSession) IsReceived() (bool, error) {
+	return _EscrowService.Contract.IsReceived(&_EscrowService.CallOpts)
+}
+
+// IsRefunded is a free data retrieval call binding the contract method 0x779cd083.
+//
+// Solidity: function isRefunded() view returns(bool)
+func (_EscrowService *EscrowServiceCaller) IsRefunded(opts *bind.CallOpts) (bool, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "isRefunded")
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// IsRefunded is a free data retrieval call binding the contract method 0x779cd083.
+//
+// Solidity: function isRefunded() view returns(bool)
+func (_EscrowService *EscrowServiceSession) IsRefunded() (bool, error) {
+	return _EscrowService.Contract.IsRefunded(&_EscrowService.CallOpts)
+}
+
+// IsRefunded is a free data retrieval call binding the contract method 0x779cd083.
+//
+// Solidity: function isRefunded() view returns(bool)
+func (_EscrowService *EscrowServiceCallerSession) IsRefunded() (bool, error) {
+	return _EscrowService.Contract.IsRefunded(&_EscrowService.CallOpts)
+}
+
+// MilestoneCount is a free data retrieval call binding the contract method 0x0681ca55.
+//
+// Solidity: function milestoneCount() view returns(uint256)
+func (_EscrowService *EscrowServiceCaller) MilestoneCount(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "milestoneCount")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// MilestoneCount is a free data retrieval call binding the contract method 0x0681ca55.
+//
+// Solidity: function milestoneCount() view returns(uint256)
+func (_EscrowService *EscrowServiceSession) MilestoneCount() (*big.Int, error) {
+	return _EscrowService.Contract.MilestoneCount(&_EscrowService.CallOpts)
+}
+
+// MilestoneCount is a free data retrieval call binding the contract method 0x0681ca55.
+//
+// Solidity: function milestoneCount() view returns(uint256)
+func (_EscrowService *EscrowServiceCallerSession) MilestoneCount() (*big.Int, error) {
+	return _EscrowService.Contract.MilestoneCount(&_EscrowService.CallOpts)
+}
+
+// Milestones is a free data retrieval call binding the contract method 0xe89e4ed6.
+//
+// Solidity: function milestones(uint256 ) view returns(bytes32)
+func (_EscrowService *EscrowServiceCaller) Milestones(opts *bind.CallOpts, arg0 *big.Int) ([32]byte, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "milestones", arg0)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// Milestones is a free data retrieval call binding the contract method 0xe89e4ed6.
+//
+// Solidity: function milestones(uint256 ) view returns(bytes32)
+func (_EscrowService *EscrowServiceSession) Milestones(arg0 *big.Int) ([32]byte, error) {
+	return _EscrowService.Contract.Milestones(&_EscrowService.CallOpts, arg0)
+}
+
+// Milestones is a free data retrieval call binding the contract method 0xe89e4ed6.
+//
+// Solidity: function milestones(uint256 ) view returns(bytes32)
+func (_EscrowService *EscrowServiceCallerSession) Milestones(arg0 *big.Int) ([32]byte, error) {
+	return _EscrowService.Contract.Milestones(&_EscrowService.CallOpts, arg0)
+}
+
+// Operator is a free data retrieval call binding the contract method 0x570ca735.
+//
+// Solidity: function operator() view returns(address)
+func (_EscrowService *EscrowServiceCaller) Operator(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "operator")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Operator is a free data retrieval call binding the contract method 0x570ca735.
+//
+// Solidity: function operator() view returns(address)
+func (_EscrowService *EscrowServiceSession) Operator() (common.Address, error) {
+	return _EscrowService.Contract.Operator(&_EscrowService.CallOpts)
+}
+
+// Operator is a free data retrieval call binding the contract method 0x570ca735.
+//
+// Solidity: function operator() view returns(address)
+func (_EscrowService *EscrowServiceCallerSession) Operator() (common.Address, error) {
+	return _EscrowService.Contract.Operator(&_EscrowService.CallOpts)
+}
+
+// PaymentAmount is a free data retrieval call binding the contract method 0xc35905c6.
+//
+// Solidity: function paymentAmount() view returns(uint256)
+func (_EscrowService *EscrowServiceCaller) PaymentAmount(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "paymentAmount")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// PaymentAmount is a free data retrieval call binding the contract method 0xc35905c6.
+//
+// Solidity: function paymentAmount() view returns(uint256)
+func (_EscrowService *EscrowServiceSession) PaymentAmount() (*big.Int, error) {
+	return _EscrowService.Contract.PaymentAmount(&_EscrowService.CallOpts)
+}
+
+// PaymentAmount is a free data retrieval call binding the contract method 0xc35905c6.
+//
+// Solidity: function paymentAmount() view returns(uint256)
+func (_EscrowService *EscrowServiceCallerSession) PaymentAmount() (*big.Int, error) {
+	return _EscrowService.Contract.PaymentAmount(&_EscrowService.CallOpts)
+}
+
+// Progress is a free data retrieval call binding the contract method 0x577bd336.
+//
+// Solidity: function progress() view returns(uint8)
+func (_EscrowService *EscrowServiceCaller) Progress(opts *bind.CallOpts) (uint8, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "progress")
+
+	if err != nil {
+		return *new(uint8), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint8)).(*uint8)
+
+	return out0, err
+
+}
+
+// Progress is a free data retrieval call binding the contract method 0x577bd336.
+//
+// Solidity: function progress() view returns(uint8)
+func (_EscrowService *EscrowServiceSession) Progress() (uint8, error) {
+	return _EscrowService.Contract.Progress(&_EscrowService.CallOpts)
+}
+
+// Progress is a free data retrieval call binding the contract method 0x577bd336.
+//
+// Solidity: function progress() view returns(uint8)
+func (_EscrowService *EscrowServiceCallerSession) Progress() (uint8, error) {
+	return _EscrowService.Contract.Progress(&_EscrowService.CallOpts)
+}
+
+// Resolution is a free data retrieval call binding the contract method 0x71e21495.
+//
+// Solidity: function resolution() view returns(string)
+func (_EscrowService *EscrowServiceCaller) Resolution(opts *bind.CallOpts) (string, error) {
+	var out []interface{}
+	err := _EscrowService.contract.Call(opts, &out, "resolution")
+
+	if err != nil {
+		return *new(string), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(string)).(*string)
+
+	return out0, err
+
+}
+
+// Resolution is a free data retrieval call binding the contract method 0x71e21495.
+//
+// Solidity: function resolution() view returns(string)
+func (_EscrowService *EscrowServiceSession) Resolution() (string, error) {
+	return _EscrowService.Contract.Resolution(&_EscrowService.CallOpts)
+}
+
+// Resolution is a free data retrieval call binding the contract method 0x71e21495.
+//
+// Solidity: function resolution() view returns(string)
+func (_EscrowService *EscrowServiceCallerSession) Resolution() (string, error) {
+	return _EscrowService.Contract.Resolution(&_EscrowService.CallOpts)
+}
+
+// ConfirmReqs is a paid mutator transaction binding the contract method 0xb48b6b42.
+//
+// Solidity: function confirmReqs() returns()
+func (_EscrowService *EscrowServiceTransactor) ConfirmReqs(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "confirmReqs")
+}
+
+// ConfirmReqs is a paid mutator transaction binding the contract method 0xb48b6b42.
+//
+// Solidity: function confirmReqs() returns()
+func (_EscrowService *EscrowServiceSession) ConfirmReqs() (*types.Transaction, error) {
+	return _EscrowService.Contract.ConfirmReqs(&_EscrowService.TransactOpts)
+}
+
+// ConfirmReqs is a paid mutator transaction binding the contract method 0xb48b6b42.
+//
+// Solidity: function confirmReqs() returns()
+func (_EscrowService *EscrowServiceTransactorSession) ConfirmReqs() (*types.Transaction, error) {
+	return _EscrowService.Contract.ConfirmReqs(&_EscrowService.TransactOpts)
+}
+
+// ExecuteContract is a paid mutator transaction binding the contract method 0xaedb1c8f.
+//
+// Solidity: function executeContract() returns()
+func (_EscrowService *EscrowServiceTransactor) ExecuteContract(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "executeContract")
+}
+
+// ExecuteContract is a paid mutator transaction binding the contract method 0xaedb1c8f.
+//
+// Solidity: function executeContract() returns()
+func (_EscrowService *EscrowServiceSession) ExecuteContract() (*types.Transaction, error) {
+	return _EscrowService.Contract.ExecuteContract(&_EscrowService.TransactOpts)
+}
+
+// ExecuteContract is a paid mutator transaction binding the contract method 0xaedb1c8f.
+//
+// Solidity: function executeContract() returns()
+func (_EscrowService *EscrowServiceTransactorSession) ExecuteContract() (*types.Transaction, error) {
+	return _EscrowService.Contract.ExecuteContract(&_EscrowService.TransactOpts)
+}
+
+// InitiateDispute is a paid mutator transaction binding the contract method 0x4acc296f.
+//
+// Solidity: function initiateDispute() returns()
+func (_EscrowService *EscrowServiceTransactor) InitiateDispute(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "initiateDispute")
+}
+
+// InitiateDispute is a paid mutator transaction binding the contract method 0x4acc296f.
+//
+// Solidity: function initiateDispute() returns()
+func (_EscrowService *EscrowServiceSession) InitiateDispute() (*types.Transaction, error) {
+	return _EscrowService.Contract.InitiateDispute(&_EscrowService.TransactOpts)
+}
+
+// InitiateDispute is a paid mutator transaction binding the contract method 0x4acc296f.
+//
+// Solidity: function initiateDispute() returns()
+func (_EscrowService *EscrowServiceTransactorSession) InitiateDispute() (*types.Transaction, error) {
+	return _EscrowService.Contract.InitiateDispute(&_EscrowService.TransactOpts)
+}
+
+// InitiateEscrow is a paid mutator transaction binding the contract method 0x52389b39.
+//
+// Solidity: function initiateEscrow() payable returns()
+func (_EscrowService *EscrowServiceTransactor) InitiateEscrow(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "initiateEscrow")
+}
+
+// InitiateEscrow is a paid mutator transaction binding the contract method 0x52389b39.
+//
+// Solidity: function initiateEscrow() payable returns()
+func (_EscrowService *EscrowServiceSession) InitiateEscrow() (*types.Transaction, error) {
+	return _EscrowService.Contract.InitiateEscrow(&_EscrowService.TransactOpts)
+}
+
+// InitiateEscrow is a paid mutator transaction binding the contract method 0x52389b39.
+//
+// Solidity: function initiateEscrow() payable returns()
+func (_EscrowService *EscrowServiceTransactorSession) InitiateEscrow() (*types.Transaction, error) {
+	return _EscrowService.Contract.InitiateEscrow(&_EscrowService.TransactOpts)
+}
+
+// MarkRequirementsComplete is a paid mutator transaction binding the contract method 0xe2e4827d.
+//
+// Solidity: function markRequirementsComplete() returns()
+func (_EscrowService *EscrowServiceTransactor) MarkRequirementsComplete(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "markRequirementsComplete")
+}
+
+// MarkRequirementsComplete is a paid mutator transaction binding the contract method 0xe2e4827d.
+//
+// Solidity: function markRequirementsComplete() returns()
+func (_EscrowService *EscrowServiceSession) MarkRequirementsComplete() (*types.Transaction, error) {
+	return _EscrowService.Contract.MarkRequirementsComplete(&_EscrowService.TransactOpts)
+}
+
+// MarkRequirementsComplete is a paid mutator transaction binding the contract method 0xe2e4827d.
+//
+// Solidity: function markRequirementsComplete() returns()
+func (_EscrowService *EscrowServiceTransactorSession) MarkRequirementsComplete() (*types.Transaction, error) {
+	return _EscrowService.Contract.MarkRequirementsComplete(&_EscrowService.TransactOpts)
+}
+
+// Refund is a paid mutator transaction binding the contract method 0x590e1ae3.
+//
+// Solidity: function refund() returns()
+func (_EscrowService *EscrowServiceTransactor) Refund(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "refund")
+}
+
+// Refund is a paid mutator transaction binding the contract method 0x590e1ae3.
+//
+// Solidity: function refund() returns()
+func (_EscrowService *EscrowServiceSession) Refund() (*types.Transaction, error) {
+	return _EscrowService.Contract.Refund(&_EscrowService.TransactOpts)
+}
+
+// Refund is a paid mutator transaction binding the contract method 0x590e1ae3.
+//
+// Solidity: function refund() returns()
+func (_EscrowService *EscrowServiceTransactorSession) Refund() (*types.Transaction, error) {
+	return _EscrowService.Contract.Refund(&_EscrowService.TransactOpts)
+}
+
+// Release is a paid mutator transaction binding the contract method 0x0357371d.
+//
+// Solidity: function release(address to, uint256 amount) returns()
+func (_EscrowService *EscrowServiceTransactor) Release(opts *bind.TransactOpts, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "release", to, amount)
+}
+
+// Release is a paid mutator transaction binding the contract method 0x0357371d.
+//
+// Solidity: function release(address to, uint256 amount) returns()
+func (_EscrowService *EscrowServiceSession) Release(to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _EscrowService.Contract.Release(&_EscrowService.TransactOpts, to, amount)
+}
+
+// Release is a paid mutator transaction binding the contract method 0x0357371d.
+//
+// Solidity: function release(address to, uint256 amount) returns()
+func (_EscrowService *EscrowServiceTransactorSession) Release(to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _EscrowService.Contract.Release(&_EscrowService.TransactOpts, to, amount)
+}
+
+// ResolveDispute is a paid mutator transaction binding the contract method 0x8f2238ba.
+//
+// Solidity: function resolveDispute(string _resolution) returns()
+func (_EscrowService *EscrowServiceTransactor) ResolveDispute(opts *bind.TransactOpts, _resolution string) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "resolveDispute", _resolution)
+}
+
+// ResolveDispute is a paid mutator transaction binding the contract method 0x8f2238ba.
+//
+// Solidity: function resolveDispute(string _resolution) returns()
+func (_EscrowService *EscrowServiceSession) ResolveDispute(_resolution string) (*types.Transaction, error) {
+	return _EscrowService.Contract.ResolveDispute(&_EscrowService.TransactOpts, _resolution)
+}
+
+// ResolveDispute is a paid mutator transaction binding the contract method 0x8f2238ba.
+//
+// Solidity: function resolveDispute(string _resolution) returns()
+func (_EscrowService *EscrowServiceTransactorSession) ResolveDispute(_resolution string) (*types.Transaction, error) {
+	return _EscrowService.Contract.ResolveDispute(&_EscrowService.TransactOpts, _resolution)
+}
+
+// UpdateContractProgress is a paid mutator transaction binding the contract method 0x9a9fa1b2.
+//
+// Solidity: function updateContractProgress(uint8 _progress) returns()
+func (_EscrowService *EscrowServiceTransactor) UpdateContractProgress(opts *bind.TransactOpts, _progress uint8) (*types.Transaction, error) {
+	return _EscrowService.contract.Transact(opts, "updateContractProgress", _progress)
+}
+
+// UpdateContractProgress is a paid mutator transaction binding the contract method 0x9a9fa1b2.
+//
+// Solidity: function updateContractProgress(uint8 _progress) returns()
+func (_EscrowService *EscrowServiceSession) UpdateContractProgress(_progress uint8) (*types.Transaction, error) {
+	return _EscrowService.Contract.UpdateContractProgress(&_EscrowService.TransactOpts, _progress)
+}
+
+// UpdateContractProgress is a paid mutator transaction binding the contract method 0x9a9fa1b2.
+//
+// Solidity: function updateContractProgress(uint8 _progress) returns()
+func (_EscrowService *EscrowServiceTransactorSession) UpdateContractProgress(_progress uint8) (*types.Transaction, error) {
+	return _EscrowService.Contract.UpdateContractProgress(&_EscrowService.TransactOpts, _progress)
+}
+
+// EscrowServiceDisputeResolvedIterator is returned from FilterDisputeResolved and is used to iterate over the raw logs and unpacked data for DisputeResolved events raised by the EscrowService contract.
+type EscrowServiceDisputeResolvedIterator struct {
+	Event *EscrowServiceDisputeResolved // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EscrowServiceDisputeResolvedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(EscrowServiceDisputeResolved)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(EscrowServiceDisputeResolved)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EscrowServiceDisputeResolvedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EscrowServiceDisputeResolvedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// EscrowServiceDisputeResolved represents a DisputeResolved event raised by the EscrowService contract.
+type EscrowServiceDisputeResolved struct {
+	Resolver   common.Address
+	Resolution string
+	Timestamp  *big.Int
+	Raw        types.Log // Blockchain specific contextual infos
+}
+
+// FilterDisputeResolved is a free log retrieval operation binding the contract event 0x2bb24e554f2fc719dc608f85bd80ae06699f807ae3532b3d743d029e8b7136fd.
+//
+// Solidity: event DisputeResolved(address resolver, string resolution, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) FilterDisputeResolved(opts *bind.FilterOpts) (*EscrowServiceDisputeResolvedIterator, error) {
+
+	logs, sub, err := _EscrowService.contract.FilterLogs(opts, "DisputeResolved")
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceDisputeResolvedIterator{contract: _EscrowService.contract, event: "DisputeResolved", logs: logs, sub: sub}, nil
+}
+
+// WatchDisputeResolved is a free log subscription operation binding the contract event 0x2bb24e554f2fc719dc608f85bd80ae06699f807ae3532b3d743d029e8b7136fd.
+//
+// Solidity: event DisputeResolved(address resolver, string resolution, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) WatchDisputeResolved(opts *bind.WatchOpts, sink chan<- *EscrowServiceDisputeResolved) (event.Subscription, error) {
+
+	logs, sub, err := _EscrowService.contract.WatchLogs(opts, "DisputeResolved")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(EscrowServiceDisputeResolved)
+				if err := _EscrowService.contract.UnpackLog(event, "DisputeResolved", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDisputeResolved is a log parse operation binding the contract event 0x2bb24e554f2fc719dc608f85bd80ae06699f807ae3532b3d743d029e8b7136fd.
+//
+// Solidity: event DisputeResolved(address resolver, string resolution, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) ParseDisputeResolved(log types.Log) (*EscrowServiceDisputeResolved, error) {
+	event := new(EscrowServiceDisputeResolved)
+	if err := _EscrowService.contract.UnpackLog(event, "DisputeResolved", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// EscrowServiceEscrowDisputedIterator is returned from FilterEscrowDisputed and is used to iterate over the raw logs and unpacked data for EscrowDisputed events raised by the EscrowService contract.
+type EscrowServiceEscrowDisputedIterator struct {
+	Event *EscrowServiceEscrowDisputed // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EscrowServiceEscrowDisputedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(EscrowServiceEscrowDisputed)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(EscrowServiceEscrowDisputed)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EscrowServiceEscrowDisputedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EscrowServiceEscrowDisputedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// EscrowServiceEscrowDisputed represents a EscrowDisputed event raised by the EscrowService contract.
+type EscrowServiceEscrowDisputed struct {
+	Disputant common.Address
+	Timestamp *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterEscrowDisputed is a free log retrieval operation binding the contract event 0x3ed1cd097a567c5fd3f69812473d62c88fbc7ecfed2fc8c02e6cbfdd88bf1381.
+//
+// Solidity: event EscrowDisputed(address disputant, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) FilterEscrowDisputed(opts *bind.FilterOpts) (*EscrowServiceEscrowDisputedIterator, error) {
+
+	logs, sub, err := _EscrowService.contract.FilterLogs(opts, "EscrowDisputed")
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceEscrowDisputedIterator{contract: _EscrowService.contract, event: "EscrowDisputed", logs: logs, sub: sub}, nil
+}
+
+// WatchEscrowDisputed is a free log subscription operation binding the contract event 0x3ed1cd097a567c5fd3f69812473d62c88fbc7ecfed2fc8c02e6cbfdd88bf1381.
+//
+// Solidity: event EscrowDisputed(address disputant, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) WatchEscrowDisputed(opts *bind.WatchOpts, sink chan<- *EscrowServiceEscrowDisputed) (event.Subscription, error) {
+
+	logs, sub, err := _EscrowService.contract.WatchLogs(opts, "EscrowDisputed")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(EscrowServiceEscrowDisputed)
+				if err := _EscrowService.contract.UnpackLog(event, "EscrowDisputed", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseEscrowDisputed is a log parse operation binding the contract event 0x3ed1cd097a567c5fd3f69812473d62c88fbc7ecfed2fc8c02e6cbfdd88bf1381.
+//
+// Solidity: event EscrowDisputed(address disputant, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) ParseEscrowDisputed(log types.Log) (*EscrowServiceEscrowDisputed, error) {
+	event := new(EscrowServiceEscrowDisputed)
+	if err := _EscrowService.contract.UnpackLog(event, "EscrowDisputed", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// EscrowServiceEscrowInitiatedIterator is returned from FilterEscrowInitiated and is used to iterate over the raw logs and unpacked data for EscrowInitiated events raised by the EscrowService contract.
+type EscrowServiceEscrowInitiatedIterator struct {
+	Event *EscrowServiceEscrowInitiated // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EscrowServiceEscrowInitiatedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(EscrowServiceEscrowInitiated)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(EscrowServiceEscrowInitiated)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EscrowServiceEscrowInitiatedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EscrowServiceEscrowInitiatedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// EscrowServiceEscrowInitiated represents a EscrowInitiated event raised by the EscrowService contract.
+type EscrowServiceEscrowInitiated struct {
+	Client    common.Address
+	Depositor common.Address
+	Amount    *big.Int
+	Timestamp *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterEscrowInitiated is a free log retrieval operation binding the contract event 0x44016e2b266a92c3d3fd8f9a39e11feb24e3fe0d4d431df578e807f6955d1aa3.
+//
+// Solidity: event EscrowInitiated(address client, address depositor, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) FilterEscrowInitiated(opts *bind.FilterOpts) (*EscrowServiceEscrowInitiatedIterator, error) {
+
+	logs, sub, err := _EscrowService.contract.FilterLogs(opts, "EscrowInitiated")
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceEscrowInitiatedIterator{contract: _EscrowService.contract, event: "EscrowInitiated", logs: logs, sub: sub}, nil
+}
+
+// WatchEscrowInitiated is a free log subscription operation binding the contract event 0x44016e2b266a92c3d3fd8f9a39e11feb24e3fe0d4d431df578e807f6955d1aa3.
+//
+// Solidity: event EscrowInitiated(address client, address depositor, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) WatchEscrowInitiated(opts *bind.WatchOpts, sink chan<- *EscrowServiceEscrowInitiated) (event.Subscription, error) {
+
+	logs, sub, err := _EscrowService.contract.WatchLogs(opts, "EscrowInitiated")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(EscrowServiceEscrowInitiated)
+				if err := _EscrowService.contract.UnpackLog(event, "EscrowInitiated", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseEscrowInitiated is a log parse operation binding the contract event 0x44016e2b266a92c3d3fd8f9a39e11feb24e3fe0d4d431df578e807f6955d1aa3.
+//
+// Solidity: event EscrowInitiated(address client, address depositor, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) ParseEscrowInitiated(log types.Log) (*EscrowServiceEscrowInitiated, error) {
+	event := new(EscrowServiceEscrowInitiated)
+	if err := _EscrowService.contract.UnpackLog(event, "EscrowInitiated", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// EscrowServiceProgressUpdatedIterator is returned from FilterProgressUpdated and is used to iterate over the raw logs and unpacked data for ProgressUpdated events raised by the EscrowService contract.
+type EscrowServiceProgressUpdatedIterator struct {
+	Event *EscrowServiceProgressUpdated // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EscrowServiceProgressUpdatedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(EscrowServiceProgressUpdated)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(EscrowServiceProgressUpdated)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EscrowServiceProgressUpdatedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EscrowServiceProgressUpdatedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// EscrowServiceProgressUpdated represents a ProgressUpdated event raised by the EscrowService contract.
+type EscrowServiceProgressUpdated struct {
+	Progress  uint8
+	Timestamp *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterProgressUpdated is a free log retrieval operation binding the contract event 0x1fd2ca4c3fd1bd39ad4895f8af6bd917cfce5f0bc8ef02cc4f05d900b85dba3b.
+//
+// Solidity: event ProgressUpdated(uint8 progress, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) FilterProgressUpdated(opts *bind.FilterOpts) (*EscrowServiceProgressUpdatedIterator, error) {
+
+	logs, sub, err := _EscrowService.contract.FilterLogs(opts, "ProgressUpdated")
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceProgressUpdatedIterator{contract: _EscrowService.contract, event: "ProgressUpdated", logs: logs, sub: sub}, nil
+}
+
+// WatchProgressUpdated is a free log subscription operation binding the contract event 0x1fd2ca4c3fd1bd39ad4895f8af6bd917cfce5f0bc8ef02cc4f05d900b85dba3b.
+//
+// Solidity: event ProgressUpdated(uint8 progress, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) WatchProgressUpdated(opts *bind.WatchOpts, sink chan<- *EscrowServiceProgressUpdated) (event.Subscription, error) {
+
+	logs, sub, err := _EscrowService.contract.WatchLogs(opts, "ProgressUpdated")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(EscrowServiceProgressUpdated)
+				if err := _EscrowService.contract.UnpackLog(event, "ProgressUpdated", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseProgressUpdated is a log parse operation binding the contract event 0x1fd2ca4c3fd1bd39ad4895f8af6bd917cfce5f0bc8ef02cc4f05d900b85dba3b.
+//
+// Solidity: event ProgressUpdated(uint8 progress, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) ParseProgressUpdated(log types.Log) (*EscrowServiceProgressUpdated, error) {
+	event := new(EscrowServiceProgressUpdated)
+	if err := _EscrowService.contract.UnpackLog(event, "ProgressUpdated", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// EscrowServiceReceiptConfirmedIterator is returned from FilterReceiptConfirmed and is used to iterate over the raw logs and unpacked data for ReceiptConfirmed events raised by the EscrowService contract.
+type EscrowServiceReceiptConfirmedIterator struct {
+	Event *EscrowServiceReceiptConfirmed // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EscrowServiceReceiptConfirmedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(EscrowServiceReceiptConfirmed)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(EscrowServiceReceiptConfirmed)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EscrowServiceReceiptConfirmedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EscrowServiceReceiptConfirmedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// EscrowServiceReceiptConfirmed represents a ReceiptConfirmed event raised by the EscrowService contract.
+type EscrowServiceReceiptConfirmed struct {
+	Confirmer common.Address
+	Amount    *big.Int
+	Timestamp *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterReceiptConfirmed is a free log retrieval operation binding the contract event 0xf58913360ed003f62f26482e1143407aa56543f43fb90827c8aecd7c87ae139c.
+//
+// Solidity: event ReceiptConfirmed(address confirmer, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) FilterReceiptConfirmed(opts *bind.FilterOpts) (*EscrowServiceReceiptConfirmedIterator, error) {
+
+	logs, sub, err := _EscrowService.contract.FilterLogs(opts, "ReceiptConfirmed")
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceReceiptConfirmedIterator{contract: _EscrowService.contract, event: "ReceiptConfirmed", logs: logs, sub: sub}, nil
+}
+
+// WatchReceiptConfirmed is a free log subscription operation binding the contract event 0xf58913360ed003f62f26482e1143407aa56543f43fb90827c8aecd7c87ae139c.
+//
+// Solidity: event ReceiptConfirmed(address confirmer, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) WatchReceiptConfirmed(opts *bind.WatchOpts, sink chan<- *EscrowServiceReceiptConfirmed) (event.Subscription, error) {
+
+	logs, sub, err := _EscrowService.contract.WatchLogs(opts, "ReceiptConfirmed")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(EscrowServiceReceiptConfirmed)
+				if err := _EscrowService.contract.UnpackLog(event, "ReceiptConfirmed", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseReceiptConfirmed is a log parse operation binding the contract event 0xf58913360ed003f62f26482e1143407aa56543f43fb90827c8aecd7c87ae139c.
+//
+// Solidity: event ReceiptConfirmed(address confirmer, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) ParseReceiptConfirmed(log types.Log) (*EscrowServiceReceiptConfirmed, error) {
+	event := new(EscrowServiceReceiptConfirmed)
+	if err := _EscrowService.contract.UnpackLog(event, "ReceiptConfirmed", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// EscrowServiceRefundedIterator is returned from FilterRefunded and is used to iterate over the raw logs and unpacked data for Refunded events raised by the EscrowService contract.
+type EscrowServiceRefundedIterator struct {
+	Event *EscrowServiceRefunded // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EscrowServiceRefundedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(EscrowServiceRefunded)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(EscrowServiceRefunded)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EscrowServiceRefundedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EscrowServiceRefundedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// EscrowServiceRefunded represents a Refunded event raised by the EscrowService contract.
+type EscrowServiceRefunded struct {
+	Client    common.Address
+	Amount    *big.Int
+	Timestamp *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterRefunded is a free log retrieval operation binding the contract event 0x2dc8e290002f06fc0085bbca9dfb8b415cf4d1178950c72ff9ee8f4d8878ee66.
+//
+// Solidity: event Refunded(address client, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) FilterRefunded(opts *bind.FilterOpts) (*EscrowServiceRefundedIterator, error) {
+
+	logs, sub, err := _EscrowService.contract.FilterLogs(opts, "Refunded")
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceRefundedIterator{contract: _EscrowService.contract, event: "Refunded", logs: logs, sub: sub}, nil
+}
+
+// WatchRefunded is a free log subscription operation binding the contract event 0x2dc8e290002f06fc0085bbca9dfb8b415cf4d1178950c72ff9ee8f4d8878ee66.
+//
+// Solidity: event Refunded(address client, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) WatchRefunded(opts *bind.WatchOpts, sink chan<- *EscrowServiceRefunded) (event.Subscription, error) {
+
+	logs, sub, err := _EscrowService.contract.WatchLogs(opts, "Refunded")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(EscrowServiceRefunded)
+				if err := _EscrowService.contract.UnpackLog(event, "Refunded", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseRefunded is a log parse operation binding the contract event 0x2dc8e290002f06fc0085bbca9dfb8b415cf4d1178950c72ff9ee8f4d8878ee66.
+//
+// Solidity: event Refunded(address client, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) ParseRefunded(log types.Log) (*EscrowServiceRefunded, error) {
+	event := new(EscrowServiceRefunded)
+	if err := _EscrowService.contract.UnpackLog(event, "Refunded", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// EscrowServiceReleasedIterator is returned from FilterReleased and is used to iterate over the raw logs and unpacked data for Released events raised by the EscrowService contract.
+type EscrowServiceReleasedIterator struct {
+	Event *EscrowServiceReleased // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EscrowServiceReleasedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(EscrowServiceReleased)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(EscrowServiceReleased)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EscrowServiceReleasedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EscrowServiceReleasedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// EscrowServiceReleased represents a Released event raised by the EscrowService contract.
+type EscrowServiceReleased struct {
+	To        common.Address
+	Amount    *big.Int
+	Timestamp *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterReleased is a free log retrieval operation binding the contract event 0x82e416ba72d10e709b5de7ac16f5f49ff1d94f22d55bf582d353d3c313a1e8dd.
+//
+// Solidity: event Released(address to, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) FilterReleased(opts *bind.FilterOpts) (*EscrowServiceReleasedIterator, error) {
+
+	logs, sub, err := _EscrowService.contract.FilterLogs(opts, "Released")
+	if err != nil {
+		return nil, err
+	}
+	return &EscrowServiceReleasedIterator{contract: _EscrowService.contract, event: "Released", logs: logs, sub: sub}, nil
+}
+
+// WatchReleased is a free log subscription operation binding the contract event 0x82e416ba72d10e709b5de7ac16f5f49ff1d94f22d55bf582d353d3c313a1e8dd.
+//
+// Solidity: event Released(address to, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) WatchReleased(opts *bind.WatchOpts, sink chan<- *EscrowServiceReleased) (event.Subscription, error) {
+
+	logs, sub, err := _EscrowService.contract.WatchLogs(opts, "Released")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(EscrowServiceReleased)
+				if err := _EscrowService.contract.UnpackLog(event, "Released", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseReleased is a log parse operation binding the contract event 0x82e416ba72d10e709b5de7ac16f5f49ff1d94f22d55bf582d353d3c313a1e8dd.
+//
+// Solidity: event Released(address to, uint256 amount, uint256 timestamp)
+func (_EscrowService *EscrowServiceFilterer) ParseReleased(log types.Log) (*EscrowServiceReleased, error) {
+	event := new(EscrowServiceReleased)
+	if err := _EscrowService.contract.UnpackLog(event, "Released", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,137 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// Methods and events the Interactor and indexer rely on, with the state mutability methods need
+var (
+	requiredMethods = map[string]string{
+		"executeContract()":             "nonpayable",
+		"initiateEscrow()":              "payable",
+		"markRequirementsComplete()":    "nonpayable",
+		"confirmReqs()":                 "nonpayable",
+		"initiateDispute()":             "nonpayable",
+		"resolveDispute(string)":        "nonpayable",
+		"updateContractProgress(uint8)": "nonpayable",
+		"release(address,uint256)":      "nonpayable",
+		"refund()":                      "nonpayable",
+	}
+	requiredEvents = []string{
+		"EscrowInitiated(address,address,uint256,uint256)",
+		"ReceiptConfirmed(address,uint256,uint256)",
+		"EscrowDisputed(address,uint256)",
+		"DisputeResolved(address,string,uint256)",
+	}
+)
+
+func parseABI(t *testing.T, definition string) abi.ABI {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+	return parsed
+}
+
+// Lists every method, event and the constructor of an ABI in a comparable form
+func signatures(parsed abi.ABI) []string {
+	var sigs []string
+	for _, method := range parsed.Methods {
+		sigs = append(sigs, fmt.Sprintf("function %s %s returns %v", method.Sig, method.StateMutability, method.Outputs))
+	}
+	for _, event := range parsed.Events {
+		sigs = append(sigs, fmt.Sprintf("event %s anonymous=%t", event.Sig, event.Anonymous))
+	}
+	sigs = append(sigs, fmt.Sprintf("constructor %v %s", parsed.Constructor.Inputs, parsed.Constructor.StateMutability))
+	sort.Strings(sigs)
+	return sigs
+}
+
+func TestBindingsAreUpToDate(t *testing.T) {
+	hash, err := SourceHash("EscrowService.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != EscrowServiceSourceHash {
+		t.Fatalf("EscrowService.sol changed since its bindings were generated, run go generate ./pkg/smart-contract/contract")
+	}
+}
+
+func TestBindingsCoverInteractor(t *testing.T) {
+	parsed := parseABI(t, EscrowServiceMetaData.ABI)
+
+	methods := map[string]abi.Method{}
+	for _, method := range parsed.Methods {
+		methods[method.Sig] = method
+	}
+	for sig, mutability := range requiredMethods {
+		method, ok := methods[sig]
+		if !ok {
+			t.Errorf("method %s is missing from the bindings", sig)
+			continue
+		}
+		if method.StateMutability != mutability {
+			t.Errorf("method %s is %s, want %s", sig, method.StateMutability, mutability)
+		}
+	}
+
+	events := map[string]bool{}
+	for _, event := range parsed.Events {
+		events[event.Sig] = true
+	}
+	for _, sig := range requiredEvents {
+		if !events[sig] {
+			t.Errorf("event %s is missing from the bindings", sig)
+		}
+	}
+
+	// The selectors recorded alongside the ABI must be the ones the ABI produces
+	for _, method := range parsed.Methods {
+		selector := fmt.Sprintf("%x", method.ID)
+		if EscrowServiceMetaData.Sigs[selector] != method.Sig {
+			t.Errorf("selector %s is recorded as %q, want %q", selector, EscrowServiceMetaData.Sigs[selector], method.Sig)
+		}
+	}
+}
+
+func TestBindingsMatchSource(t *testing.T) {
+	hash, err := SourceHash("EscrowService.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != EscrowServiceSourceHash {
+		t.Errorf("EscrowService.sol hashes to %s but the bindings were generated from %s, run go generate ./pkg/smart-contract/contract",
+			hash, EscrowServiceSourceHash)
+	}
+}
+
+func TestBindingsMatchCompiledTemplate(t *testing.T) {
+	// Without bytecode the bindings can't deploy escrows, so this fails rather than waiting for solc to be installed
+	if artifact := EscrowServiceArtifact(); artifact.ABI == "" || artifact.Bin == "" {
+		t.Fatal("bindings have no ABI or bytecode, run go generate ./pkg/smart-contract/contract")
+	}
+
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Skip("solc is not installed")
+	}
+	artifact, err := Compile(context.Background(), solc, "EscrowService.sol", "EscrowService")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := signatures(parseABI(t, artifact.ABI))
+	got := signatures(parseABI(t, EscrowServiceMetaData.ABI))
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("bindings don't match the compiled template, run go generate ./pkg/smart-contract/contract\ngot:\n%s\nwant:\n%s",
+			strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
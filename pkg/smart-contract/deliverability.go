@@ -0,0 +1,94 @@
+package smart_contract
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+)
+
+// Records a bounce or spam complaint: complaints unsubscribe the address from optional notices, and clients
+// whose address failed are flagged and their freelancer asked to fix it. Soft bounces are left to the outbox's retries.
+func RecordBounce(bounce email.Bounce) error {
+	status := db.EmailBounced
+	switch bounce.Kind {
+	case email.SoftBounce:
+		log.Printf("Soft bounce for %s: %s", bounce.Recipient, bounce.Reason)
+		return nil
+	case email.Complaint:
+		status = db.EmailComplained
+		for _, category := range email.OptionalCategories {
+			if err := db.SetNotificationPreference(bounce.Recipient, string(category), false); err != nil {
+				return err
+			}
+		}
+	}
+
+	clients, err := db.FlagClientEmail(db.DB, bounce.Recipient, status, bounce.Reason)
+	if err != nil {
+		return err
+	}
+	for _, client := range clients {
+		log.Printf("Flagged email of client %d as %s: %s", client.ID, status, bounce.Reason)
+		if err := queueClientEmailBounced(client, bounce); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Asks the freelancer to fix a flagged client address, linking the client's latest contract
+func queueClientEmailBounced(client db.Client, bounce email.Bounce) error {
+	ids, err := db.GetContractIDsByClient(client.ID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	contractID := ids[len(ids)-1]
+
+	user, err := db.GetUserByID(client.UserID)
+	if err != nil {
+		return err
+	}
+	rendered, err := email.ClientEmailBounced.Render(user.Locale, email.ClientEmailBouncedData{
+		Recipient:   email.Recipient{FirstName: user.FirstName, ContractID: contractID, DashboardLink: dashboardLink(contractID, FreelancerParty)},
+		ClientName:  client.Name,
+		ClientEmail: client.Email,
+		Complaint:   bounce.Kind == email.Complaint,
+		Reason:      bounce.Reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render client email bounced email: %v", err)
+	}
+
+	// An address can be flagged again after the freelancer fixes it, so number each occurrence
+	prefix := fmt.Sprintf("client_email_flagged:%d:", client.ID)
+	previous, err := db.CountOutboxEvents(contractID, prefix)
+	if err != nil {
+		return err
+	}
+	event := fmt.Sprintf("%s%d", prefix, previous+1)
+	return db.EnqueueEmails([]db.OutboxEmail{outboxEmail(contractID, event, user.Email, FreelancerParty, rendered)})
+}
+
+// Changes a client's email address, clearing any bounce or complaint flag on the old one
+func UpdateClientEmail(clientID int, address string) (*db.Client, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address %q: %v", address, err)
+	}
+
+	client, err := db.GetClientByID(db.DB, clientID)
+	if err != nil {
+		return nil, err
+	}
+	client.Email, client.EmailStatus, client.EmailIssue = parsed.Address, db.EmailOK, ""
+	if err := db.UpdateClient(db.DB, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,137 @@
+package smart_contract
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/smart-contract/contract"
+)
+
+// Deploys escrow contracts on-chain, implemented by the Interactor
+type EscrowDeployer interface {
+	Deploy(ctx context.Context, contractID int, artifact contract.Artifact, args ...interface{}) (string, error)
+	WaitDeployed(ctx context.Context, txHash string) (*contract.Deployment, error)
+	ExecuteContract(ctx context.Context, contractAddress string) error
+}
+
+// Deploys an escrow contract for each confirmed contract, committing it to the client's wallet, the amount due,
+// the deadline and the milestones agreed
+type DeploymentService struct {
+	Deployer EscrowDeployer
+	Artifact contract.Artifact
+	Currency money.Currency // The network's native coin, which escrowed amounts must be priced in
+}
+
+// Deploys every confirmed contract that has no escrow yet, then repeats every interval until ctx is cancelled
+func (s *DeploymentService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ids, err := db.GetUndeployedContractIDs(string(ContractConfirmed))
+		if err != nil {
+			log.Printf("Error loading undeployed contracts: %v", err)
+		}
+		for _, id := range ids {
+			if _, err := s.Deploy(ctx, id); err != nil {
+				log.Printf("Error deploying contract %d: %v", id, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deploys a contract's escrow, or resumes waiting for a deployment sent before a restart, and stores where it was deployed
+func (s *DeploymentService) Deploy(ctx context.Context, contractID int) (*contract.Deployment, error) {
+	c, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Address != "" {
+		return nil, fmt.Errorf("contract %d is already deployed at %s", contractID, c.Address)
+	}
+
+	txHash := c.DeployTxHash
+	if txHash == "" {
+		args, err := s.constructorArgs(c)
+		if err != nil {
+			return nil, err
+		}
+		if txHash, err = s.Deployer.Deploy(ctx, contractID, s.Artifact, args...); err != nil {
+			return nil, err
+		}
+		if err := db.SetContractDeployTx(contractID, txHash); err != nil {
+			return nil, err
+		}
+	}
+
+	deployment, err := s.Deployer.WaitDeployed(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	// The escrow only accepts deposits once executed, so its address is stored, and deposits to it watched for, afterwards
+	if err := s.Deployer.ExecuteContract(ctx, deployment.Address); err != nil {
+		return nil, err
+	}
+	if err := db.SetContractDeployment(contractID, deployment.Address, deployment.TxHash, deployment.BlockNumber); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Deployed contract %d at %s in block %d", contractID, deployment.Address, deployment.BlockNumber)
+	return deployment, nil
+}
+
+// Returns the escrow's constructor arguments: the client's wallet, the amount due in base units, the deadline
+// as a Unix timestamp and the hashes of the milestones in order
+func (s *DeploymentService) constructorArgs(c *db.Contract) ([]interface{}, error) {
+	client, err := db.GetClientByID(db.DB, c.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(client.Wallet) {
+		return nil, fmt.Errorf("client %d has no wallet address", client.ID)
+	}
+
+	amount := c.Fees.Gross
+	if amount.Sign() <= 0 {
+		return nil, fmt.Errorf("contract %d has not been quoted yet", c.ID)
+	}
+	if amount.Currency != s.Currency {
+		return nil, fmt.Errorf("contract %d is priced in %s, not %s", c.ID, amount.Currency.Code, s.Currency.Code)
+	}
+	if err := checkQuote(c.ID); err != nil {
+		return nil, err
+	}
+	if c.Deadline.IsZero() {
+		return nil, fmt.Errorf("contract %d has no deadline", c.ID)
+	}
+
+	milestones, err := db.GetContractMilestones(c.ID)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([][32]byte, len(milestones))
+	for i, milestone := range milestones {
+		hashes[i] = MilestoneHash(milestone.Description)
+	}
+
+	return []interface{}{common.HexToAddress(client.Wallet), amount.Units, big.NewInt(c.Deadline.Unix()), hashes}, nil
+}
+
+// Returns the hash a milestone is committed to in the escrow contract
+func MilestoneHash(description string) [32]byte {
+	return crypto.Keccak256Hash([]byte(description))
+}
@@ -0,0 +1,338 @@
+package smart_contract
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/smart-contract/contract"
+)
+
+// Reads the logs of escrow contracts, implemented by *ethclient.Client and the simulated backend's client
+type LogReader interface {
+	ethereum.LogFilterer
+	ethereum.BlockNumberReader
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// The escrow events that move a contract through its statuses
+var indexedEvents = []string{"EscrowInitiated", "ReceiptConfirmed", "EscrowDisputed", "DisputeResolved"}
+
+// The most blocks read in one query, which RPC providers limit
+const maxLogRange = 2000
+
+// Follows the events of every deployed escrow so that actions taken on-chain, e.g. a client depositing or
+// disputing directly from their wallet, move the contract through its statuses. Events are stored as soon as
+// they are seen, but only applied once buried under the network's confirmations; unconfirmed events are
+// rolled back and read again when a reorg replaces the blocks they were in
+type EventIndexer struct {
+	Reader        LogReader
+	Confirmations uint64
+}
+
+// Indexes every deployed contract still in progress, then repeats every interval until ctx is cancelled
+func (x *EventIndexer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ids, err := db.GetDeployedContractIDs()
+		if err != nil {
+			log.Printf("Error loading deployed contracts: %v", err)
+		}
+		for _, id := range ids {
+			if err := x.Index(ctx, id); err != nil {
+				log.Printf("Error indexing events of contract %d: %v", id, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reads a contract's new escrow events and applies those that are now confirmed
+func (x *EventIndexer) Index(ctx context.Context, contractID int) error {
+	c, err := db.GetContractByID(contractID)
+	if err != nil {
+		return err
+	}
+	if c.Address == "" {
+		return fmt.Errorf("contract %d has not been deployed yet", contractID)
+	}
+	switch ContractStatus(c.Status) {
+	case PaymentReleased, Cancelled, Refunded:
+		return nil
+	}
+
+	cursor, err := db.GetContractEventCursor(contractID)
+	if err != nil {
+		return err
+	}
+	if cursor == nil {
+		// Nothing can be emitted before the escrow was deployed
+		cursor = &db.ContractEventCursor{ContractID: contractID, NextBlock: c.DeployBlock, ConfirmedBlock: c.DeployBlock}
+	}
+
+	head, err := x.Reader.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %v", err)
+	}
+
+	reorged, err := x.reorged(ctx, cursor)
+	if err != nil {
+		return err
+	}
+	if reorged {
+		log.Printf("Chain reorganized below block %d, rolling back unconfirmed events of contract %d", cursor.NextBlock, contractID)
+		if err := db.RollBackContractEvents(contractID); err != nil {
+			return err
+		}
+		cursor.NextBlock, cursor.LastHash = cursor.ConfirmedBlock, ""
+	}
+
+	if cursor.NextBlock <= head {
+		if err := x.read(ctx, c.Address, cursor, min(head, cursor.NextBlock+maxLogRange-1)); err != nil {
+			return err
+		}
+	}
+	return x.confirm(contractID, cursor, head)
+}
+
+// Reports whether the last block read is no longer part of the chain
+func (x *EventIndexer) reorged(ctx context.Context, cursor *db.ContractEventCursor) (bool, error) {
+	if cursor.LastHash == "" || cursor.NextBlock == 0 {
+		return false, nil
+	}
+	header, err := x.Reader.HeaderByNumber(ctx, new(big.Int).SetUint64(cursor.NextBlock-1))
+	if errors.Is(err, ethereum.NotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get block %d: %v", cursor.NextBlock-1, err)
+	}
+	return header.Hash().Hex() != cursor.LastHash, nil
+}
+
+// Stores the escrow's events between the cursor and toBlock as unconfirmed, and moves the cursor past them
+func (x *EventIndexer) read(ctx context.Context, address string, cursor *db.ContractEventCursor, toBlock uint64) error {
+	escrowABI, err := contract.EscrowServiceMetaData.GetAbi()
+	if err != nil {
+		return fmt.Errorf("failed to parse escrow ABI: %v", err)
+	}
+	var topics []common.Hash
+	for _, name := range indexedEvents {
+		topics = append(topics, escrowABI.Events[name].ID)
+	}
+
+	// The logs are only kept if the block they were read up to is the same before and after reading them
+	before, err := x.Reader.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return fmt.Errorf("failed to get block %d: %v", toBlock, err)
+	}
+	logs, err := x.Reader.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(cursor.NextBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: []common.Address{common.HexToAddress(address)},
+		Topics:    [][]common.Hash{topics},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get escrow logs: %v", err)
+	}
+	after, err := x.Reader.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return fmt.Errorf("failed to get block %d: %v", toBlock, err)
+	}
+	if before.Hash() != after.Hash() {
+		return fmt.Errorf("chain reorganized at block %d while reading events", toBlock)
+	}
+
+	var events []db.ContractEvent
+	for _, entry := range logs {
+		if entry.Removed {
+			continue
+		}
+		event, err := decodeEscrowEvent(escrowABI, entry)
+		if err != nil {
+			return err
+		}
+		events = append(events, *event)
+	}
+
+	next := &db.ContractEventCursor{
+		ContractID:     cursor.ContractID,
+		NextBlock:      toBlock + 1,
+		LastHash:       after.Hash().Hex(),
+		ConfirmedBlock: cursor.ConfirmedBlock,
+	}
+	if err := db.SaveContractEvents(next, events); err != nil {
+		return err
+	}
+	*cursor = *next
+	return nil
+}
+
+// Applies the events buried under the network's confirmations to the contract's status, in the order they were emitted
+func (x *EventIndexer) confirm(contractID int, cursor *db.ContractEventCursor, head uint64) error {
+	confirmations := max(x.Confirmations, 1)
+	if head+1 < confirmations || cursor.NextBlock == 0 {
+		return nil
+	}
+	// Only blocks that have been read can be confirmed
+	confirmed := min(head+1-confirmations, cursor.NextBlock-1)
+	if confirmed < cursor.ConfirmedBlock {
+		return nil
+	}
+
+	events, err := db.GetUnconfirmedContractEvents(contractID, confirmed)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := applyEscrowEvent(contractID, event); err != nil {
+			return err
+		}
+		if err := db.ConfirmContractEvent(event.ID); err != nil {
+			return err
+		}
+	}
+	if err := db.SetContractEventsConfirmed(contractID, confirmed+1); err != nil {
+		return err
+	}
+	cursor.ConfirmedBlock = confirmed + 1
+	return nil
+}
+
+// Moves a contract to the status a confirmed escrow event puts it in, posting the deposit an EscrowInitiated event
+// records in the same transaction. Events repeating a status the contract is already in, e.g. because we sent the
+// transaction ourselves, don't move it, nor do events its status can't follow, but their deposits are still posted.
+// Once the client's receipt is confirmed the escrow can be released, so the contract goes on to be executed
+func applyEscrowEvent(contractID int, event db.ContractEvent) error {
+	var next ContractStatus
+	var entries []*ledger.Entry
+	switch event.Name {
+	case "EscrowInitiated":
+		next = PaymentMade
+		entry, err := depositEntry(contractID, event)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	case "ReceiptConfirmed":
+		next = ReqsCompleted
+	case "EscrowDisputed":
+		next = Disputed
+	case "DisputeResolved":
+		// Resolving a dispute returns the escrow to where it was before, which is complete if receipt was confirmed
+		received, err := db.HasConfirmedContractEvent(contractID, "ReceiptConfirmed")
+		if err != nil {
+			return err
+		}
+		next = PaymentMade
+		if received {
+			next = ReqsCompleted
+		}
+	default:
+		return nil
+	}
+
+	current, err := GetCurrentContractStatus(contractID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case current != next && CanTransition(current, next):
+		log.Printf("Contract %d's escrow emitted %s in %s", contractID, event.Name, event.TxHash)
+		if err := updateContractStatus(contractID, next, entries...); err != nil {
+			return err
+		}
+		current = next
+	case current != next:
+		log.Printf("Ignoring %s of contract %d in %s: it can't move from %s to %s", event.Name, contractID, event.TxHash, current, next)
+		fallthrough
+	default:
+		// Posting an entry a payment provider already posted for the same transfer is a no-op
+		for _, entry := range entries {
+			if err := ledger.Post(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	if current == ReqsCompleted && next == ReqsCompleted {
+		log.Printf("Contract %d's receipt is confirmed, executing it", contractID)
+		return updateContractStatus(contractID, ContractExecuted)
+	}
+	return nil
+}
+
+// Builds the ledger entry for a deposit an EscrowInitiated event records, or nil if it deposited nothing. It is
+// posted under the reference the crypto payment provider uses for the same transfer, so it is only counted once
+func depositEntry(contractID int, event db.ContractEvent) (*ledger.Entry, error) {
+	var fields struct {
+		Amount *big.Int `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(event.Data), &fields); err != nil || fields.Amount == nil {
+		return nil, fmt.Errorf("failed to decode the amount of %s in %s: %v", event.Name, event.TxHash, err)
+	}
+	if fields.Amount.Sign() == 0 {
+		return nil, nil
+	}
+
+	c, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Fees.Gross.Units == nil {
+		return nil, fmt.Errorf("contract %d has no quoted currency to record its deposit in", contractID)
+	}
+	reference := fmt.Sprintf("chain:%s:%d", strings.ToLower(event.TxHash), event.LogIndex)
+	return ledger.PaymentEntry(contractID, money.New(fields.Amount, c.Fees.Gross.Currency), reference), nil
+}
+
+// Decodes an escrow log into the event stored for it
+func decodeEscrowEvent(escrowABI *abi.ABI, entry types.Log) (*db.ContractEvent, error) {
+	if len(entry.Topics) == 0 {
+		return nil, fmt.Errorf("log %s:%d has no topics", entry.TxHash.Hex(), entry.Index)
+	}
+	definition, err := escrowABI.EventByID(entry.Topics[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify log %s:%d: %v", entry.TxHash.Hex(), entry.Index, err)
+	}
+
+	fields := map[string]interface{}{}
+	if err := escrowABI.UnpackIntoMap(fields, definition.Name, entry.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode %s in %s: %v", definition.Name, entry.TxHash.Hex(), err)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s fields: %v", definition.Name, err)
+	}
+
+	return &db.ContractEvent{
+		Name:        definition.Name,
+		TxHash:      entry.TxHash.Hex(),
+		LogIndex:    entry.Index,
+		BlockNumber: entry.BlockNumber,
+		BlockHash:   entry.BlockHash.Hex(),
+		Data:        string(data),
+	}, nil
+}
@@ -0,0 +1,190 @@
+//go:build integration
+
+// Runs the EventIndexer against go-ethereum's simulated backend:
+//
+//	go test -tags integration ./pkg/smart-contract
+//
+// Go 1.23 and later also need -ldflags=-checklinkname=0 to link the simulated node.
+package smart_contract
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/smart-contract/contract"
+)
+
+// Deploys a stand-in for the escrow that emits EscrowInitiated(caller, caller, value, timestamp) whenever it is
+// called, so the indexer can be tested without the compiled template. Its runtime code is
+//
+//	CALLER PUSH1 0 MSTORE CALLER PUSH1 0x20 MSTORE CALLVALUE PUSH1 0x40 MSTORE TIMESTAMP PUSH1 0x60 MSTORE
+//	PUSH32 <topic> PUSH1 0x80 PUSH1 0 LOG1 STOP
+//
+// copied into memory and returned by an 11 byte constructor
+func deployDepositEmitter(t *testing.T, backend *simulated.Backend, key *ecdsa.PrivateKey) common.Address {
+	t.Helper()
+	escrowABI, err := contract.EscrowServiceMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic := escrowABI.Events["EscrowInitiated"].ID
+	runtime := append(common.FromHex("0x33600052336020523460405242606052"+"7f"), topic.Bytes()...)
+	runtime = append(runtime, common.FromHex("0x60806000a100")...)
+	code := append(common.FromHex(fmt.Sprintf("0x60%02x80600b6000396000f3", len(runtime))), runtime...)
+
+	tx := sendTx(t, backend, key, nil, big.NewInt(0), code)
+	backend.Commit()
+	receipt, err := backend.Client().TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("failed to deploy the emitter: %v", err)
+	}
+	return receipt.ContractAddress
+}
+
+// Signs and sends a transaction from the key's account
+func sendTx(t *testing.T, backend *simulated.Backend, key *ecdsa.PrivateKey, to *common.Address, value *big.Int, data []byte) *types.Transaction {
+	t.Helper()
+	ctx := context.Background()
+	nonce, err := backend.Client().PendingNonceAt(ctx, crypto.PubkeyToAddress(key.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := params.AllDevChainProtocolChanges.ChainID
+	tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(100 * params.GWei),
+		Gas:       200000,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	}), types.NewLondonSigner(chainID), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.Client().SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// Creates a confirmed contract whose escrow is deployed at address
+func confirmedContract(t *testing.T, address common.Address, block uint64) int {
+	t.Helper()
+	contractID := newContract(t, ContractConfirmed)
+	if err := db.SetContractDeployment(contractID, address.Hex(), "0x01", block); err != nil {
+		t.Fatal(err)
+	}
+	return contractID
+}
+
+func TestIndexerRereadsDepositAfterReorg(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+	})
+	t.Cleanup(func() { backend.Close() })
+	ctx := context.Background()
+	reader := backend.Client()
+
+	escrow := deployDepositEmitter(t, backend, key)
+	deployed, err := reader.BlockNumber(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contractID := confirmedContract(t, escrow, deployed)
+	indexer := &EventIndexer{Reader: reader, Confirmations: 3}
+
+	// The deposit is read as soon as it is mined, but not applied until it is confirmed
+	parent, err := reader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposit := sendTx(t, backend, key, &escrow, big.NewInt(params.Ether), nil)
+	backend.Commit()
+	if err := indexer.Index(ctx, contractID); err != nil {
+		t.Fatal(err)
+	}
+	before := unconfirmedEvents(t, contractID)
+	if len(before) != 1 || before[0].Name != "EscrowInitiated" {
+		t.Fatalf("read %+v, want the deposit", before)
+	}
+
+	// A longer fork mines the same deposit a block later
+	if err := backend.Fork(parent.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+	if err := backend.Client().SendTransaction(ctx, deposit); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+	if err := indexer.Index(ctx, contractID); err != nil {
+		t.Fatal(err)
+	}
+	after := unconfirmedEvents(t, contractID)
+	if len(after) != 1 {
+		t.Fatalf("read %+v after the reorg, want the deposit once", after)
+	}
+	if after[0].BlockHash == before[0].BlockHash || after[0].BlockNumber != before[0].BlockNumber+1 {
+		t.Errorf("deposit still in block %d %s after the reorg", after[0].BlockNumber, after[0].BlockHash)
+	}
+	expectStatus(t, contractID, ContractConfirmed)
+
+	// Once confirmed, the deposit moves the contract and is posted under the transfer's reference
+	backend.Commit()
+	backend.Commit()
+	if err := indexer.Index(ctx, contractID); err != nil {
+		t.Fatal(err)
+	}
+	if events := unconfirmedEvents(t, contractID); len(events) != 0 {
+		t.Errorf("%d events left unconfirmed", len(events))
+	}
+	expectStatus(t, contractID, PaymentMade)
+
+	reference := fmt.Sprintf("chain:%s:%d", strings.ToLower(deposit.Hash().Hex()), after[0].LogIndex)
+	if posted, err := db.LedgerEntryPosted(reference); err != nil || !posted {
+		t.Errorf("deposit not posted as %s (%v)", reference, err)
+	}
+	balance, err := ledger.Balance(contractID, ledger.ClientDeposits, money.ETH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := money.New(big.NewInt(-params.Ether), money.ETH); balance.String() != want.String() {
+		t.Errorf("client deposits balance %s, want %s", balance, want)
+	}
+
+	// Applying the deposit again, e.g. after the crypto provider posted it, doesn't count it twice
+	if err := applyEscrowEvent(contractID, after[0]); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := ledger.Balance(contractID, ledger.ClientDeposits, money.ETH); err != nil || again.String() != balance.String() {
+		t.Errorf("client deposits balance %s after applying the deposit twice (%v)", again, err)
+	}
+}
+
+func unconfirmedEvents(t *testing.T, contractID int) []db.ContractEvent {
+	t.Helper()
+	events, err := db.GetUnconfirmedContractEvents(contractID, 1<<62)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
@@ -0,0 +1,29 @@
+package smart_contract
+
+import (
+	"fmt"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+	"smart_contract/pkg/invoice"
+)
+
+// Issues the invoice or receipt for a contract and returns it with its PDF as an email attachment
+func issueDocument(contractID int, kind string) (*db.Invoice, email.Attachment, error) {
+	issue := invoice.IssueInvoice
+	if kind == invoice.KindReceipt {
+		issue = invoice.IssueReceipt
+	}
+
+	document, err := issue(contractID)
+	if err != nil {
+		return nil, email.Attachment{}, fmt.Errorf("failed to issue %s: %v", kind, err)
+	}
+
+	attachment := email.Attachment{
+		Filename:    fmt.Sprintf("%s.pdf", document.Number),
+		ContentType: "application/pdf",
+		Data:        document.PDF,
+	}
+	return document, attachment, nil
+}
@@ -0,0 +1,63 @@
+package smart_contract
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/fees"
+	"smart_contract/pkg/money"
+)
+
+func TestMain(m *testing.M) {
+	// Invoices and emails are rendered from templates relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// Creates a contract in status between a freelancer and client, quoted at 1 ETH with no fees, in a fresh database
+func newContract(t *testing.T, status ContractStatus) int {
+	t.Helper()
+	if err := db.Initialize(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+
+	if err := db.CreateUser(db.User{FirstName: "Fran", Email: "fran@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := db.GetUserByEmail("fran@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &db.Client{UserID: user.ID, Name: "Cleo", Email: "cleo@example.com"}
+	if err := db.CreateClient(db.DB, client); err != nil {
+		t.Fatal(err)
+	}
+	contractID, err := db.CreateContract(&db.Contract{ClientID: client.ID, Description: "logo design", Status: string(status)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gross := money.New(big.NewInt(1e18), money.ETH)
+	breakdown := fees.Breakdown{Gross: gross, PlatformFee: money.Zero(money.ETH), NetworkFee: money.Zero(money.ETH), Net: gross}
+	if err := db.SaveContractFees(contractID, breakdown); err != nil {
+		t.Fatal(err)
+	}
+	return contractID
+}
+
+func expectStatus(t *testing.T, contractID int, want ContractStatus) {
+	t.Helper()
+	status, err := GetCurrentContractStatus(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != want {
+		t.Errorf("contract is %s, want %s", status, want)
+	}
+}
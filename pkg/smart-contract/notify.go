@@ -0,0 +1,243 @@
+package smart_contract
+
+import (
+	"fmt"
+	"log"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+	"smart_contract/pkg/invoice"
+	"smart_contract/pkg/ledger"
+)
+
+// Base URL of the web app, used to build payment and dashboard links in emails
+var BaseURL = "http://localhost:8080"
+
+// Reply-to addresses routing replies back to their contract; emails have no Reply-To when nil
+var Replies *email.ReplyAddresses
+
+// Signs the unsubscribe links on optional notices; they carry no List-Unsubscribe header when nil
+var Unsubscribes *email.UnsubscribeLinks
+
+// Signs the dashboard links through which each party acts on a contract; parties cannot act on contracts when nil
+var PartyLinks *email.PartyLinks
+
+// Renders an email for one recipient of a contract in their locale
+type renderFunc func(locale string, recipient email.Recipient) (*email.Rendered, error)
+
+// Adapts a typed template to a renderFunc, building its data for each recipient
+func withTemplate[T any](tmpl email.Template[T], data func(recipient email.Recipient) T) renderFunc {
+	return func(locale string, recipient email.Recipient) (*email.Rendered, error) {
+		return tmpl.Render(locale, data(recipient))
+	}
+}
+
+// Queues the email telling the client that the freelancer has initiated an escrow, with links to pay and to their Dashboard
+func QueueInitiationEmail(contractID int, requirements string) error {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return err
+	}
+	client, err := db.GetClientByID(db.DB, contract.ClientID)
+	if err != nil {
+		return err
+	}
+	user, err := db.GetUserByID(client.UserID)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := email.GenerateEmail(email.EmailData{
+		ClientFirstName: client.Name,
+		UserFirstName:   user.FirstName,
+		Requirements:    requirements,
+		PaymentLink:     fmt.Sprintf("%s/request_payment?contract_id=%d", BaseURL, contractID),
+		DashboardLink:   dashboardLink(contractID, ClientParty),
+		Locale:          client.Locale,
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.EnqueueEmails([]db.OutboxEmail{outboxEmail(contractID, "initiated", client.Email, ClientParty, rendered)})
+}
+
+func dashboardLink(contractID int, party Party) string {
+	if PartyLinks != nil {
+		return PartyLinks.Dashboard(contractID, string(party))
+	}
+	return fmt.Sprintf("%s/contracts/%d", BaseURL, contractID)
+}
+
+// Renders the emails announcing a status transition, choosing the template from the statuses moved between.
+// Transitions without a template return no emails.
+func transitionEmails(contractID int, from, to ContractStatus) ([]db.OutboxEmail, error) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	var render renderFunc
+	var attachments []email.Attachment
+	switch {
+	case to == ContractConfirmed:
+		data := email.RequirementsConfirmedData{AmountDue: contract.Fees.Gross}
+		if quote, err := db.GetLatestQuote(contractID); err == nil {
+			data.Quoted, data.ExpiresAt = &quote.Quoted, quote.ExpiresAt
+		}
+		render = withTemplate(email.RequirementsConfirmed, func(r email.Recipient) email.RequirementsConfirmedData {
+			data.Recipient = r
+			return data
+		})
+
+	case from == Disputed && (to == PaymentMade || to == ReqsCompleted):
+		render = withTemplate(email.DisputeResolved, func(r email.Recipient) email.DisputeResolvedData {
+			return email.DisputeResolvedData{Recipient: r, RequirementsCompleted: to == ReqsCompleted}
+		})
+
+	case to == PaymentMade:
+		document, attachment, err := issueDocument(contractID, invoice.KindInvoice)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+		render = withTemplate(email.PaymentReceived, func(r email.Recipient) email.PaymentReceivedData {
+			return email.PaymentReceivedData{Recipient: r, Amount: contract.Fees.Gross, InvoiceNumber: document.Number}
+		})
+
+	case to == ReqsCompleted:
+		render = withTemplate(email.MilestoneCompleted, func(r email.Recipient) email.MilestoneCompletedData {
+			return email.MilestoneCompletedData{Recipient: r}
+		})
+
+	case to == Disputed:
+		render = withTemplate(email.DisputeOpened, func(r email.Recipient) email.DisputeOpenedData {
+			return email.DisputeOpenedData{Recipient: r}
+		})
+
+	case to == PaymentReleased:
+		document, attachment, err := issueDocument(contractID, invoice.KindReceipt)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+		breakdown, err := contract.SettledFees()
+		if err != nil {
+			return nil, err
+		}
+		render = withTemplate(email.FundsReleased, func(r email.Recipient) email.FundsReleasedData {
+			return email.FundsReleasedData{Recipient: r, Amount: breakdown.Net, ReceiptNumber: document.Number}
+		})
+
+	case to == Refunded:
+		refunded, err := ledger.Balance(contractID, ledger.Refunds, contract.Fees.Gross.Currency)
+		if err != nil {
+			return nil, err
+		}
+		render = withTemplate(email.RefundIssued, func(r email.Recipient) email.RefundIssuedData {
+			return email.RefundIssuedData{Recipient: r, Amount: refunded}
+		})
+
+	case to == Cancelled:
+		render = withTemplate(email.ContractCancelled, func(r email.Recipient) email.ContractCancelledData {
+			return email.ContractCancelledData{Recipient: r}
+		})
+
+	default:
+		return nil, nil
+	}
+
+	// A contract can pass through the same status more than once, e.g. repeated disputes, so number each occurrence
+	previous, err := db.CountOutboxEvents(contractID, statusEvent(to)+":")
+	if err != nil {
+		return nil, err
+	}
+	event := fmt.Sprintf("%s:%d", statusEvent(to), previous+1)
+	return partyEmails(contractID, event, render, attachments...)
+}
+
+// Event name for the emails announcing a status change
+func statusEvent(status ContractStatus) string {
+	return "status:" + string(status)
+}
+
+// Queues emails to both parties of a contract for an event that doesn't change its status
+func notifyParties(contractID int, event string, render renderFunc, attachments ...email.Attachment) {
+	emails, err := partyEmails(contractID, event, render, attachments...)
+	if err == nil {
+		err = db.EnqueueEmails(emails)
+	}
+	if err != nil {
+		log.Printf("Error queueing %s emails for contract %d: %v", event, contractID, err)
+	}
+}
+
+// Renders an email for the client and the freelancer of a contract, addressing each by name
+func partyEmails(contractID int, event string, render renderFunc, attachments ...email.Attachment) ([]db.OutboxEmail, error) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := db.GetClientByID(db.DB, contract.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := db.GetUserByID(client.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var emails []db.OutboxEmail
+	for _, recipient := range []struct {
+		address, name, locale string
+		party                 Party
+	}{
+		{client.Email, client.Name, client.Locale, ClientParty},
+		{user.Email, user.FirstName, user.Locale, FreelancerParty},
+	} {
+		// Mail to an address that bounced or complained hurts delivery to everyone, so wait for the freelancer to fix it
+		if recipient.party == ClientParty && client.EmailStatus != db.EmailOK {
+			log.Printf("Not emailing %s about contract %d: address %s", event, contractID, client.EmailStatus)
+			continue
+		}
+
+		rendered, err := render(recipient.locale, email.Recipient{
+			FirstName:     recipient.name,
+			ContractID:    contractID,
+			DashboardLink: dashboardLink(contractID, recipient.party),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s email: %v", event, err)
+		}
+		if rendered.Category != email.Transactional {
+			unsubscribed, err := db.IsUnsubscribed(recipient.address, string(rendered.Category))
+			if err != nil {
+				return nil, err
+			}
+			if unsubscribed {
+				continue
+			}
+		}
+		emails = append(emails, outboxEmail(contractID, event, recipient.address, recipient.party, rendered, attachments...))
+	}
+	return emails, nil
+}
+
+func outboxEmail(contractID int, event, to string, party Party, rendered *email.Rendered, attachments ...email.Attachment) db.OutboxEmail {
+	e := db.OutboxEmail{
+		ContractID:  contractID,
+		Event:       event,
+		Recipient:   to,
+		Subject:     rendered.Subject,
+		Text:        rendered.Text,
+		HTML:        rendered.HTML,
+		Attachments: attachments,
+	}
+	if Replies != nil {
+		e.ReplyTo = Replies.For(contractID, string(party))
+	}
+	if Unsubscribes != nil && rendered.Category != email.Transactional {
+		e.Unsubscribe = Unsubscribes.For(to, rendered.Category)
+	}
+	return e
+}
@@ -0,0 +1,75 @@
+package smart_contract
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+)
+
+// Returns the emails queued for a contract's event, keyed by recipient
+func queuedEmails(t *testing.T, contractID int, event string) map[string]db.OutboxEmail {
+	t.Helper()
+	due, err := db.GetDueEmails(time.Now().Add(time.Hour), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emails := make(map[string]db.OutboxEmail)
+	for _, e := range due {
+		if e.ContractID == contractID && e.Event == event {
+			emails[e.Recipient] = e
+		}
+	}
+	return emails
+}
+
+func TestTransitionEmailsBothParties(t *testing.T) {
+	PartyLinks = &email.PartyLinks{BaseURL: "https://tronch.test", Secret: []byte("secret")}
+	t.Cleanup(func() { PartyLinks = nil })
+	contractID := newContract(t, PaymentMade)
+
+	if err := UpdateContractStatus(contractID, ReqsCompleted); err != nil {
+		t.Fatal(err)
+	}
+	emails := queuedEmails(t, contractID, "status:reqs_completed:1")
+	if len(emails) != 2 {
+		t.Fatalf("queued %v, want an email to each party", emails)
+	}
+
+	// Each party gets a dashboard link that signs them in as themselves
+	for recipient, party := range map[string]Party{"cleo@example.com": ClientParty, "fran@example.com": FreelancerParty} {
+		link := PartyLinks.Dashboard(contractID, string(party))
+		if e := emails[recipient]; !strings.Contains(e.Text, link) {
+			t.Errorf("email to %s doesn't link to %s:\n%s", recipient, link, e.Text)
+		}
+	}
+
+	// Passing through a status again is announced again, here as the dispute being resolved
+	if err := UpdateContractStatus(contractID, Disputed); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateContractStatus(contractID, ReqsCompleted); err != nil {
+		t.Fatal(err)
+	}
+	again := queuedEmails(t, contractID, "status:reqs_completed:2")
+	if len(again) != 2 || again["cleo@example.com"].Subject == emails["cleo@example.com"].Subject {
+		t.Errorf("queued %v after the dispute was resolved, want a resolution email to each party", again)
+	}
+}
+
+func TestTransitionEmailsSkipBouncedClient(t *testing.T) {
+	contractID := newContract(t, PaymentMade)
+	if _, err := db.FlagClientEmail(db.DB, "cleo@example.com", db.EmailBounced, "550 no such user"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateContractStatus(contractID, ReqsCompleted); err != nil {
+		t.Fatal(err)
+	}
+	emails := queuedEmails(t, contractID, "status:reqs_completed:1")
+	if _, ok := emails["cleo@example.com"]; ok || len(emails) != 1 {
+		t.Errorf("queued %v, want only the freelancer's email", emails)
+	}
+}
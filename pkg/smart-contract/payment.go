@@ -0,0 +1,206 @@
+package smart_contract
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/payment"
+)
+
+// Creates a payment intent for the contract's quoted gross amount with the given provider
+func RequestPayment(ctx context.Context, provider payment.Provider, contractID int) (*payment.Intent, error) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Fees.Gross.Sign() <= 0 {
+		return nil, fmt.Errorf("contract %d has not been quoted yet", contractID)
+	}
+	if err := checkQuote(contractID); err != nil {
+		return nil, err
+	}
+
+	intent, err := provider.CreateIntent(ctx, contractID, contract.Fees.Gross)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment intent: %v", err)
+	}
+
+	err = db.CreatePaymentIntent(&db.PaymentIntent{
+		ID:          intent.ID,
+		Provider:    intent.Provider,
+		ContractID:  intent.ContractID,
+		Amount:      intent.Amount,
+		Status:      string(intent.Status),
+		PaymentLink: intent.PaymentLink,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return intent, nil
+}
+
+// Records a provider's payment event and moves the contract to PaymentMade once paid. The contract, amount and
+// currency come from the stored intent; an event that does not match it is rejected
+func HandlePaymentEvent(ctx context.Context, event *payment.Event) error {
+	intent, err := db.GetPaymentIntentByID(event.IntentID)
+	if err != nil {
+		return err
+	}
+	if intent.Provider != event.Provider {
+		return fmt.Errorf("payment intent %s belongs to %s, not %s", intent.ID, intent.Provider, event.Provider)
+	}
+	if intent.ContractID != event.ContractID {
+		return fmt.Errorf("payment intent %s is for contract %d, not %d", intent.ID, intent.ContractID, event.ContractID)
+	}
+
+	if event.Type == payment.EventFailed {
+		log.Printf("Payment %s for contract %d failed", intent.ID, intent.ContractID)
+		if intent.Status != string(payment.IntentPending) {
+			return nil
+		}
+		return db.UpdatePaymentIntentStatus(intent.ID, string(payment.IntentFailed))
+	}
+
+	// A replayed event has already been credited; only the status change may still be outstanding
+	reference := event.Reference
+	if reference == "" {
+		reference = event.Provider + ":" + event.ID
+	}
+	posted, err := db.LedgerEntryPosted(reference)
+	if err != nil {
+		return err
+	}
+	if !posted {
+		if err := creditPaymentIntent(intent, event, reference); err != nil {
+			return err
+		}
+	}
+
+	intent, err = db.GetPaymentIntentByID(intent.ID)
+	if err != nil {
+		return err
+	}
+	if intent.Status != string(payment.IntentSucceeded) {
+		return nil
+	}
+
+	current, err := GetCurrentContractStatus(intent.ContractID)
+	if err != nil {
+		return err
+	}
+	if !CanTransition(current, PaymentMade) {
+		return nil
+	}
+	return UpdateContractStatus(intent.ContractID, PaymentMade)
+}
+
+// Checks that an event pays the intent's amount in full, then moves the funds into escrow and updates the intent together
+func creditPaymentIntent(intent *db.PaymentIntent, event *payment.Event, reference string) error {
+	if event.Amount.Sign() <= 0 || event.Amount.Currency != intent.Amount.Currency {
+		return fmt.Errorf("payment of %s does not match intent %s for %s", event.Amount, intent.ID, intent.Amount)
+	}
+
+	received, err := intent.Received.Add(event.Amount)
+	if err != nil {
+		return err
+	}
+	// Escrows only accept the amount they were deployed with, all at once
+	if after, _ := received.Cmp(intent.Amount); event.Type != payment.EventSucceeded || after != 0 {
+		return fmt.Errorf("%s of %s does not match intent %s, which has received %s of %s",
+			event.Type, event.Amount, intent.ID, intent.Received, intent.Amount)
+	}
+
+	entry, err := ledger.PaymentEntry(intent.ContractID, event.Amount, reference).Record()
+	if err != nil {
+		return err
+	}
+	_, err = db.CreditPaymentIntent(intent.ID, string(payment.IntentSucceeded), received, entry)
+	return err
+}
+
+// Resolves the deposit address for a contract, which is its deployed escrow contract
+func EscrowDepositAddress(ctx context.Context, contractID int) (string, error) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return "", err
+	}
+	if contract.Address == "" {
+		return "", fmt.Errorf("contract %d has not been deployed yet", contractID)
+	}
+	return contract.Address, nil
+}
+
+// Stores a provider event and dispatches it unless it was already processed, so webhook retries are harmless
+func ProcessPaymentEvent(ctx context.Context, registry *payment.Registry, event *payment.Event, payload []byte) error {
+	_, err := db.InsertPaymentEvent(&db.PaymentEvent{
+		Provider:   event.Provider,
+		EventID:    event.ID,
+		Type:       event.Type,
+		IntentID:   event.IntentID,
+		ContractID: event.ContractID,
+		Status:     string(event.Status),
+		Amount:     event.Amount,
+		Reference:  event.Reference,
+		Payload:    payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	stored, err := db.GetPaymentEvent(event.Provider, event.ID)
+	if err != nil {
+		return err
+	}
+	if stored.Processed {
+		log.Printf("Skipping already processed %s event %s", event.Provider, event.ID)
+		return nil
+	}
+
+	return dispatchPaymentEvent(ctx, registry, event)
+}
+
+// Dispatches stored payment events again, e.g. after fixing a bug in their handling
+func ReplayPaymentEvents(ctx context.Context, registry *payment.Registry, provider string, includeProcessed bool) (int, error) {
+	stored, err := db.ListPaymentEvents(provider, !includeProcessed)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, s := range stored {
+		event := &payment.Event{
+			ID:         s.EventID,
+			Provider:   s.Provider,
+			Type:       s.Type,
+			IntentID:   s.IntentID,
+			ContractID: s.ContractID,
+			Status:     payment.IntentStatus(s.Status),
+			Amount:     s.Amount,
+			Reference:  s.Reference,
+		}
+		if err := dispatchPaymentEvent(ctx, registry, event); err != nil {
+			log.Printf("Error replaying %s event %s: %v", s.Provider, s.EventID, err)
+			continue
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// Dispatches an event and records whether it succeeded
+func dispatchPaymentEvent(ctx context.Context, registry *payment.Registry, event *payment.Event) error {
+	dispatchErr := registry.Dispatch(ctx, event)
+
+	errMsg := ""
+	if dispatchErr != nil {
+		errMsg = dispatchErr.Error()
+	}
+	if err := db.MarkPaymentEventProcessed(event.Provider, event.ID, errMsg); err != nil {
+		return err
+	}
+	return dispatchErr
+}
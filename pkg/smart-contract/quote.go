@@ -0,0 +1,163 @@
+package smart_contract
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/email"
+	"smart_contract/pkg/fees"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/rates"
+)
+
+// Confirms a contract's requirements, locking the exchange rate used to convert its price into payIn
+func ConfirmContract(ctx context.Context, contractID int, source rates.Source, payIn money.Currency, ttl time.Duration) error {
+	status, err := GetCurrentContractStatus(contractID)
+	if err != nil {
+		return err
+	}
+	if status != AwaitingConfirmation {
+		return fmt.Errorf("contract %d is already %s", contractID, status)
+	}
+
+	if _, err := lockQuote(ctx, contractID, source, payIn, ttl); err != nil {
+		return err
+	}
+	return UpdateContractStatus(contractID, ContractConfirmed)
+}
+
+// Locks a fresh rate for a confirmed contract whose quote expired before the client paid
+func RefreshQuote(ctx context.Context, contractID int, source rates.Source, ttl time.Duration) (*db.Quote, error) {
+	status, err := GetCurrentContractStatus(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if status != ContractConfirmed {
+		return nil, fmt.Errorf("contract %d is %s; only confirmed contracts can be requoted", contractID, status)
+	}
+	// The deployed escrow only accepts the amount it was deployed with
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Address != "" {
+		return nil, fmt.Errorf("contract %d is already deployed with its quoted amount", contractID)
+	}
+
+	current, err := db.GetLatestQuote(contractID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().Before(current.ExpiresAt) {
+		return nil, fmt.Errorf("the quote for contract %d is locked until %s", contractID, current.ExpiresAt.Format(time.RFC3339))
+	}
+	return lockQuote(ctx, contractID, source, current.Amount.Currency, ttl)
+}
+
+// Emails both parties of each confirmed contract whose quote expires within the given window, once per quote.
+// Deployed contracts are skipped since their escrow has locked the amount.
+func RemindExpiringQuotes(within time.Duration) error {
+	ids, err := db.GetUndeployedContractIDs(string(ContractConfirmed))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		quote, err := db.GetLatestQuote(id)
+		if err != nil {
+			continue
+		}
+		remaining := time.Until(quote.ExpiresAt)
+		if remaining <= 0 || remaining > within {
+			continue
+		}
+
+		notifyParties(id, fmt.Sprintf("quote_expiring:%d", quote.ID), withTemplate(email.ContractExpiring, func(r email.Recipient) email.ContractExpiringData {
+			return email.ContractExpiringData{Recipient: r, AmountDue: quote.Amount, ExpiresAt: quote.ExpiresAt}
+		}))
+	}
+	return nil
+}
+
+// Returns an error if the contract's price was converted at a rate that has since expired,
+// unless its escrow was already deployed and so holds the client to the amount quoted
+func checkQuote(contractID int) error {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return err
+	}
+	if contract.Address != "" {
+		return nil
+	}
+
+	quote, err := db.GetLatestQuote(contractID)
+	if err != nil {
+		// Contracts priced in the currency they are paid in have no quote
+		return nil
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		return fmt.Errorf("the quote for contract %d expired at %s", contractID, quote.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Converts the contract's agreed price into payIn, re-quotes its fees in that currency and stores the rate used
+func lockQuote(ctx context.Context, contractID int, source rates.Source, payIn money.Currency, ttl time.Duration) (*db.Quote, error) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	// After the first conversion the contract's fees are in payIn, so start again from the agreed price
+	quoted := contract.Fees.Gross
+	previousID := 0
+	if previous, err := db.GetLatestQuote(contractID); err == nil {
+		quoted = previous.Quoted
+		previousID = previous.ID
+	}
+	if quoted.Currency == payIn {
+		return nil, nil
+	}
+
+	rate, err := source.Rate(ctx, quoted.Currency, payIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate: %v", err)
+	}
+	amount := quoted.Convert(rate, payIn)
+
+	client, err := db.GetClientByID(db.DB, contract.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := db.GetUserByID(client.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	feeConfig, err := fees.LoadConfig("fee_schedule.json")
+	if err != nil {
+		return nil, err
+	}
+	breakdown, err := feeConfig.QuoteAt(contract.Fees.ScheduleVersion, user.ID, user.Plan, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote fees: %v", err)
+	}
+
+	now := time.Now()
+	quote := &db.Quote{
+		ContractID: contractID,
+		Quoted:     quoted,
+		Amount:     amount,
+		Rate:       rate.FloatString(18),
+		Source:     source.Name(),
+		LockedAt:   now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	// Fails if another confirmation or re-quote, or the deployment, got to the contract since it was read
+	if quote.ID, err = db.LockQuote(quote, breakdown, contract.Status, previousID); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
@@ -0,0 +1,182 @@
+package smart_contract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/fees"
+	"smart_contract/pkg/money"
+	"smart_contract/pkg/rates"
+)
+
+// Quotes from a static table, running something else the first time a rate is asked for
+type racingSource struct {
+	rates.Source
+	during func()
+}
+
+func (s *racingSource) Rate(ctx context.Context, from, to money.Currency) (*big.Rat, error) {
+	if during := s.during; during != nil {
+		s.during = nil
+		during()
+	}
+	return s.Source.Rate(ctx, from, to)
+}
+
+// A rate provider that is down
+type failingSource struct{}
+
+func (failingSource) Name() string {
+	return "down"
+}
+
+func (failingSource) Rate(ctx context.Context, from, to money.Currency) (*big.Rat, error) {
+	return nil, fmt.Errorf("rate provider unavailable")
+}
+
+func staticSource(t *testing.T, usdToMatic string) rates.Source {
+	t.Helper()
+	source, err := rates.NewStaticSource("static", map[string]string{"USD/MATIC": usdToMatic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+// Creates a contract awaiting confirmation, priced at 100 USD
+func pricedContract(t *testing.T) int {
+	t.Helper()
+	contractID := newContract(t, AwaitingConfirmation)
+	price := money.New(big.NewInt(10000), money.USD)
+	breakdown := fees.Breakdown{ScheduleVersion: "2024-06", Gross: price, PlatformFee: money.Zero(money.USD), NetworkFee: money.Zero(money.USD), Net: price}
+	if err := db.SaveContractFees(contractID, breakdown); err != nil {
+		t.Fatal(err)
+	}
+	return contractID
+}
+
+// Checks what the contract's latest quote converted 100 USD into and that its fees were re-quoted to match
+func expectQuote(t *testing.T, contractID int, want string) *db.Quote {
+	t.Helper()
+	quote, err := db.GetLatestQuote(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount, err := money.Parse(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Quoted.String() != "100 USD" || quote.Amount.String() != amount.String() || contract.Fees.Gross.String() != amount.String() {
+		t.Errorf("quoted %s as %s and priced the contract at %s, want 100 USD as %s", quote.Quoted, quote.Amount, contract.Fees.Gross, amount)
+	}
+	return quote
+}
+
+func TestConfirmContractLocksQuote(t *testing.T) {
+	contractID := pricedContract(t)
+
+	if err := ConfirmContract(context.Background(), contractID, staticSource(t, "1.45"), money.MATIC, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, contractID, ContractConfirmed)
+	expectQuote(t, contractID, "145 MATIC")
+	if err := checkQuote(contractID); err != nil {
+		t.Errorf("fresh quote rejected: %v", err)
+	}
+
+	// A quote that is still locked can't be replaced
+	if _, err := RefreshQuote(context.Background(), contractID, staticSource(t, "1.5"), time.Hour); err == nil {
+		t.Error("requoted before the quote expired")
+	}
+	expectQuote(t, contractID, "145 MATIC")
+}
+
+func TestRefreshQuoteAfterExpiry(t *testing.T) {
+	contractID := pricedContract(t)
+
+	if err := ConfirmContract(context.Background(), contractID, staticSource(t, "1.45"), money.MATIC, -time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkQuote(contractID); err == nil {
+		t.Error("expired quote accepted")
+	}
+
+	// The agreed price is converted again, not the amount from the expired quote
+	if _, err := RefreshQuote(context.Background(), contractID, staticSource(t, "1.5"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, contractID, ContractConfirmed)
+	expectQuote(t, contractID, "150 MATIC")
+	if err := checkQuote(contractID); err != nil {
+		t.Errorf("refreshed quote rejected: %v", err)
+	}
+}
+
+func TestRefreshQuoteRejectsDeployedContract(t *testing.T) {
+	contractID := pricedContract(t)
+	if err := ConfirmContract(context.Background(), contractID, staticSource(t, "1.45"), money.MATIC, -time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetContractDeployment(contractID, "0x00000000000000000000000000000000000e5c40", "0x01", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The escrow only accepts the amount it was deployed with, however old its quote
+	if _, err := RefreshQuote(context.Background(), contractID, staticSource(t, "1.5"), time.Hour); err == nil {
+		t.Error("requoted a deployed contract")
+	}
+	expectQuote(t, contractID, "145 MATIC")
+	if err := checkQuote(contractID); err != nil {
+		t.Errorf("deployed contract's quote rejected: %v", err)
+	}
+}
+
+func TestConfirmContractRateProviderDown(t *testing.T) {
+	contractID := pricedContract(t)
+
+	if err := ConfirmContract(context.Background(), contractID, failingSource{}, money.MATIC, time.Hour); err == nil {
+		t.Fatal("confirmed without a rate")
+	}
+	expectStatus(t, contractID, AwaitingConfirmation)
+	if quote, err := db.GetLatestQuote(contractID); err == nil {
+		t.Errorf("locked %+v without a rate", quote)
+	}
+	if contract, err := db.GetContractByID(contractID); err != nil || contract.Fees.Gross.String() != "100 USD" {
+		t.Errorf("contract repriced without a rate (%v)", err)
+	}
+
+	// Once the provider is back the contract can be confirmed
+	if err := ConfirmContract(context.Background(), contractID, staticSource(t, "1.45"), money.MATIC, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	expectQuote(t, contractID, "145 MATIC")
+}
+
+func TestConcurrentConfirmationsLockOneQuote(t *testing.T) {
+	contractID := pricedContract(t)
+
+	// Another confirmation locks a quote while this one is waiting for its rate
+	var other *db.Quote
+	source := &racingSource{Source: staticSource(t, "1.45"), during: func() {
+		if err := ConfirmContract(context.Background(), contractID, staticSource(t, "1.5"), money.MATIC, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		other = expectQuote(t, contractID, "150 MATIC")
+	}}
+	if err := ConfirmContract(context.Background(), contractID, source, money.MATIC, time.Hour); err == nil {
+		t.Error("both confirmations succeeded")
+	}
+
+	expectStatus(t, contractID, ContractConfirmed)
+	if quote := expectQuote(t, contractID, "150 MATIC"); other == nil || quote.ID != other.ID {
+		t.Errorf("latest quote is %+v, want the one locked first", quote)
+	}
+}
@@ -9,6 +9,11 @@ import (
 	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
+
+	"smart_contract/pkg/db"
+	"smart_contract/pkg/fees"
+	"smart_contract/pkg/ledger"
+	"smart_contract/pkg/money"
 )
 
 // Represents the current stage/status of the contract
@@ -21,31 +26,134 @@ const (
 	ReqsCompleted        ContractStatus = "reqs_completed"
 	ContractExecuted     ContractStatus = "contract_executed"
 	PaymentReleased      ContractStatus = "payment_released"
+	Disputed             ContractStatus = "disputed"
+	Cancelled            ContractStatus = "cancelled"
+	Refunded             ContractStatus = "refunded"
 )
 
-// Initiates the contract and calculates payment amount minus our fee
-func InitiateContract(paymentAmount float64) (float64, error) {
-	// Calculate payment amount minus our fee (e.g., 5% fee)
-	ourFee := 0.05 * paymentAmount
-	netPaymentAmount := paymentAmount - ourFee
+// Initiates the contract, quoting our fee from the current fee schedule and storing the breakdown with it
+func InitiateContract(contractID int, user db.User, paymentAmount money.Amount) (fees.Breakdown, error) {
+	feeConfig, err := fees.LoadConfig("fee_schedule.json")
+	if err != nil {
+		return fees.Breakdown{}, err
+	}
+
+	breakdown, err := feeConfig.Quote(user.ID, user.Plan, paymentAmount)
+	if err != nil {
+		return fees.Breakdown{}, fmt.Errorf("failed to quote fees: %v", err)
+	}
+
+	if err := db.SaveContractFees(contractID, breakdown); err != nil {
+		return fees.Breakdown{}, err
+	}
+
+	return breakdown, nil
+}
+
+// The statuses a contract may move to from each status
+var statusTransitions = map[ContractStatus][]ContractStatus{
+	AwaitingConfirmation: {ContractConfirmed, Cancelled},
+	ContractConfirmed:    {PaymentMade, Cancelled},
+	PaymentMade:          {ReqsCompleted, Disputed, Refunded},
+	ReqsCompleted:        {ContractExecuted, Disputed},
+	Disputed:             {PaymentMade, ReqsCompleted, Refunded},
+	ContractExecuted:     {PaymentReleased},
+}
 
-	return netPaymentAmount, nil
+// Reports whether a contract may move from one status to another
+func CanTransition(from, to ContractStatus) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
 }
 
 // Retrieves the current stage/status of the contract
-func GetCurrentContractStatus() ContractStatus {
-	// TODO: Implement logic to retrieve the current status from the contract
-	return AwaitingConfirmation
+func GetCurrentContractStatus(contractID int) (ContractStatus, error) {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return "", err
+	}
+	if contract.Status == "" {
+		return AwaitingConfirmation, nil
+	}
+	return ContractStatus(contract.Status), nil
+}
+
+// UpdateContractStatus moves the contract to a new stage/status if the transition is allowed,
+// queueing the emails announcing it in the same transaction
+func UpdateContractStatus(contractID int, newStatus ContractStatus) error {
+	return updateContractStatus(contractID, newStatus)
 }
 
-// UpdateContractStatus updates the contract's current stage/status
-func UpdateContractStatus(newStatus ContractStatus) error {
-	// TODO: Implement logic to update the contract's status
+// Moves the contract to a new status, posting entries for any money the change moves in the same transaction
+func updateContractStatus(contractID int, newStatus ContractStatus, entries ...*ledger.Entry) error {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return err
+	}
+	current := ContractStatus(contract.Status)
+	if current == "" {
+		current = AwaitingConfirmation
+	}
+	if !CanTransition(current, newStatus) {
+		return fmt.Errorf("contract %d cannot move from %s to %s", contractID, current, newStatus)
+	}
+
+	var records []*db.LedgerEntry
+	for _, entry := range entries {
+		record, err := entry.Record()
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+
+	emails, err := transitionEmails(contractID, current, newStatus)
+	if err != nil {
+		return err
+	}
+	// Only moves the contract if nothing else moved it since its status was read
+	if err := db.UpdateContractStatusWithEmails(contractID, contract.Status, string(newStatus), emails, records...); err != nil {
+		return err
+	}
+
+	log.Printf("Contract %d moved from %s to %s", contractID, current, newStatus)
 	return nil
 }
 
+// Releases escrowed funds, recording our fee and the freelancer's net amount in the ledger together with the status
+func ReleaseFunds(contractID int) error {
+	contract, err := db.GetContractByID(contractID)
+	if err != nil {
+		return err
+	}
+	current := ContractStatus(contract.Status)
+	if !CanTransition(current, PaymentReleased) {
+		return fmt.Errorf("contract %d cannot move from %s to %s", contractID, current, PaymentReleased)
+	}
+
+	// The network fee, settled against the gas actually spent when the payout was created, is kept alongside our
+	// fee to cover the gas we pay
+	breakdown, err := contract.SettledFees()
+	if err != nil {
+		return err
+	}
+	fee, err := breakdown.PlatformFee.Add(breakdown.NetworkFee)
+	if err != nil {
+		return err
+	}
+
+	reference := fmt.Sprintf("contract:%d", contractID)
+	return updateContractStatus(contractID, PaymentReleased,
+		ledger.FeeEntry(contractID, fee, reference+":fee"),
+		ledger.ReleaseEntry(contractID, breakdown.Net, reference+":release"))
+}
+
 // Uses GPT-3.5 to extract requirements from user-provided parameters
-func ExtractRequirements(ctx context.Context, clientName, clientEmail string, paymentAmount float64, requirements, description string) (string, error) {
+func ExtractRequirements(ctx context.Context, clientName, clientEmail string, paymentAmount money.Amount, requirements, description string) (string, error) {
 	client := openai.NewClient(os.Getenv("OPENAI_API_KEY"))
 	if client == nil {
 		return "", fmt.Errorf("failed to create OpenAI client")
@@ -58,7 +166,7 @@ func ExtractRequirements(ctx context.Context, clientName, clientEmail string, pa
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleUser,
-					Content: fmt.Sprintf("Extract the requirements for a Solidity smart contract for an escrow service based on the following details:\n\nClient Name: %s\nClient Email: %s\nPayment Amount: %.2f ETH\nUser Requirements: %s\nDescription: %s", clientName, clientEmail, paymentAmount, requirements, description),
+					Content: fmt.Sprintf("Extract the requirements for a Solidity smart contract for an escrow service based on the following details:\n\nClient Name: %s\nClient Email: %s\nPayment Amount: %s\nUser Requirements: %s\nDescription: %s", clientName, clientEmail, paymentAmount, requirements, description),
 				},
 			},
 		},
@@ -15,7 +15,8 @@ document.addEventListener('DOMContentLoaded', function() {
             clientEmail: clientEmail,
             paymentAmount: paymentAmount,
             requirements: requirements,
-            description: description
+            description: description,
+            locale: document.documentElement.lang
         };
 
         fetch('/generate_contract', {
@@ -1,3 +1,6 @@
+//go:build ignore
+
+// Renders a sample email to email.txt: go run test.go
 package main
 
 import (